@@ -0,0 +1,389 @@
+package web3
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gochain-io/gochain/v3/accounts/abi"
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/common/hexutil"
+)
+
+// parseABI parses the JSON ABI read from r. Unlike abi.JSON, it supports tuple types.
+func parseABI(r io.Reader) (abi.ABI, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return abi.ABI{}, err
+	}
+	if !bytes.Contains(b, []byte(`"tuple`)) {
+		return abi.JSON(bytes.NewReader(b))
+	}
+	var fields []map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return abi.ABI{}, err
+	}
+	return parseTupleABI(fields)
+}
+
+// convertParameters converts params to the Go values expected by the abi package for inputs.
+// Params may be strings (as given on the command line), JSON decoded values, or values of
+// the exact Go type already. Arrays and tuples given as strings are parsed as JSON, e.g.
+// `[1,2,3]`. Tuples are given as an object keyed by component name, or as an array.
+func convertParameters(inputs abi.Arguments, params []interface{}) ([]interface{}, error) {
+	if len(inputs) != len(params) {
+		return nil, fmt.Errorf("wrong number of arguments: expected %d, given %d", len(inputs), len(params))
+	}
+	converted := make([]interface{}, len(params))
+	for i, input := range inputs {
+		v, err := convertArgument(input.Type, params[i])
+		if err != nil {
+			name := input.Name
+			if name == "" {
+				name = "<unnamed>"
+			}
+			return nil, fmt.Errorf("argument %d (%s %s): %v", i, typeString(input.Type), name, err)
+		}
+		converted[i] = v.Interface()
+	}
+	return converted, nil
+}
+
+// convertArgument converts v to a value of the Go type used by the abi package for t.
+func convertArgument(t abi.Type, v interface{}) (reflect.Value, error) {
+	if v == nil {
+		return reflect.Value{}, fmt.Errorf("missing value")
+	}
+	if reflect.TypeOf(v) == t.Type {
+		return reflect.ValueOf(v), nil
+	}
+	if s, ok := v.(string); ok && isCompositeType(t) {
+		d := json.NewDecoder(strings.NewReader(s))
+		d.UseNumber()
+		var decoded interface{}
+		if err := d.Decode(&decoded); err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid JSON %q: %v", s, err)
+		}
+		v = decoded
+	}
+	switch t.T {
+	case abi.BoolTy:
+		switch v := v.(type) {
+		case bool:
+			return reflect.ValueOf(v), nil
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("invalid bool %q", v)
+			}
+			return reflect.ValueOf(b), nil
+		}
+	case abi.IntTy, abi.UintTy:
+		s, ok := scalarString(v)
+		if !ok {
+			break
+		}
+		return convertInt(t, s)
+	case abi.AddressTy:
+		if s, ok := v.(string); ok {
+			if !common.IsHexAddress(s) {
+				return reflect.Value{}, fmt.Errorf("invalid address %q", s)
+			}
+			return reflect.ValueOf(common.HexToAddress(s)), nil
+		}
+	case abi.StringTy:
+		if s, ok := v.(string); ok {
+			return reflect.ValueOf(s), nil
+		}
+	case abi.BytesTy:
+		switch v := v.(type) {
+		case []byte:
+			return reflect.ValueOf(v), nil
+		case string:
+			b, err := hexutil.Decode(v)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("invalid hex bytes %q: %v", v, err)
+			}
+			return reflect.ValueOf(b), nil
+		}
+	case abi.FixedBytesTy, abi.FunctionTy:
+		var b []byte
+		switch v := v.(type) {
+		case []byte:
+			b = v
+		case string:
+			var err error
+			b, err = hexutil.Decode(v)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("invalid hex bytes %q: %v", v, err)
+			}
+		default:
+			return reflect.Value{}, fmt.Errorf("cannot use %T as %s", v, t)
+		}
+		// Unlike solidity literals, shorter values are not padded, since a missing byte is
+		// more likely a mistake.
+		if len(b) != t.Size {
+			return reflect.Value{}, fmt.Errorf("expected %d bytes for %s, given %d", t.Size, t, len(b))
+		}
+		arr := reflect.New(t.Type).Elem()
+		reflect.Copy(arr, reflect.ValueOf(b))
+		return arr, nil
+	case abi.SliceTy, abi.ArrayTy:
+		items, ok := v.([]interface{})
+		if rv := reflect.ValueOf(v); !ok && (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array) {
+			items, ok = make([]interface{}, rv.Len()), true
+			for i := range items {
+				items[i] = rv.Index(i).Interface()
+			}
+		}
+		if !ok {
+			break
+		}
+		var out reflect.Value
+		if t.T == abi.SliceTy {
+			out = reflect.MakeSlice(t.Type, len(items), len(items))
+		} else {
+			if len(items) != t.Size {
+				return reflect.Value{}, fmt.Errorf("expected %d elements for %s, given %d", t.Size, typeString(t), len(items))
+			}
+			out = reflect.New(t.Type).Elem()
+		}
+		for i, item := range items {
+			ev, err := convertArgument(*t.Elem, item)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("element %d: %v", i, err)
+			}
+			out.Index(i).Set(ev)
+		}
+		return out, nil
+	case tupleTy:
+		return convertTuple(t, v)
+	default:
+		return reflect.Value{}, fmt.Errorf("unsupported type %s", t)
+	}
+	return reflect.Value{}, fmt.Errorf("cannot use %T as %s", v, typeString(t))
+}
+
+// convertTuple converts v, an object keyed by component name or an array of components,
+// to a value of the tuple type t.
+func convertTuple(t abi.Type, v interface{}) (reflect.Value, error) {
+	args := tupleArgs(t)
+	items := make([]interface{}, len(args))
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for i, arg := range args {
+			if arg.Name == "" {
+				return reflect.Value{}, fmt.Errorf("unnamed components of %s must be given as an array", typeString(t))
+			}
+			item, ok := v[arg.Name]
+			if !ok {
+				return reflect.Value{}, fmt.Errorf("missing component %q", arg.Name)
+			}
+			items[i] = item
+		}
+		if len(v) != len(args) {
+			known := make(map[string]bool)
+			for _, arg := range args {
+				known[arg.Name] = true
+			}
+			var unknown []string
+			for k := range v {
+				if !known[k] {
+					unknown = append(unknown, k)
+				}
+			}
+			sort.Strings(unknown)
+			return reflect.Value{}, fmt.Errorf("unknown component %q", unknown[0])
+		}
+	case []interface{}:
+		if len(v) != len(args) {
+			return reflect.Value{}, fmt.Errorf("expected %d components for %s, given %d", len(args), typeString(t), len(v))
+		}
+		copy(items, v)
+	default:
+		return reflect.Value{}, fmt.Errorf("cannot use %T as %s", v, typeString(t))
+	}
+	out := reflect.New(t.Type).Elem()
+	for i, arg := range args {
+		ev, err := convertArgument(arg.Type, items[i])
+		if err != nil {
+			name := arg.Name
+			if name == "" {
+				name = strconv.Itoa(i)
+			}
+			return reflect.Value{}, fmt.Errorf("component %s: %v", name, err)
+		}
+		out.Field(i).Set(ev)
+	}
+	return out, nil
+}
+
+func isCompositeType(t abi.Type) bool {
+	return t.T == abi.SliceTy || t.T == abi.ArrayTy || t.T == tupleTy
+}
+
+// scalarString returns the string form of a string or JSON number.
+func scalarString(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case *big.Int:
+		return v.String(), v != nil
+	}
+	return "", false
+}
+
+// convertInt parses s, in decimal or 0x prefixed hex, as an integer of type t.
+func convertInt(t abi.Type, s string) (reflect.Value, error) {
+	i, ok := new(big.Int), false
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "-0x") {
+		i, ok = i.SetString(strings.Replace(s, "0x", "", 1), 16)
+	} else {
+		i, ok = i.SetString(s, 10)
+	}
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("invalid integer %q", s)
+	}
+	if !intInRange(t, i) {
+		return reflect.Value{}, fmt.Errorf("%s out of range for %s", s, t)
+	}
+	return intValue(t, i), nil
+}
+
+// intInRange reports whether i fits in the integer type t.
+func intInRange(t abi.Type, i *big.Int) bool {
+	if t.T == abi.UintTy {
+		return i.Sign() >= 0 && i.BitLen() <= t.Size
+	}
+	max := new(big.Int).Lsh(common.Big1, uint(t.Size-1))
+	min := new(big.Int).Neg(max)
+	return i.Cmp(min) >= 0 && i.Cmp(max) < 0
+}
+
+// intValue returns i as the Go type used by the abi package for t, which is either
+// *big.Int or a sized integer. i must be in range for t.
+func intValue(t abi.Type, i *big.Int) reflect.Value {
+	if t.Type.Kind() == reflect.Ptr {
+		return reflect.ValueOf(i)
+	}
+	out := reflect.New(t.Type).Elem()
+	if t.T == abi.UintTy {
+		out.SetUint(i.Uint64())
+	} else {
+		out.SetInt(i.Int64())
+	}
+	return out
+}
+
+// unpackValues decodes data into well-typed Go values for args: *big.Int or sized ints,
+// bool, string, common.Address, []byte, [N]byte, slices, arrays, and structs for tuples.
+func unpackValues(args abi.Arguments, data []byte) ([]interface{}, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	if len(data) == 0 {
+		return nil, errors.New("abi: unmarshalling empty output")
+	}
+	types := make([]abi.Type, len(args))
+	tuples := false
+	for i, arg := range args {
+		types[i] = arg.Type
+		tuples = tuples || hasTuple(arg.Type)
+	}
+	if tuples {
+		vals, err := decodeSequence(types, data)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, len(vals))
+		for i, v := range vals {
+			values[i] = v.Interface()
+		}
+		return values, nil
+	}
+	if len(args) == 1 {
+		v := reflect.New(args[0].Type.Type)
+		if err := args.Unpack(v.Interface(), data); err != nil {
+			return nil, err
+		}
+		return []interface{}{v.Elem().Interface()}, nil
+	}
+	// Multiple values are unpacked into the fields of a struct, which are renamed since
+	// argument names may be empty or clash once capitalized.
+	fields := make([]reflect.StructField, len(args))
+	renamed := make(abi.Arguments, len(args))
+	for i, arg := range args {
+		fields[i] = reflect.StructField{Name: fmt.Sprintf("V%d", i), Type: arg.Type.Type}
+		renamed[i] = arg
+		renamed[i].Name = fields[i].Name
+	}
+	v := reflect.New(reflect.StructOf(fields))
+	if err := renamed.Unpack(v.Interface(), data); err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(args))
+	for i := range values {
+		values[i] = v.Elem().Field(i).Interface()
+	}
+	return values, nil
+}
+
+// FormatValue converts v, as decoded for type t, to a value which prints and marshals to
+// JSON legibly. Addresses, byte arrays and byte slices become hex, arrays are converted
+// element-wise, and tuples become objects with their components in order. Other values are
+// returned unchanged.
+func FormatValue(t abi.Type, v interface{}) interface{} {
+	if _, ok := v.(IndexedHash); ok {
+		return v
+	}
+	rv := reflect.ValueOf(v)
+	switch t.T {
+	case abi.AddressTy:
+		// Address formats as raw bytes with %v, so use the checksummed hex string.
+		if a, ok := v.(common.Address); ok {
+			return a.Hex()
+		}
+	case abi.FixedBytesTy, abi.FunctionTy:
+		if rv.Kind() == reflect.Array && rv.Type().Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, rv.Len())
+			reflect.Copy(reflect.ValueOf(b), rv)
+			return hexutil.Bytes(b)
+		}
+	case abi.BytesTy:
+		if b, ok := v.([]byte); ok {
+			return hexutil.Bytes(b)
+		}
+	case abi.SliceTy, abi.ArrayTy:
+		if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+			out := make([]interface{}, rv.Len())
+			for i := range out {
+				out[i] = FormatValue(*t.Elem, rv.Index(i).Interface())
+			}
+			return out
+		}
+	case tupleTy:
+		if rv.IsValid() && rv.Type() == t.Type {
+			args := tupleArgs(t)
+			out := make(tupleValue, len(args))
+			for i, arg := range args {
+				name := arg.Name
+				if name == "" {
+					name = strconv.Itoa(i)
+				}
+				out[i] = tupleField{name: name, value: FormatValue(arg.Type, rv.Field(i).Interface())}
+			}
+			return out
+		}
+	}
+	return v
+}
@@ -0,0 +1,420 @@
+package web3
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gochain-io/gochain/v3/accounts/abi"
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/crypto"
+)
+
+const convertTestABI = `[{"type":"function","name":"f","inputs":[
+	{"name":"a","type":"int8"},
+	{"name":"b","type":"uint256"},
+	{"name":"c","type":"bytes4"},
+	{"name":"d","type":"bytes"},
+	{"name":"e","type":"address[]"},
+	{"name":"f","type":"uint16[2][]"},
+	{"name":"g","type":"bool"}
+]}]`
+
+func TestConvertParameters(t *testing.T) {
+	myabi, err := abi.JSON(strings.NewReader(convertTestABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	method := myabi.Methods["f"]
+	params := []interface{}{
+		"-128",
+		"0x10",
+		"0xdeadbeef",
+		"0x0102",
+		`["0x0000000000000000000000000000000000000001","0x0000000000000000000000000000000000000002"]`,
+		`[[1,2],[3,4]]`,
+		"true",
+	}
+	args, err := convertParameters(method.Inputs, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := args[0].(int8); got != -128 {
+		t.Errorf("expected -128 but got %d", got)
+	}
+	if got := args[1].(*big.Int); got.Int64() != 16 {
+		t.Errorf("expected 16 but got %s", got)
+	}
+	if got := args[2].([4]byte); got != [4]byte{0xde, 0xad, 0xbe, 0xef} {
+		t.Errorf("unexpected bytes4 %x", got)
+	}
+	if got := args[4].([]common.Address); len(got) != 2 || got[1] != common.HexToAddress("0x2") {
+		t.Errorf("unexpected addresses %v", got)
+	}
+	if got := args[5].([][2]uint16); !reflect.DeepEqual(got, [][2]uint16{{1, 2}, {3, 4}}) {
+		t.Errorf("unexpected nested array %v", got)
+	}
+	if _, err := myabi.Pack("f", args...); err != nil {
+		t.Errorf("failed to pack converted parameters: %v", err)
+	}
+}
+
+func TestConvertParametersErrors(t *testing.T) {
+	myabi, err := abi.JSON(strings.NewReader(convertTestABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	method := myabi.Methods["f"]
+	valid := []interface{}{"1", "1", "0x01020304", "0x", "[]", "[]", "false"}
+	for _, test := range []struct {
+		index int
+		value string
+		err   string
+	}{
+		{0, "128", "argument 0 (int8 a): 128 out of range for int8"},
+		{1, "-1", "argument 1 (uint256 b): -1 out of range for uint256"},
+		{2, "0x0102030405", "argument 2 (bytes4 c): expected 4 bytes for bytes4, given 5"},
+		{2, "0x010203", "argument 2 (bytes4 c): expected 4 bytes for bytes4, given 3"},
+		{4, `["0x1234"]`, `argument 4 (address[] e): element 0: invalid address "0x1234"`},
+		{5, `[[1,2,3]]`, "argument 5 (uint16[2][] f): element 0: expected 2 elements for uint16[2], given 3"},
+		{5, `{"a":1}`, "argument 5 (uint16[2][] f): cannot use map[string]interface {} as uint16[2][]"},
+		{6, "yes", `argument 6 (bool g): invalid bool "yes"`},
+	} {
+		params := append([]interface{}{}, valid...)
+		params[test.index] = test.value
+		_, err := convertParameters(method.Inputs, params)
+		if err == nil {
+			t.Errorf("expected error for %q", test.value)
+		} else if err.Error() != test.err {
+			t.Errorf("expected error %q but got %q", test.err, err)
+		}
+	}
+}
+
+const tupleTestABI = `[{"type":"function","name":"f","inputs":[
+	{"name":"s","type":"tuple","components":[{"name":"a","type":"uint256"},{"name":"b","type":"address"}]},
+	{"name":"d","type":"tuple[]","components":[{"name":"x","type":"uint8"},{"name":"y","type":"string"}]},
+	{"name":"n","type":"uint256"}
+]},{"type":"function","name":"g","inputs":[
+	{"name":"p","type":"tuple[2]","components":[{"name":"ok","type":"bool"},{"name":"","type":"bytes2"}]}
+]},{"type":"function","name":"exactInputSingle","inputs":[
+	{"name":"params","type":"tuple","components":[
+		{"name":"tokenIn","type":"address"},
+		{"name":"tokenOut","type":"address"},
+		{"name":"fee","type":"uint24"},
+		{"name":"recipient","type":"address"},
+		{"name":"deadline","type":"uint256"},
+		{"name":"amountIn","type":"uint256"},
+		{"name":"amountOutMinimum","type":"uint256"},
+		{"name":"sqrtPriceLimitX96","type":"uint160"}
+	]}
+]},{"type":"event","name":"E","inputs":[
+	{"name":"s","type":"tuple","indexed":false,"components":[{"name":"a","type":"uint256"},{"name":"b","type":"address"}]}
+]}]`
+
+func TestParseABITuple(t *testing.T) {
+	myabi, err := parseABI(strings.NewReader(tupleTestABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		method string
+		sig    string
+	}{
+		{"f", "f((uint256,address),(uint8,string)[],uint256)"},
+		{"g", "g((bool,bytes2)[2])"},
+	} {
+		if got := signature(test.method, myabi.Methods[test.method].Inputs); got != test.sig {
+			t.Errorf("expected signature %s but got %s", test.sig, got)
+		}
+	}
+	if got := common.Bytes2Hex(MethodID(myabi.Methods["exactInputSingle"])); got != "414bf389" {
+		t.Errorf("expected selector 414bf389 but got %s", got)
+	}
+	if got, exp := EventID(myabi.Events["E"]), crypto.Keccak256Hash([]byte("E((uint256,address))")); got != exp {
+		t.Errorf("expected event id %s but got %s", exp.Hex(), got.Hex())
+	}
+	const exp = "function f(s (uint256,address), d (uint8,string)[], n uint256) returns()"
+	if got := MethodString(myabi.Methods["f"]); got != exp {
+		t.Errorf("expected %q but got %q", exp, got)
+	}
+
+	for _, bad := range []string{
+		`[{"type":"function","name":"f","inputs":[{"name":"s","type":"tuple"}]}]`,
+		`[{"type":"function","name":"f","inputs":[{"name":"s","type":"tuple[x]","components":[{"name":"a","type":"bool"}]}]}]`,
+		`[{"type":"function","name":"f","inputs":[{"name":"s","type":"tuple","components":[{"name":"a","type":"uint"}]}]}]`,
+	} {
+		if _, err := parseABI(strings.NewReader(bad)); err == nil {
+			t.Errorf("expected error for %s", bad)
+		}
+	}
+}
+
+func TestPackTuple(t *testing.T) {
+	myabi, err := parseABI(strings.NewReader(tupleTestABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		method string
+		params []interface{}
+		exp    []string // 32 byte words
+	}{
+		{"f", []interface{}{
+			`{"a":1,"b":"0x0000000000000000000000000000000000000002"}`,
+			`[{"x":3,"y":"hi"}]`,
+			"4",
+		}, []string{
+			"0000000000000000000000000000000000000000000000000000000000000001",
+			"0000000000000000000000000000000000000000000000000000000000000002",
+			"0000000000000000000000000000000000000000000000000000000000000080",
+			"0000000000000000000000000000000000000000000000000000000000000004",
+			"0000000000000000000000000000000000000000000000000000000000000001",
+			"0000000000000000000000000000000000000000000000000000000000000020",
+			"0000000000000000000000000000000000000000000000000000000000000003",
+			"0000000000000000000000000000000000000000000000000000000000000040",
+			"0000000000000000000000000000000000000000000000000000000000000002",
+			"6869000000000000000000000000000000000000000000000000000000000000",
+		}},
+		// Tuples may also be given as arrays.
+		{"g", []interface{}{`[[true,"0x0102"],[false,"0x0304"]]`}, []string{
+			"0000000000000000000000000000000000000000000000000000000000000001",
+			"0102000000000000000000000000000000000000000000000000000000000000",
+			"0000000000000000000000000000000000000000000000000000000000000000",
+			"0304000000000000000000000000000000000000000000000000000000000000",
+		}},
+	} {
+		method := myabi.Methods[test.method]
+		args, err := convertParameters(method.Inputs, test.params)
+		if err != nil {
+			t.Fatalf("%s: %v", test.method, err)
+		}
+		packed, err := packArguments(method.Inputs, args)
+		if err != nil {
+			t.Fatalf("%s: %v", test.method, err)
+		}
+		if got, exp := common.Bytes2Hex(packed), strings.Join(test.exp, ""); got != exp {
+			t.Errorf("%s: expected\n%s\nbut got\n%s", test.method, exp, got)
+		}
+	}
+}
+
+func TestConvertTupleErrors(t *testing.T) {
+	myabi, err := parseABI(strings.NewReader(tupleTestABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs := myabi.Methods["f"].Inputs
+	valid := []interface{}{`[1,"0x0000000000000000000000000000000000000002"]`, "[]", "1"}
+	for _, test := range []struct {
+		index int
+		value string
+		err   string
+	}{
+		{0, `{"a":1}`, `argument 0 ((uint256,address) s): missing component "b"`},
+		{0, `{"a":1,"b":"0x0000000000000000000000000000000000000002","c":3,"d":4}`, `argument 0 ((uint256,address) s): unknown component "c"`},
+		{0, `[1]`, "argument 0 ((uint256,address) s): expected 2 components for (uint256,address), given 1"},
+		{0, `{"a":-1,"b":"0x0000000000000000000000000000000000000002"}`, "argument 0 ((uint256,address) s): component a: -1 out of range for uint256"},
+		{0, `1`, "argument 0 ((uint256,address) s): cannot use json.Number as (uint256,address)"},
+		{1, `[{"x":1,"y":2}]`, "argument 1 ((uint8,string)[] d): element 0: component y: cannot use json.Number as string"},
+	} {
+		params := append([]interface{}{}, valid...)
+		params[test.index] = test.value
+		_, err := convertParameters(inputs, params)
+		if err == nil {
+			t.Errorf("expected error for %q", test.value)
+		} else if err.Error() != test.err {
+			t.Errorf("expected error %q but got %q", test.err, err)
+		}
+	}
+	if _, err := convertParameters(myabi.Methods["g"].Inputs, []interface{}{`[{"ok":true,"0":"0x0102"},[false,"0x0304"]]`}); err == nil ||
+		!strings.Contains(err.Error(), "unnamed components of (bool,bytes2) must be given as an array") {
+		t.Errorf("expected error for unnamed components but got %v", err)
+	}
+}
+
+func TestUnpackTuple(t *testing.T) {
+	myabi, err := parseABI(strings.NewReader(tupleTestABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		method string
+		params []interface{}
+	}{
+		{"f", []interface{}{
+			`{"a":1,"b":"0x0000000000000000000000000000000000000002"}`,
+			`[{"x":3,"y":"hi"},{"x":4,"y":""}]`,
+			"5",
+		}},
+		{"f", []interface{}{`[7,"0x0000000000000000000000000000000000000001"]`, "[]", "8"}},
+		{"g", []interface{}{`[[true,"0x0102"],[false,"0x0304"]]`}},
+	} {
+		method := myabi.Methods[test.method]
+		args, err := convertParameters(method.Inputs, test.params)
+		if err != nil {
+			t.Fatalf("%s: %v", test.method, err)
+		}
+		input, err := packArguments(method.Inputs, args)
+		if err != nil {
+			t.Fatalf("%s: %v", test.method, err)
+		}
+		input = append(MethodID(method), input...)
+		got, values, err := ParseInput(myabi, input)
+		if err != nil {
+			t.Fatalf("%s: %v", test.method, err)
+		}
+		if got == nil || got.Name != test.method {
+			t.Fatalf("expected method %s but got %v", test.method, got)
+		}
+		if !reflect.DeepEqual(values, args) {
+			t.Errorf("%s: expected %v but got %v", test.method, args, values)
+		}
+		for n := len(input) - 1; n > 4; n -= 32 {
+			if _, _, err := ParseInput(myabi, input[:n]); err == nil {
+				t.Errorf("%s: expected error for input truncated to %d bytes", test.method, n)
+			}
+		}
+	}
+
+	// A slice length larger than the data.
+	inputs := myabi.Methods["f"].Inputs
+	data := make([]byte, 6*32)
+	data[2*32+31] = 0x80
+	data[4*32+31] = 0xff
+	if _, err := unpackValues(inputs, data); err == nil {
+		t.Error("expected error for a slice longer than the data")
+	}
+}
+
+func TestFormatTuple(t *testing.T) {
+	myabi, err := parseABI(strings.NewReader(tupleTestABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs := myabi.Methods["f"].Inputs
+	args, err := convertParameters(inputs, []interface{}{
+		`{"a":1,"b":"0xcd2a3d9f938e13cd947ec05abc7fe734df8dd826"}`,
+		`[{"x":3,"y":"hi"}]`,
+		"5",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, exp := range []struct{ json, str string }{
+		{`{"a":1,"b":"0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"}`, "{a: 1, b: 0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826}"},
+		{`[{"x":3,"y":"hi"}]`, "[{x: 3, y: hi}]"},
+	} {
+		v := FormatValue(inputs[i].Type, args[i])
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != exp.json {
+			t.Errorf("expected %s but got %s", exp.json, b)
+		}
+		if s := fmt.Sprint(v); s != exp.str {
+			t.Errorf("expected %s but got %s", exp.str, s)
+		}
+	}
+	p, err := convertParameters(myabi.Methods["g"].Inputs, []interface{}{`[[true,"0x0102"],[false,"0x0304"]]`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const exp = `[{"ok":true,"1":"0x0102"},{"ok":false,"1":"0x0304"}]`
+	if b, err := json.Marshal(FormatValue(myabi.Methods["g"].Inputs[0].Type, p[0])); err != nil || string(b) != exp {
+		t.Errorf("expected %s but got %s (%v)", exp, b, err)
+	}
+}
+
+const unpackTestABI = `[{"type":"function","name":"f","outputs":[
+	{"name":"","type":"int8"},
+	{"name":"amount","type":"int256"},
+	{"name":"to","type":"address"},
+	{"name":"id","type":"bytes32"},
+	{"name":"sel","type":"bytes4"},
+	{"name":"data","type":"bytes"},
+	{"name":"ids","type":"uint64[]"},
+	{"name":"pairs","type":"int16[2][]"},
+	{"name":"ok","type":"bool"},
+	{"name":"name","type":"string"}
+]},{"type":"function","name":"g","outputs":[{"name":"Amount","type":"uint256"}]},
+{"type":"function","name":"h","outputs":[{"name":"a","type":"uint256"},{"name":"A","type":"uint256"}]}]`
+
+func TestUnpackValues(t *testing.T) {
+	myabi, err := abi.JSON(strings.NewReader(unpackTestABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		method string
+		values []interface{}
+	}{
+		{"f", []interface{}{
+			int8(-128),
+			big.NewInt(-1),
+			common.HexToAddress("0x0000000000000000000000000000000000000001"),
+			[32]byte{1, 2, 3},
+			[4]byte{0xde, 0xad, 0xbe, 0xef},
+			[]byte{4, 5},
+			[]uint64{6, 7, 8},
+			[][2]int16{{-1, 1}, {-2, 2}},
+			true,
+			"hi",
+		}},
+		// A single named output.
+		{"g", []interface{}{big.NewInt(9)}},
+		// Names which clash once capitalized.
+		{"h", []interface{}{big.NewInt(10), big.NewInt(11)}},
+	} {
+		outputs := myabi.Methods[test.method].Outputs
+		data, err := outputs.Pack(test.values...)
+		if err != nil {
+			t.Fatalf("%s: %v", test.method, err)
+		}
+		got, err := unpackValues(outputs, data)
+		if err != nil {
+			t.Fatalf("%s: %v", test.method, err)
+		}
+		if !reflect.DeepEqual(got, test.values) {
+			t.Errorf("%s: expected %v but got %v", test.method, test.values, got)
+		}
+	}
+
+	if _, err := unpackValues(myabi.Methods["g"].Outputs, nil); err == nil {
+		t.Error("expected error for empty output")
+	}
+	if got, err := unpackValues(nil, nil); err != nil || got != nil {
+		t.Errorf("expected no values for no outputs but got %v (%v)", got, err)
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	myabi, err := abi.JSON(strings.NewReader(unpackTestABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputs := myabi.Methods["f"].Outputs
+	for _, test := range []struct {
+		index int
+		v     interface{}
+		exp   string
+	}{
+		{2, common.HexToAddress("0xcd2a3d9f938e13cd947ec05abc7fe734df8dd826"), `"0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"`},
+		{4, [4]byte{0xde, 0xad, 0xbe, 0xef}, `"0xdeadbeef"`},
+		{5, []byte{4, 5}, `"0x0405"`},
+		{7, [][2]int16{{-1, 1}}, `[[-1,1]]`},
+		{1, big.NewInt(-1), `-1`},
+	} {
+		b, err := json.Marshal(FormatValue(outputs[test.index].Type, test.v))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != test.exp {
+			t.Errorf("%s: expected %s but got %s", outputs[test.index].Type, test.exp, b)
+		}
+	}
+}
@@ -0,0 +1,514 @@
+package web3
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gochain-io/gochain/v3/accounts/abi"
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/crypto"
+)
+
+// tupleTy is the abi.Type.T of tuple (struct) types, which the abi package of gochain
+// v3.0.11 can neither parse nor encode. Tuple types are built by parseABI, with a struct
+// Type holding a field for each component, and are encoded by packArguments.
+const tupleTy byte = 0xff
+
+// tupleComponents holds the components of each tuple type, keyed by its struct type.
+// Struct fields are tagged with the component names and types, so equal struct types have
+// equal components.
+var tupleComponents sync.Map
+
+// tupleArgs returns the components of the tuple type t.
+func tupleArgs(t abi.Type) abi.Arguments {
+	c, _ := tupleComponents.Load(t.Type)
+	return c.(abi.Arguments)
+}
+
+// jsonArgument is an argument of a JSON ABI, with the components of tuple types.
+type jsonArgument struct {
+	Name       string         `json:"name"`
+	Type       string         `json:"type"`
+	Components []jsonArgument `json:"components,omitempty"`
+	Indexed    bool           `json:"indexed,omitempty"`
+}
+
+// tupleSuffix matches the array suffix of a tuple type, e.g. "[2][]".
+var tupleSuffix = regexp.MustCompile(`^(\[[0-9]*\])*$`)
+
+// newTupleType returns the type of a "tuple" argument, which may be an array or slice of
+// tuples, e.g. "tuple[2][]".
+func newTupleType(typ string, components []jsonArgument) (abi.Type, error) {
+	suffix := strings.TrimPrefix(typ, "tuple")
+	if !strings.HasPrefix(typ, "tuple") || !tupleSuffix.MatchString(suffix) {
+		return abi.Type{}, fmt.Errorf("unsupported arg type: %s", typ)
+	}
+	if len(components) == 0 {
+		return abi.Type{}, fmt.Errorf("tuple type %s without components", typ)
+	}
+	args := make(abi.Arguments, len(components))
+	fields := make([]reflect.StructField, len(components))
+	for i, c := range components {
+		var err error
+		if strings.HasPrefix(c.Type, "tuple") {
+			args[i].Type, err = newTupleType(c.Type, c.Components)
+		} else {
+			args[i].Type, err = abi.NewType(c.Type)
+		}
+		if err != nil {
+			return abi.Type{}, err
+		}
+		args[i].Name = c.Name
+		name := c.Name
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+		fields[i] = reflect.StructField{
+			Name: fmt.Sprintf("F%d", i),
+			Type: args[i].Type.Type,
+			Tag:  reflect.StructTag(fmt.Sprintf("json:%q abi:%q", name, typeString(args[i].Type))),
+		}
+	}
+	t := abi.Type{T: tupleTy, Kind: reflect.Struct, Type: reflect.StructOf(fields)}
+	tupleComponents.LoadOrStore(t.Type, args)
+
+	for _, dim := range strings.SplitAfter(suffix, "]") {
+		if dim == "" {
+			continue
+		}
+		elem := t
+		if dim == "[]" {
+			t = abi.Type{T: abi.SliceTy, Kind: reflect.Slice, Type: reflect.SliceOf(elem.Type), Elem: &elem}
+			continue
+		}
+		size, err := strconv.Atoi(dim[1 : len(dim)-1])
+		if err != nil {
+			return abi.Type{}, fmt.Errorf("invalid array size in %s: %v", typ, err)
+		}
+		t = abi.Type{T: abi.ArrayTy, Kind: reflect.Array, Type: reflect.ArrayOf(size, elem.Type), Elem: &elem, Size: size}
+	}
+	return t, nil
+}
+
+// tuplePatch is a tuple typed argument replaced by a placeholder before parsing.
+type tuplePatch struct {
+	outputs bool
+	index   int
+	typ     abi.Type
+}
+
+// parseTupleABI parses a JSON ABI declaring tuple types. The abi package cannot parse
+// them, so each one is replaced by a bytes placeholder, and patched into the parsed ABI.
+func parseTupleABI(fields []map[string]json.RawMessage) (abi.ABI, error) {
+	// Patches by entry type and name. Like abi.JSON, the last entry with a name wins.
+	patches := make(map[string]map[string][]tuplePatch)
+	for _, f := range fields {
+		// Invalid types and names are reported by abi.JSON.
+		var kind, name string
+		json.Unmarshal(f["type"], &kind)
+		json.Unmarshal(f["name"], &name)
+		if kind == "" {
+			kind = "function"
+		}
+		var entry []tuplePatch
+		for _, key := range []string{"inputs", "outputs"} {
+			if len(f[key]) == 0 {
+				continue
+			}
+			var args []jsonArgument
+			if err := json.Unmarshal(f[key], &args); err != nil {
+				return abi.ABI{}, fmt.Errorf("argument json err: %v", err)
+			}
+			for i, arg := range args {
+				if !strings.HasPrefix(arg.Type, "tuple") {
+					continue
+				}
+				t, err := newTupleType(arg.Type, arg.Components)
+				if err != nil {
+					return abi.ABI{}, err
+				}
+				entry = append(entry, tuplePatch{outputs: key == "outputs", index: i, typ: t})
+				args[i] = jsonArgument{Name: arg.Name, Type: "bytes" + strings.TrimPrefix(arg.Type, "tuple"), Indexed: arg.Indexed}
+			}
+			b, err := json.Marshal(args)
+			if err != nil {
+				return abi.ABI{}, err
+			}
+			f[key] = b
+		}
+		if patches[kind] == nil {
+			patches[kind] = make(map[string][]tuplePatch)
+		}
+		patches[kind][name] = entry
+	}
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return abi.ABI{}, err
+	}
+	parsed, err := abi.JSON(bytes.NewReader(b))
+	if err != nil {
+		return abi.ABI{}, err
+	}
+	for _, p := range patches["constructor"][""] {
+		parsed.Constructor.Inputs[p.index].Type = p.typ
+	}
+	for name, m := range parsed.Methods {
+		for _, p := range patches["function"][name] {
+			if p.outputs {
+				m.Outputs[p.index].Type = p.typ
+			} else {
+				m.Inputs[p.index].Type = p.typ
+			}
+		}
+	}
+	for name, e := range parsed.Events {
+		for _, p := range patches["event"][name] {
+			e.Inputs[p.index].Type = p.typ
+		}
+	}
+	return parsed, nil
+}
+
+// hasTuple reports whether t is a tuple, or an array or slice of tuples.
+func hasTuple(t abi.Type) bool {
+	switch t.T {
+	case tupleTy:
+		return true
+	case abi.SliceTy, abi.ArrayTy:
+		return hasTuple(*t.Elem)
+	}
+	return false
+}
+
+// typeString returns the canonical name of t used in signatures, e.g. "(uint256,bytes)[]"
+// for a slice of tuples. abi.Type.String is empty for tuple types.
+func typeString(t abi.Type) string {
+	switch {
+	case t.T == tupleTy:
+		args := tupleArgs(t)
+		types := make([]string, len(args))
+		for i, arg := range args {
+			types[i] = typeString(arg.Type)
+		}
+		return "(" + strings.Join(types, ",") + ")"
+	case t.T == abi.SliceTy && hasTuple(t):
+		return typeString(*t.Elem) + "[]"
+	case t.T == abi.ArrayTy && hasTuple(t):
+		return typeString(*t.Elem) + "[" + strconv.Itoa(t.Size) + "]"
+	}
+	return t.String()
+}
+
+func signature(name string, args abi.Arguments) string {
+	types := make([]string, len(args))
+	for i, arg := range args {
+		types[i] = typeString(arg.Type)
+	}
+	return name + "(" + strings.Join(types, ",") + ")"
+}
+
+// MethodID returns the 4 byte selector of m. Unlike m.Id(), it supports tuple inputs.
+func MethodID(m abi.Method) []byte {
+	return crypto.Keccak256([]byte(signature(m.Name, m.Inputs)))[:4]
+}
+
+// EventID returns the id (topic[0]) of e. Unlike e.Id(), it supports tuple inputs.
+func EventID(e abi.Event) common.Hash {
+	return crypto.Keccak256Hash([]byte(signature(e.Name, e.Inputs)))
+}
+
+// MethodString formats m like m.String(), with the names of tuple types.
+func MethodString(m abi.Method) string {
+	inputs := make([]string, len(m.Inputs))
+	for i, input := range m.Inputs {
+		inputs[i] = input.Name + " " + typeString(input.Type)
+	}
+	outputs := make([]string, len(m.Outputs))
+	for i, output := range m.Outputs {
+		if output.Name != "" {
+			outputs[i] = output.Name + " "
+		}
+		outputs[i] += typeString(output.Type)
+	}
+	constant := ""
+	if m.Const {
+		constant = "constant "
+	}
+	return fmt.Sprintf("function %s(%s) %sreturns(%s)", m.Name, strings.Join(inputs, ", "), constant, strings.Join(outputs, ", "))
+}
+
+// isDynamic reports whether values of t are encoded in the tail, after an offset.
+// Types without tuples follow the abi package, which encodes arrays in place.
+func isDynamic(t abi.Type) bool {
+	switch t.T {
+	case tupleTy:
+		for _, arg := range tupleArgs(t) {
+			if isDynamic(arg.Type) {
+				return true
+			}
+		}
+		return false
+	case abi.ArrayTy:
+		return hasTuple(t) && isDynamic(*t.Elem)
+	case abi.SliceTy, abi.StringTy, abi.BytesTy:
+		return true
+	}
+	return false
+}
+
+// staticSize returns the size of the encoding of values of t, which is not dynamic.
+func staticSize(t abi.Type) int {
+	switch t.T {
+	case tupleTy:
+		size := 0
+		for _, arg := range tupleArgs(t) {
+			size += staticSize(arg.Type)
+		}
+		return size
+	case abi.ArrayTy:
+		return t.Size * staticSize(*t.Elem)
+	}
+	return 32
+}
+
+// packArguments encodes values for args, like args.Pack, but also supports tuples.
+func packArguments(args abi.Arguments, values []interface{}) ([]byte, error) {
+	tuples := false
+	for _, arg := range args {
+		tuples = tuples || hasTuple(arg.Type)
+	}
+	if !tuples {
+		return args.Pack(values...)
+	}
+	if len(values) != len(args) {
+		return nil, fmt.Errorf("argument count mismatch: %d for %d", len(values), len(args))
+	}
+	types := make([]abi.Type, len(args))
+	vals := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		types[i], vals[i] = arg.Type, reflect.ValueOf(values[i])
+	}
+	return encodeSequence(types, vals)
+}
+
+// encodeSequence encodes the values of a tuple or array, with the values of static types
+// in place and those of dynamic types in the tail.
+func encodeSequence(types []abi.Type, vals []reflect.Value) ([]byte, error) {
+	headSize := 0
+	for _, t := range types {
+		if isDynamic(t) {
+			headSize += 32
+		} else {
+			headSize += staticSize(t)
+		}
+	}
+	var head, tail []byte
+	for i, t := range types {
+		enc, err := encodeValue(t, vals[i])
+		if err != nil {
+			return nil, err
+		}
+		if isDynamic(t) {
+			head = append(head, encodeWord(headSize+len(tail))...)
+			tail = append(tail, enc...)
+		} else {
+			head = append(head, enc...)
+		}
+	}
+	return append(head, tail...), nil
+}
+
+func encodeValue(t abi.Type, v reflect.Value) ([]byte, error) {
+	if !hasTuple(t) {
+		b, err := abi.Arguments{{Type: t}}.Pack(v.Interface())
+		if err != nil {
+			return nil, err
+		}
+		if isDynamic(t) {
+			// Drop the offset of the single value.
+			b = b[32:]
+		}
+		return b, nil
+	}
+	if !v.IsValid() {
+		return nil, fmt.Errorf("missing value for %s", typeString(t))
+	}
+	if v.Type() != t.Type {
+		return nil, fmt.Errorf("cannot use %s as %s", v.Type(), typeString(t))
+	}
+	if t.T == tupleTy {
+		args := tupleArgs(t)
+		types := make([]abi.Type, len(args))
+		vals := make([]reflect.Value, len(args))
+		for i, arg := range args {
+			types[i], vals[i] = arg.Type, v.Field(i)
+		}
+		return encodeSequence(types, vals)
+	}
+	types := make([]abi.Type, v.Len())
+	vals := make([]reflect.Value, v.Len())
+	for i := range types {
+		types[i], vals[i] = *t.Elem, v.Index(i)
+	}
+	enc, err := encodeSequence(types, vals)
+	if err != nil {
+		return nil, err
+	}
+	if t.T == abi.SliceTy {
+		enc = append(encodeWord(v.Len()), enc...)
+	}
+	return enc, nil
+}
+
+// encodeWord encodes n as a 32 byte word.
+func encodeWord(n int) []byte {
+	return common.LeftPadBytes(big.NewInt(int64(n)).Bytes(), 32)
+}
+
+// decodeSequence decodes the values of a tuple or array encoded at the start of data.
+func decodeSequence(types []abi.Type, data []byte) ([]reflect.Value, error) {
+	vals := make([]reflect.Value, len(types))
+	offset := 0
+	for i, t := range types {
+		var err error
+		if isDynamic(t) {
+			var start int
+			start, err = decodeWord(data, offset)
+			if err != nil {
+				return nil, err
+			}
+			vals[i], err = decodeValue(t, data[start:])
+			offset += 32
+		} else {
+			size := staticSize(t)
+			if offset+size > len(data) {
+				return nil, fmt.Errorf("abi: cannot unmarshal %s: length insufficient %d require %d", typeString(t), len(data), offset+size)
+			}
+			vals[i], err = decodeValue(t, data[offset:offset+size])
+			offset += size
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return vals, nil
+}
+
+// decodeValue decodes a value of type t encoded at the start of data.
+func decodeValue(t abi.Type, data []byte) (reflect.Value, error) {
+	if !hasTuple(t) {
+		// Decode a single value of the abi package, pointing a dynamic one at its tail.
+		if isDynamic(t) {
+			data = append(encodeWord(32), data...)
+		}
+		v := reflect.New(t.Type)
+		if err := (abi.Arguments{{Type: t}}).Unpack(v.Interface(), data); err != nil {
+			return reflect.Value{}, err
+		}
+		return v.Elem(), nil
+	}
+	if t.T == tupleTy {
+		args := tupleArgs(t)
+		types := make([]abi.Type, len(args))
+		for i, arg := range args {
+			types[i] = arg.Type
+		}
+		vals, err := decodeSequence(types, data)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		out := reflect.New(t.Type).Elem()
+		for i, v := range vals {
+			out.Field(i).Set(v)
+		}
+		return out, nil
+	}
+	n := t.Size
+	var out reflect.Value
+	if t.T == abi.SliceTy {
+		var err error
+		n, err = decodeWord(data, 0)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		data = data[32:]
+		// Each element takes at least a word, which bounds the allocation.
+		if n > len(data)/32 {
+			return reflect.Value{}, fmt.Errorf("abi: slice length %d exceeds data length %d", n, len(data))
+		}
+		out = reflect.MakeSlice(t.Type, n, n)
+	} else {
+		out = reflect.New(t.Type).Elem()
+	}
+	types := make([]abi.Type, n)
+	for i := range types {
+		types[i] = *t.Elem
+	}
+	vals, err := decodeSequence(types, data)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	for i, v := range vals {
+		out.Index(i).Set(v)
+	}
+	return out, nil
+}
+
+// decodeWord decodes the word at offset in data as a length or offset.
+func decodeWord(data []byte, offset int) (int, error) {
+	if offset+32 > len(data) {
+		return 0, fmt.Errorf("abi: length insufficient %d require %d", len(data), offset+32)
+	}
+	n := new(big.Int).SetBytes(data[offset : offset+32])
+	if !n.IsInt64() || n.Int64() > int64(len(data)) {
+		return 0, fmt.Errorf("abi: offset or length %s exceeds data length %d", n, len(data))
+	}
+	return int(n.Int64()), nil
+}
+
+// tupleValue is a formatted tuple, which prints and marshals to JSON with its components
+// in order.
+type tupleValue []tupleField
+
+type tupleField struct {
+	name  string
+	value interface{}
+}
+
+func (v tupleValue) String() string {
+	fields := make([]string, len(v))
+	for i, f := range v {
+		fields[i] = fmt.Sprintf("%s: %v", f.name, f.value)
+	}
+	return "{" + strings.Join(fields, ", ") + "}"
+}
+
+func (v tupleValue) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range v {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(f.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -26,7 +26,7 @@ func ABIOpenFile(contractFile string) (*abi.ABI, error) {
 }
 
 func readAbi(reader io.Reader) (*abi.ABI, error) {
-	abi, err := abi.JSON(reader)
+	abi, err := parseABI(reader)
 	if err != nil {
 		return nil, err
 	}
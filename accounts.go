@@ -38,5 +38,21 @@ func (a *Account) PublicKey() string {
 }
 
 func (a *Account) PrivateKey() string {
-	return "0x" + hex.EncodeToString(a.key.D.Bytes())
+	return "0x" + hex.EncodeToString(crypto.FromECDSA(a.key))
+}
+
+// SignHash returns a 65 byte [R || S || V] signature of the 32 byte hash. V is 0 or 1.
+func (a *Account) SignHash(hash []byte) ([]byte, error) {
+	return crypto.Sign(hash, a.key)
+}
+
+// SignMessage returns a 65 byte [R || S || V] signature of msg, after applying the
+// standard Ethereum signed message prefix, as in personal_sign. V is 27 or 28.
+func (a *Account) SignMessage(msg []byte) ([]byte, error) {
+	sig, err := a.SignHash(textHash(msg))
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
 }
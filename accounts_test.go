@@ -0,0 +1,21 @@
+package web3
+
+import "testing"
+
+func TestPrivateKeyLeadingZero(t *testing.T) {
+	const key = "0x00a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f"
+	acc, err := ParsePrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := acc.PrivateKey(); got != key {
+		t.Fatalf("expected private key %s but got %s", key, got)
+	}
+	parsed, err := ParsePrivateKey(acc.PrivateKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.PublicKey() != acc.PublicKey() {
+		t.Errorf("expected address %s but got %s", acc.PublicKey(), parsed.PublicKey())
+	}
+}
@@ -0,0 +1,165 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/common/hexutil"
+	"github.com/gochain-io/gochain/v3/rpc"
+)
+
+// BatchError is returned by batch calls when some of the items failed.
+type BatchError struct {
+	// Errors holds an entry for each item, which is nil for items that succeeded.
+	Errors []error
+}
+
+func (e *BatchError) Error() string {
+	failed, first := countErrors(e.Errors)
+	return fmt.Sprintf("%d of %d batch items failed, first error: %v", failed, len(e.Errors), first)
+}
+
+// countErrors returns the number of non-nil errs, and the first of them.
+func countErrors(errs []error) (failed int, first error) {
+	for _, err := range errs {
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			failed++
+		}
+	}
+	return failed, first
+}
+
+// batchError returns a *BatchError for the failed reqs, or nil if all succeeded.
+func batchError(reqs []rpc.BatchElem) error {
+	var errs []error
+	for i, req := range reqs {
+		if req.Error == nil {
+			continue
+		}
+		if errs == nil {
+			errs = make([]error, len(reqs))
+		}
+		errs[i] = req.Error
+	}
+	if errs == nil {
+		return nil
+	}
+	return &BatchError{Errors: errs}
+}
+
+// MaxBlockRange is the maximum number of blocks returned by one GetBlocksByNumber call.
+const MaxBlockRange = 10000
+
+// blockRange returns the number of blocks from start to end inclusive, or an error if the
+// range is invalid or larger than MaxBlockRange.
+func blockRange(start, end *big.Int) (uint64, error) {
+	if start == nil || end == nil {
+		return 0, errors.New("start and end block numbers are required")
+	}
+	if end.Cmp(start) < 0 {
+		return 0, fmt.Errorf("end block %s is before start block %s", end, start)
+	}
+	count := new(big.Int).Sub(end, start)
+	if count.Cmp(big.NewInt(MaxBlockRange)) >= 0 {
+		return 0, fmt.Errorf("block range %s to %s is larger than the maximum of %d blocks", start, end, MaxBlockRange)
+	}
+	return count.Uint64() + 1, nil
+}
+
+// batchCall sends reqs in chunks of at most maxBatchSize requests.
+func (c *client) batchCall(ctx context.Context, reqs []rpc.BatchElem) error {
+	for start := 0; start < len(reqs); start += c.maxBatchSize {
+		end := start + c.maxBatchSize
+		if end > len(reqs) {
+			end = len(reqs)
+		}
+		if err := c.r.BatchCallContext(ctx, reqs[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *client) GetReceipts(ctx context.Context, hashes []common.Hash) ([]*Receipt, error) {
+	receipts := make([]*Receipt, len(hashes))
+	reqs := make([]rpc.BatchElem, len(hashes))
+	for i, hash := range hashes {
+		reqs[i] = rpc.BatchElem{
+			Method: "eth_getTransactionReceipt",
+			Args:   []interface{}{hash},
+			Result: &receipts[i],
+		}
+	}
+	if err := c.batchCall(ctx, reqs); err != nil {
+		return nil, err
+	}
+	for i := range reqs {
+		if reqs[i].Error == nil && receipts[i] == nil {
+			reqs[i].Error = NotFoundErr
+		}
+	}
+	return receipts, batchError(reqs)
+}
+
+func (c *client) GetBlocksByNumber(ctx context.Context, start, end *big.Int, includeTxs bool) ([]*Block, error) {
+	count, err := blockRange(start, end)
+	if err != nil {
+		return nil, err
+	}
+	blocks := make([]*Block, count)
+	reqs := make([]rpc.BatchElem, count)
+	for i := range reqs {
+		number := new(big.Int).Add(start, new(big.Int).SetUint64(uint64(i)))
+		reqs[i] = rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []interface{}{hexutil.EncodeBig(number), includeTxs},
+			Result: &blocks[i],
+		}
+	}
+	if err := c.batchCall(ctx, reqs); err != nil {
+		return nil, err
+	}
+	for i := range reqs {
+		if reqs[i].Error != nil {
+			continue
+		}
+		if blocks[i] == nil {
+			reqs[i].Error = NotFoundErr
+		} else if err := verifyBlock(blocks[i]); err != nil {
+			reqs[i].Error = err
+			blocks[i] = nil
+		}
+	}
+	return blocks, batchError(reqs)
+}
+
+func (c *client) GetBalances(ctx context.Context, addresses []common.Address, blockNumber *big.Int) ([]*big.Int, error) {
+	results := make([]*hexutil.Big, len(addresses))
+	reqs := make([]rpc.BatchElem, len(addresses))
+	for i, address := range addresses {
+		reqs[i] = rpc.BatchElem{
+			Method: "eth_getBalance",
+			Args:   []interface{}{address, toBlockNumArg(blockNumber)},
+			Result: &results[i],
+		}
+	}
+	if err := c.batchCall(ctx, reqs); err != nil {
+		return nil, err
+	}
+	balances := make([]*big.Int, len(addresses))
+	for i := range reqs {
+		if reqs[i].Error == nil && results[i] == nil {
+			reqs[i].Error = NotFoundErr
+		}
+		if reqs[i].Error == nil {
+			balances[i] = results[i].ToInt()
+		}
+	}
+	return balances, batchError(reqs)
+}
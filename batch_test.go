@@ -0,0 +1,246 @@
+package web3
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/common/hexutil"
+	"github.com/gochain-io/gochain/v3/core/types"
+	"github.com/gochain-io/gochain/v3/rpc"
+)
+
+// BatchService is an eth service for batch calls, with a balance equal to each address
+// and blocks up to head. It is exported because the rpc server only registers services
+// of exported types.
+type BatchService struct {
+	head    int64
+	invalid int64 // the number of a block which fails verification
+	failed  common.Address
+	missing common.Address
+}
+
+func (s *BatchService) GetBalance(addr common.Address, block string) (*hexutil.Big, error) {
+	switch addr {
+	case s.failed:
+		return nil, errors.New("balance unavailable")
+	case s.missing:
+		return nil, nil
+	}
+	return (*hexutil.Big)(addr.Big()), nil
+}
+
+func (s *BatchService) GetBlockByNumber(number hexutil.Big, full bool) (*Block, error) {
+	n := number.ToInt()
+	if n.Int64() > s.head {
+		return nil, nil
+	}
+	b := &Block{
+		Sha3Uncles:   types.EmptyUncleHash,
+		TxsRoot:      types.EmptyRootHash,
+		LogsBloom:    new(types.Bloom),
+		Difficulty:   big.NewInt(1),
+		Number:       n,
+		Timestamp:    time.Unix(0, 0),
+		Hash:         common.BigToHash(n),
+		TxHashes:     []common.Hash{},
+		ReceiptsRoot: types.EmptyRootHash,
+	}
+	if n.Int64() == s.invalid {
+		b.TxHashes = []common.Hash{{1}}
+	}
+	return b, nil
+}
+
+// batchCounter is an http.Handler which records the size of each batch it serves.
+type batchCounter struct {
+	http.Handler
+	mu      sync.Mutex
+	batches []int
+}
+
+func (h *batchCounter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var batch []json.RawMessage
+	if json.Unmarshal(body, &batch) == nil {
+		h.mu.Lock()
+		h.batches = append(h.batches, len(batch))
+		h.mu.Unlock()
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	h.Handler.ServeHTTP(w, r)
+}
+
+func newBatchClient(t *testing.T, svc *BatchService, opts ...ClientOption) (Client, *batchCounter, func()) {
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", svc); err != nil {
+		t.Fatal(err)
+	}
+	h := &batchCounter{Handler: server}
+	ts := httptest.NewServer(h)
+	r, err := rpc.DialHTTP(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(r, opts...)
+	return c, h, func() {
+		c.Close()
+		ts.Close()
+		server.Stop()
+	}
+}
+
+func TestGetBalancesChunks(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		size  int
+		count int
+		exp   []int
+	}{
+		{0, 5, []int{5}},
+		{2, 5, []int{2, 2, 1}},
+		{5, 5, []int{5}},
+		{10, 5, []int{5}},
+		{2, 0, nil},
+	} {
+		c, h, stop := newBatchClient(t, &BatchService{}, WithMaxBatchSize(test.size))
+		var addrs []common.Address
+		for i := 1; i <= test.count; i++ {
+			addrs = append(addrs, common.BigToAddress(big.NewInt(int64(i))))
+		}
+		balances, err := c.GetBalances(ctx, addrs, nil)
+		stop()
+		if err != nil {
+			t.Fatalf("size %d: %v", test.size, err)
+		}
+		for i, b := range balances {
+			if b.Int64() != int64(i+1) {
+				t.Errorf("size %d: expected balance %d but got %s", test.size, i+1, b)
+			}
+		}
+		if len(h.batches) != len(test.exp) {
+			t.Errorf("size %d: expected batches %v but got %v", test.size, test.exp, h.batches)
+			continue
+		}
+		for i := range test.exp {
+			if h.batches[i] != test.exp[i] {
+				t.Errorf("size %d: expected batches %v but got %v", test.size, test.exp, h.batches)
+				break
+			}
+		}
+	}
+}
+
+func TestGetBalancesPartialFailure(t *testing.T) {
+	svc := &BatchService{
+		failed:  common.HexToAddress("0x02"),
+		missing: common.HexToAddress("0x04"),
+	}
+	c, _, stop := newBatchClient(t, svc, WithMaxBatchSize(2))
+	defer stop()
+	addrs := []common.Address{
+		common.HexToAddress("0x01"),
+		svc.failed,
+		common.HexToAddress("0x03"),
+		svc.missing,
+		common.HexToAddress("0x05"),
+	}
+	balances, err := c.GetBalances(context.Background(), addrs, nil)
+	berr, ok := err.(*BatchError)
+	if !ok {
+		t.Fatalf("expected *BatchError but got %v", err)
+	}
+	if len(balances) != len(addrs) || len(berr.Errors) != len(addrs) {
+		t.Fatalf("expected %d balances and errors but got %d and %d", len(addrs), len(balances), len(berr.Errors))
+	}
+	for i, addr := range addrs {
+		switch addr {
+		case svc.failed:
+			if berr.Errors[i] == nil || berr.Errors[i].Error() != "balance unavailable" {
+				t.Errorf("%d: expected balance unavailable but got %v", i, berr.Errors[i])
+			}
+		case svc.missing:
+			if berr.Errors[i] != NotFoundErr {
+				t.Errorf("%d: expected %v but got %v", i, NotFoundErr, berr.Errors[i])
+			}
+		default:
+			if berr.Errors[i] != nil {
+				t.Errorf("%d: unexpected error %v", i, berr.Errors[i])
+			}
+			if balances[i] == nil || balances[i].Cmp(addr.Big()) != 0 {
+				t.Errorf("%d: expected balance %s but got %v", i, addr.Big(), balances[i])
+			}
+			continue
+		}
+		if balances[i] != nil {
+			t.Errorf("%d: expected no balance but got %s", i, balances[i])
+		}
+	}
+	const exp = "2 of 5 batch items failed, first error: balance unavailable"
+	if err.Error() != exp {
+		t.Errorf("expected error %q but got %q", exp, err)
+	}
+}
+
+func TestGetBlocksByNumber(t *testing.T) {
+	ctx := context.Background()
+	c, h, stop := newBatchClient(t, &BatchService{head: 5, invalid: 3}, WithMaxBatchSize(3))
+	defer stop()
+	blocks, err := c.GetBlocksByNumber(ctx, big.NewInt(1), big.NewInt(7), false)
+	berr, ok := err.(*BatchError)
+	if !ok {
+		t.Fatalf("expected *BatchError but got %v", err)
+	}
+	if len(h.batches) != 3 {
+		t.Errorf("expected 3 batches but got %v", h.batches)
+	}
+	if len(blocks) != 7 || len(berr.Errors) != 7 {
+		t.Fatalf("expected 7 blocks and errors but got %d and %d", len(blocks), len(berr.Errors))
+	}
+	for i, b := range blocks {
+		n := int64(i + 1)
+		switch {
+		case n == 3:
+			if berr.Errors[i] == nil || b != nil {
+				t.Errorf("block %d: expected verification error but got %v", n, berr.Errors[i])
+			}
+		case n > 5:
+			if berr.Errors[i] != NotFoundErr || b != nil {
+				t.Errorf("block %d: expected %v but got %v", n, NotFoundErr, berr.Errors[i])
+			}
+		default:
+			if berr.Errors[i] != nil {
+				t.Errorf("block %d: unexpected error %v", n, berr.Errors[i])
+			} else if b.Number.Int64() != n {
+				t.Errorf("expected block %d but got %s", n, b.Number)
+			}
+		}
+	}
+
+	blocks, err = c.GetBlocksByNumber(ctx, big.NewInt(1), big.NewInt(2), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 2 {
+		t.Errorf("expected 2 blocks but got %d", len(blocks))
+	}
+	if _, err := c.GetBlocksByNumber(ctx, big.NewInt(2), big.NewInt(1), false); err == nil {
+		t.Error("expected error for an end block before the start block")
+	}
+	if _, err := c.GetBlocksByNumber(ctx, big.NewInt(1), big.NewInt(MaxBlockRange+1), false); err == nil {
+		t.Error("expected error for a range larger than MaxBlockRange")
+	}
+}
@@ -0,0 +1,460 @@
+package web3
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gochain-io/gochain/v3/common"
+)
+
+// CacheOptions configures a caching client. Zero values select the defaults.
+type CacheOptions struct {
+	// Size is the maximum number of entries held in memory. Default: 10000.
+	Size int
+	// Dir is an optional directory for a persistent cache, shared between processes.
+	Dir string
+	// FinalityDepth is the number of blocks below the head after which data is
+	// considered final, and may be cached. Default: 12.
+	FinalityDepth uint64
+}
+
+// headTTL is how long the head block number is reused when deciding finality.
+const headTTL = 2 * time.Second
+
+// NewCachingClient returns a Client which caches immutable data from client: blocks,
+// transactions, receipts, code, balances and storage at final blocks, which are at least
+// opts.FinalityDepth blocks below the head. Batch calls are cached per item. Queries for
+// the latest block (nil block numbers) and pending data always go to client.
+func NewCachingClient(client Client, opts CacheOptions) (Client, error) {
+	if opts.Size <= 0 {
+		opts.Size = 10000
+	}
+	if opts.FinalityDepth == 0 {
+		opts.FinalityDepth = 12
+	}
+	if opts.Dir != "" {
+		if err := os.MkdirAll(opts.Dir, 0755); err != nil {
+			return nil, fmt.Errorf("cannot create cache directory: %v", err)
+		}
+	}
+	return &cachingClient{Client: client, opts: opts, lru: newLRU(opts.Size)}, nil
+}
+
+type cachingClient struct {
+	Client // Methods which are not overridden are not cached.
+	opts   CacheOptions
+	lru    *lru
+
+	headMu   sync.Mutex
+	head     uint64
+	headTime time.Time
+}
+
+// final reports whether block number n is at least FinalityDepth blocks below the head.
+// The head is only fetched when n is not final below the last head seen, and that is older
+// than headTTL. The lock is not held while fetching, so that other lookups don't wait.
+func (c *cachingClient) final(ctx context.Context, n uint64) bool {
+	c.headMu.Lock()
+	head, fetched := c.head, c.headTime
+	c.headMu.Unlock()
+	if n+c.opts.FinalityDepth <= head {
+		return true
+	}
+	if time.Since(fetched) <= headTTL {
+		return false
+	}
+	b, err := c.Client.GetBlockByNumber(ctx, nil, false)
+	if err != nil || b.Number == nil {
+		return false
+	}
+	head = b.Number.Uint64()
+	c.headMu.Lock()
+	if head > c.head {
+		c.head = head
+	}
+	c.headTime = time.Now()
+	c.headMu.Unlock()
+	return n+c.opts.FinalityDepth <= head
+}
+
+// get decodes the entry for key into v, reporting whether it was found.
+func (c *cachingClient) get(key string, v interface{}) bool {
+	b, ok := c.lru.get(key)
+	if !ok {
+		if c.opts.Dir == "" {
+			return false
+		}
+		var err error
+		b, err = ioutil.ReadFile(c.path(key))
+		if err != nil {
+			return false
+		}
+		c.lru.add(key, b)
+	}
+	return json.Unmarshal(b, v) == nil
+}
+
+// put stores v for key. Values are stored as JSON so that callers can't modify them.
+func (c *cachingClient) put(key string, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	c.lru.add(key, b)
+	if c.opts.Dir != "" {
+		// Write to a temporary file first so that readers never see partial entries.
+		tmp, err := ioutil.TempFile(c.opts.Dir, ".tmp-")
+		if err != nil {
+			return
+		}
+		_, err = tmp.Write(b)
+		if cerr := tmp.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), c.path(key))
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}
+}
+
+func (c *cachingClient) path(key string) string {
+	return filepath.Join(c.opts.Dir, strings.Replace(key, "/", "-", -1))
+}
+
+func (c *cachingClient) GetBlockByHash(ctx context.Context, hash string, includeTxs bool) (*Block, error) {
+	key := fmt.Sprintf("block/%s/%t", strings.ToLower(hash), includeTxs)
+	var b Block
+	if c.get(key, &b) {
+		return &b, nil
+	}
+	block, err := c.Client.GetBlockByHash(ctx, hash, includeTxs)
+	if err != nil {
+		return nil, err
+	}
+	// A recent block may still be reorged out of the canonical chain.
+	if block.Number != nil && c.final(ctx, block.Number.Uint64()) {
+		c.put(key, block)
+	}
+	return block, nil
+}
+
+func (c *cachingClient) GetBlockByNumber(ctx context.Context, number *big.Int, includeTxs bool) (*Block, error) {
+	if number == nil {
+		return c.Client.GetBlockByNumber(ctx, number, includeTxs)
+	}
+	key := blockNumberKey(number, includeTxs)
+	var b Block
+	if c.get(key, &b) {
+		return &b, nil
+	}
+	block, err := c.Client.GetBlockByNumber(ctx, number, includeTxs)
+	if err != nil {
+		return nil, err
+	}
+	if c.final(ctx, number.Uint64()) {
+		c.put(key, block)
+	}
+	return block, nil
+}
+
+func (c *cachingClient) GetTransactionByHash(ctx context.Context, hash common.Hash) (*Transaction, error) {
+	key := "tx/" + hash.Hex()
+	var t Transaction
+	if c.get(key, &t) {
+		return &t, nil
+	}
+	tx, err := c.Client.GetTransactionByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if tx.BlockNumber != nil && c.final(ctx, tx.BlockNumber.Uint64()) {
+		c.put(key, tx)
+	}
+	return tx, nil
+}
+
+func (c *cachingClient) GetTransactionReceipt(ctx context.Context, hash common.Hash) (*Receipt, error) {
+	key := receiptKey(hash)
+	var r Receipt
+	if c.get(key, &r) {
+		return &r, nil
+	}
+	receipt, err := c.Client.GetTransactionReceipt(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if c.final(ctx, receipt.BlockNumber) {
+		c.put(key, receipt)
+	}
+	return receipt, nil
+}
+
+func (c *cachingClient) GetCode(ctx context.Context, address string, blockNumber *big.Int) ([]byte, error) {
+	if blockNumber == nil {
+		return c.Client.GetCode(ctx, address, blockNumber)
+	}
+	key := fmt.Sprintf("code/%s/%s", common.HexToAddress(address).Hex(), blockNumber)
+	var code []byte
+	if c.get(key, &code) {
+		return code, nil
+	}
+	code, err := c.Client.GetCode(ctx, address, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if c.final(ctx, blockNumber.Uint64()) {
+		c.put(key, code)
+	}
+	return code, nil
+}
+
+func (c *cachingClient) GetBalance(ctx context.Context, address string, blockNumber *big.Int) (*big.Int, error) {
+	if blockNumber == nil {
+		return c.Client.GetBalance(ctx, address, blockNumber)
+	}
+	key := balanceKey(common.HexToAddress(address), blockNumber)
+	var bal big.Int
+	if c.get(key, &bal) {
+		return &bal, nil
+	}
+	balance, err := c.Client.GetBalance(ctx, address, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if c.final(ctx, blockNumber.Uint64()) {
+		c.put(key, balance)
+	}
+	return balance, nil
+}
+
+func (c *cachingClient) GetStorageAt(ctx context.Context, address common.Address, slot common.Hash, blockNumber *big.Int) (common.Hash, error) {
+	if blockNumber == nil {
+		return c.Client.GetStorageAt(ctx, address, slot, blockNumber)
+	}
+	key := fmt.Sprintf("storage/%s/%s/%s", address.Hex(), slot.Hex(), blockNumber)
+	var value common.Hash
+	if c.get(key, &value) {
+		return value, nil
+	}
+	value, err := c.Client.GetStorageAt(ctx, address, slot, blockNumber)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if c.final(ctx, blockNumber.Uint64()) {
+		c.put(key, value)
+	}
+	return value, nil
+}
+
+func blockNumberKey(number *big.Int, includeTxs bool) string {
+	return fmt.Sprintf("blocknum/%s/%t", number, includeTxs)
+}
+
+func receiptKey(hash common.Hash) string {
+	return "receipt/" + hash.Hex()
+}
+
+func balanceKey(address common.Address, blockNumber *big.Int) string {
+	return fmt.Sprintf("balance/%s/%s", address.Hex(), blockNumber)
+}
+
+// The batch methods get each item from the cache, and only request the missing ones from
+// the client.
+
+func (c *cachingClient) GetReceipts(ctx context.Context, hashes []common.Hash) ([]*Receipt, error) {
+	receipts := make([]*Receipt, len(hashes))
+	var missing []int
+	var missingHashes []common.Hash
+	for i, hash := range hashes {
+		var r Receipt
+		if c.get(receiptKey(hash), &r) {
+			receipts[i] = &r
+			continue
+		}
+		missing = append(missing, i)
+		missingHashes = append(missingHashes, hash)
+	}
+	if len(missing) == 0 {
+		return receipts, nil
+	}
+	fetched, err := c.Client.GetReceipts(ctx, missingHashes)
+	errs, err := batchItemErrors(err, len(missing), len(fetched))
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range missing {
+		receipts[i] = fetched[j]
+		if errs[j] == nil && fetched[j] != nil && c.final(ctx, fetched[j].BlockNumber) {
+			c.put(receiptKey(hashes[i]), fetched[j])
+		}
+	}
+	return receipts, spreadBatchErrors(errs, missing, len(hashes))
+}
+
+func (c *cachingClient) GetBlocksByNumber(ctx context.Context, start, end *big.Int, includeTxs bool) ([]*Block, error) {
+	count, err := blockRange(start, end)
+	if err != nil {
+		return nil, err
+	}
+	blocks := make([]*Block, count)
+	var missing []int
+	for i := range blocks {
+		number := new(big.Int).Add(start, big.NewInt(int64(i)))
+		var b Block
+		if c.get(blockNumberKey(number, includeTxs), &b) {
+			blocks[i] = &b
+			continue
+		}
+		missing = append(missing, i)
+	}
+	var errs []error
+	// Each run of consecutive missing blocks is requested as a range.
+	for run := 0; run < len(missing); {
+		next := run + 1
+		for next < len(missing) && missing[next] == missing[next-1]+1 {
+			next++
+		}
+		from := new(big.Int).Add(start, big.NewInt(int64(missing[run])))
+		to := new(big.Int).Add(start, big.NewInt(int64(missing[next-1])))
+		fetched, err := c.Client.GetBlocksByNumber(ctx, from, to, includeTxs)
+		runErrs, err := batchItemErrors(err, next-run, len(fetched))
+		if err != nil {
+			return nil, err
+		}
+		for j, i := range missing[run:next] {
+			blocks[i] = fetched[j]
+			number := new(big.Int).Add(start, big.NewInt(int64(i)))
+			if runErrs[j] == nil && fetched[j] != nil && c.final(ctx, number.Uint64()) {
+				c.put(blockNumberKey(number, includeTxs), fetched[j])
+			}
+		}
+		errs = append(errs, runErrs...)
+		run = next
+	}
+	return blocks, spreadBatchErrors(errs, missing, len(blocks))
+}
+
+func (c *cachingClient) GetBalances(ctx context.Context, addresses []common.Address, blockNumber *big.Int) ([]*big.Int, error) {
+	if blockNumber == nil {
+		return c.Client.GetBalances(ctx, addresses, blockNumber)
+	}
+	balances := make([]*big.Int, len(addresses))
+	var missing []int
+	var missingAddrs []common.Address
+	for i, address := range addresses {
+		var bal big.Int
+		if c.get(balanceKey(address, blockNumber), &bal) {
+			balances[i] = &bal
+			continue
+		}
+		missing = append(missing, i)
+		missingAddrs = append(missingAddrs, address)
+	}
+	if len(missing) == 0 {
+		return balances, nil
+	}
+	fetched, err := c.Client.GetBalances(ctx, missingAddrs, blockNumber)
+	errs, err := batchItemErrors(err, len(missing), len(fetched))
+	if err != nil {
+		return nil, err
+	}
+	final := c.final(ctx, blockNumber.Uint64())
+	for j, i := range missing {
+		balances[i] = fetched[j]
+		if errs[j] == nil && fetched[j] != nil && final {
+			c.put(balanceKey(addresses[i], blockNumber), fetched[j])
+		}
+	}
+	return balances, spreadBatchErrors(errs, missing, len(addresses))
+}
+
+// batchItemErrors returns the errors of the n items of a batch call which returned err and
+// the given number of results. Errors other than a *BatchError are returned as is, since
+// the call failed as a whole.
+func batchItemErrors(err error, n, results int) ([]error, error) {
+	errs := make([]error, n)
+	if err != nil {
+		berr, ok := err.(*BatchError)
+		if !ok {
+			return nil, err
+		}
+		if len(berr.Errors) != n {
+			return nil, fmt.Errorf("expected %d batch errors but got %d", n, len(berr.Errors))
+		}
+		errs = berr.Errors
+	}
+	if results != n {
+		return nil, fmt.Errorf("expected %d batch results but got %d", n, results)
+	}
+	return errs, nil
+}
+
+// spreadBatchErrors returns a *BatchError of n items, with errs at the indexes idx, or nil
+// if all of errs are nil.
+func spreadBatchErrors(errs []error, idx []int, n int) error {
+	if failed, _ := countErrors(errs); failed == 0 {
+		return nil
+	}
+	all := make([]error, n)
+	for j, i := range idx {
+		all[i] = errs[j]
+	}
+	return &BatchError{Errors: all}
+}
+
+// lru is a size bounded cache which evicts the least recently used entries.
+type lru struct {
+	size int
+
+	mu      sync.Mutex
+	ll      *list.List
+	entries map[string]*list.Element
+}
+
+type lruEntry struct {
+	key   string
+	value []byte
+}
+
+func newLRU(size int) *lru {
+	return &lru{size: size, ll: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (l *lru) get(key string) ([]byte, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e, ok := l.entries[key]
+	if !ok {
+		return nil, false
+	}
+	l.ll.MoveToFront(e)
+	return e.Value.(*lruEntry).value, true
+}
+
+func (l *lru) add(key string, value []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e, ok := l.entries[key]; ok {
+		l.ll.MoveToFront(e)
+		e.Value.(*lruEntry).value = value
+		return
+	}
+	l.entries[key] = l.ll.PushFront(&lruEntry{key: key, value: value})
+	for l.ll.Len() > l.size {
+		e := l.ll.Back()
+		l.ll.Remove(e)
+		delete(l.entries, e.Value.(*lruEntry).key)
+	}
+}
@@ -0,0 +1,325 @@
+package web3
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/core/types"
+)
+
+// cacheTestData returns a transaction, and its block and receipt, at block number.
+func cacheTestData(number int64) (*Block, *Transaction, *Receipt) {
+	to := common.HexToAddress("0x0000000000000000000000000000000000000002")
+	blockHash := common.BigToHash(big.NewInt(number))
+	tx := &Transaction{
+		Nonce:            3,
+		GasPrice:         big.NewInt(2e9),
+		GasLimit:         50000,
+		To:               &to,
+		Value:            big.NewInt(1e18),
+		Input:            []byte{1, 2, 3, 4},
+		From:             common.HexToAddress("0x0000000000000000000000000000000000000001"),
+		V:                big.NewInt(155),
+		R:                big.NewInt(1),
+		S:                big.NewInt(2),
+		Hash:             common.HexToHash("0xabcd"),
+		BlockNumber:      big.NewInt(number),
+		BlockHash:        blockHash,
+		TransactionIndex: 0,
+	}
+	block := &Block{
+		ParentHash:      common.BigToHash(big.NewInt(number - 1)),
+		Sha3Uncles:      types.EmptyUncleHash,
+		Miner:           common.HexToAddress("0x0000000000000000000000000000000000000003"),
+		Signers:         []common.Address{common.HexToAddress("0x0000000000000000000000000000000000000003")},
+		Signer:          []byte{0xaa, 0xbb},
+		StateRoot:       common.HexToHash("0x01"),
+		TxsRoot:         common.HexToHash("0x02"),
+		ReceiptsRoot:    common.HexToHash("0x03"),
+		LogsBloom:       new(types.Bloom),
+		Difficulty:      big.NewInt(5),
+		TotalDifficulty: big.NewInt(500),
+		Number:          big.NewInt(number),
+		GasLimit:        8000000,
+		GasUsed:         21000,
+		Timestamp:       time.Unix(1550000000, 0).UTC(),
+		ExtraData:       []byte{0xde, 0xad},
+		Hash:            blockHash,
+		TxDetails:       []*Transaction{tx},
+		Uncles:          []common.Hash{},
+	}
+	receipt := &Receipt{
+		Status:            types.ReceiptStatusSuccessful,
+		CumulativeGasUsed: 21000,
+		Bloom:             types.Bloom{1},
+		Logs: []*types.Log{{
+			Address:     to,
+			Topics:      []common.Hash{common.HexToHash("0x04")},
+			Data:        []byte{5},
+			BlockNumber: uint64(number),
+			TxHash:      tx.Hash,
+			BlockHash:   blockHash,
+		}},
+		TxHash:      tx.Hash,
+		GasUsed:     21000,
+		BlockHash:   blockHash,
+		BlockNumber: uint64(number),
+		From:        tx.From,
+		To:          &to,
+	}
+	return block, tx, receipt
+}
+
+// cacheMock is a MockClient at head 100, which serves a single block, transaction and
+// receipt, and counts the calls for them.
+type cacheMock struct {
+	MockClient
+	calls int
+}
+
+func newCacheMock(block *Block, tx *Transaction, receipt *Receipt) *cacheMock {
+	m := &cacheMock{}
+	m.GetBlockByNumberFunc = func(ctx context.Context, number *big.Int, includeTxs bool) (*Block, error) {
+		if number == nil {
+			return &Block{Number: big.NewInt(100)}, nil
+		}
+		m.calls++
+		return block, nil
+	}
+	if block == nil {
+		return m
+	}
+	m.GetBlockByHashFunc = func(ctx context.Context, hash string, includeTxs bool) (*Block, error) {
+		m.calls++
+		return block, nil
+	}
+	m.GetTransactionByHashFunc = func(ctx context.Context, hash common.Hash) (*Transaction, error) {
+		m.calls++
+		return tx, nil
+	}
+	m.GetTransactionReceiptFunc = func(ctx context.Context, hash common.Hash) (*Receipt, error) {
+		m.calls++
+		return receipt, nil
+	}
+	return m
+}
+
+// fetchAll gets the block by hash and number, the transaction, and the receipt.
+func fetchAll(t *testing.T, c Client, block *Block, tx *Transaction) (*Block, *Block, *Transaction, *Receipt) {
+	t.Helper()
+	ctx := context.Background()
+	byHash, err := c.GetBlockByHash(ctx, block.Hash.Hex(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byNumber, err := c.GetBlockByNumber(ctx, block.Number, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotTx, err := c.GetTransactionByHash(ctx, tx.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt, err := c.GetTransactionReceipt(ctx, tx.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return byHash, byNumber, gotTx, receipt
+}
+
+func TestCachingClientDisk(t *testing.T) {
+	dir, err := ioutil.TempDir("", "web3-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	block, tx, receipt := cacheTestData(50)
+	c, err := NewCachingClient(newCacheMock(block, tx, receipt), CacheOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetchAll(t, c, block, tx)
+
+	// Another client reads the same directory, without any data from its node.
+	m := newCacheMock(nil, nil, nil)
+	c, err = NewCachingClient(m, CacheOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byHash, byNumber, gotTx, gotReceipt := fetchAll(t, c, block, tx)
+	if m.calls != 0 {
+		t.Errorf("expected all data from the cache but got %d calls", m.calls)
+	}
+	if !reflect.DeepEqual(byHash, block) {
+		t.Errorf("expected block by hash %+v but got %+v", block, byHash)
+	}
+	if !reflect.DeepEqual(byNumber, block) {
+		t.Errorf("expected block by number %+v but got %+v", block, byNumber)
+	}
+	if !reflect.DeepEqual(gotTx, tx) {
+		t.Errorf("expected transaction %+v but got %+v", tx, gotTx)
+	}
+	if !reflect.DeepEqual(gotReceipt, receipt) {
+		t.Errorf("expected receipt %+v but got %+v", receipt, gotReceipt)
+	}
+}
+
+func TestCachingClientNotFinal(t *testing.T) {
+	dir, err := ioutil.TempDir("", "web3-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// Block 89 is 11 blocks below the head of 100, within the finality depth.
+	block, tx, receipt := cacheTestData(89)
+	m := newCacheMock(block, tx, receipt)
+	c, err := NewCachingClient(m, CacheOptions{Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetchAll(t, c, block, tx)
+	fetchAll(t, c, block, tx)
+	if m.calls != 8 {
+		t.Errorf("expected every call to reach the node but got %d calls", m.calls)
+	}
+
+	// Pending transactions and the latest data are not cached either.
+	m.GetTransactionByHashFunc = func(ctx context.Context, hash common.Hash) (*Transaction, error) {
+		m.calls++
+		return &Transaction{Hash: hash}, nil
+	}
+	m.GetBalanceFunc = func(ctx context.Context, address string, blockNumber *big.Int) (*big.Int, error) {
+		m.calls++
+		return big.NewInt(1), nil
+	}
+	ctx := context.Background()
+	m.calls = 0
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetTransactionByHash(ctx, tx.Hash); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.GetBalance(ctx, tx.From.Hex(), nil); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := c.GetBlockByNumber(ctx, nil, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if m.calls != 4 {
+		t.Errorf("expected every call to reach the node but got %d calls", m.calls)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		t.Errorf("unexpected cache entry %s", f.Name())
+	}
+}
+
+func TestCachingClientBatch(t *testing.T) {
+	ctx := context.Background()
+	var m MockClient
+	m.GetBlockByNumberFunc = func(ctx context.Context, number *big.Int, includeTxs bool) (*Block, error) {
+		return &Block{Number: big.NewInt(100)}, nil
+	}
+	var ranges [][2]int64
+	m.GetBlocksByNumberFunc = func(ctx context.Context, start, end *big.Int, includeTxs bool) ([]*Block, error) {
+		ranges = append(ranges, [2]int64{start.Int64(), end.Int64()})
+		var blocks []*Block
+		for n := start.Int64(); n <= end.Int64(); n++ {
+			b, _, _ := cacheTestData(n)
+			blocks = append(blocks, b)
+		}
+		return blocks, nil
+	}
+	failed := common.HexToHash("0xf0")
+	var requested [][]common.Hash
+	m.GetReceiptsFunc = func(ctx context.Context, hashes []common.Hash) ([]*Receipt, error) {
+		requested = append(requested, hashes)
+		receipts := make([]*Receipt, len(hashes))
+		errs := make([]error, len(hashes))
+		for i, hash := range hashes {
+			if hash == failed {
+				errs[i] = NotFoundErr
+				continue
+			}
+			_, _, receipts[i] = cacheTestData(hash.Big().Int64())
+		}
+		return receipts, simBatchError(errs)
+	}
+	var balanceCalls int
+	m.GetBalancesFunc = func(ctx context.Context, addresses []common.Address, blockNumber *big.Int) ([]*big.Int, error) {
+		balanceCalls++
+		balances := make([]*big.Int, len(addresses))
+		for i, addr := range addresses {
+			balances[i] = addr.Big()
+		}
+		return balances, nil
+	}
+	c, err := NewCachingClient(&m, CacheOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Blocks 84 to 86 are final, so only the blocks around them are requested again.
+	if _, err := c.GetBlocksByNumber(ctx, big.NewInt(84), big.NewInt(86), false); err != nil {
+		t.Fatal(err)
+	}
+	blocks, err := c.GetBlocksByNumber(ctx, big.NewInt(80), big.NewInt(90), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, b := range blocks {
+		if b == nil || b.Number.Int64() != int64(80+i) {
+			t.Fatalf("expected block %d but got %v", 80+i, b)
+		}
+	}
+	if exp := [][2]int64{{84, 86}, {80, 83}, {87, 90}}; !reflect.DeepEqual(ranges, exp) {
+		t.Errorf("expected ranges %v but got %v", exp, ranges)
+	}
+
+	// The receipt in block 50 is final, the one in block 95 is not.
+	hashes := []common.Hash{common.BigToHash(big.NewInt(50)), common.BigToHash(big.NewInt(95)), failed}
+	for i := 0; i < 2; i++ {
+		receipts, err := c.GetReceipts(ctx, hashes)
+		berr, ok := err.(*BatchError)
+		if !ok {
+			t.Fatalf("expected *BatchError but got %v", err)
+		}
+		if len(berr.Errors) != 3 || berr.Errors[0] != nil || berr.Errors[1] != nil || berr.Errors[2] != NotFoundErr {
+			t.Errorf("expected only the last receipt to fail but got %v", berr.Errors)
+		}
+		if receipts[0] == nil || receipts[0].BlockNumber != 50 || receipts[1] == nil || receipts[1].BlockNumber != 95 || receipts[2] != nil {
+			t.Errorf("unexpected receipts %v", receipts)
+		}
+	}
+	if exp := [][]common.Hash{hashes, hashes[1:]}; !reflect.DeepEqual(requested, exp) {
+		t.Errorf("expected receipt requests %v but got %v", exp, requested)
+	}
+
+	addrs := []common.Address{common.HexToAddress("0x01"), common.HexToAddress("0x02")}
+	for i := 0; i < 2; i++ {
+		balances, err := c.GetBalances(ctx, addrs, big.NewInt(50))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j, b := range balances {
+			if b.Cmp(addrs[j].Big()) != 0 {
+				t.Errorf("expected balance %s but got %s", addrs[j].Big(), b)
+			}
+		}
+	}
+	if _, err := c.GetBalances(ctx, addrs, nil); err != nil {
+		t.Fatal(err)
+	}
+	if balanceCalls != 2 {
+		t.Errorf("expected 2 balance requests but got %d", balanceCalls)
+	}
+}
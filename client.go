@@ -19,6 +19,11 @@ type Client interface {
 	GetBalance(ctx context.Context, address string, blockNumber *big.Int) (*big.Int, error)
 	// GetCode returns the code for an address at the given block number (nil for latest).
 	GetCode(ctx context.Context, address string, blockNumber *big.Int) ([]byte, error)
+	// GetStorageAt returns the value of a storage slot for an address at the given block number (nil for latest).
+	GetStorageAt(ctx context.Context, address common.Address, slot common.Hash, blockNumber *big.Int) (common.Hash, error)
+	// GetProof returns the account and storage slot values for an address at the given block number (nil for
+	// latest), with Merkle proofs which can be checked against the block's StateRoot with VerifyProof.
+	GetProof(ctx context.Context, address common.Address, slots []common.Hash, blockNumber *big.Int) (*AccountProof, error)
 	// GetBlockByNumber returns block details by number (nil for latest), optionally including full txs.
 	GetBlockByNumber(ctx context.Context, number *big.Int, includeTxs bool) (*Block, error)
 	// GetBlockByHash returns block details for the given hash, optionally include full transaction details.
@@ -42,27 +47,78 @@ type Client interface {
 	GetPendingTransactionCount(ctx context.Context, account common.Address) (uint64, error)
 	// SendRawTransaction sends the signed raw transaction bytes.
 	SendRawTransaction(ctx context.Context, tx []byte) error
-	// Call executes a call without submitting a transaction.
+	// Call executes a call without submitting a transaction. If the node reports that the
+	// call reverted, the error is a *RevertError.
 	Call(ctx context.Context, msg CallMsg) ([]byte, error)
+	// EstimateGas returns an estimate of the gas required to execute msg as a transaction.
+	EstimateGas(ctx context.Context, msg CallMsg) (uint64, error)
+	// TraceTransaction replays a mined transaction with debug_traceTransaction, and returns the trace
+	// produced by the tracer selected by cfg. Requires a node with the debug API enabled.
+	TraceTransaction(ctx context.Context, hash common.Hash, cfg *TraceConfig) (*Trace, error)
+	// TraceCall executes msg like Call with debug_traceCall, and returns the trace produced by the
+	// tracer selected by cfg. Requires a node with the debug API enabled.
+	TraceCall(ctx context.Context, msg CallMsg, cfg *TraceConfig) (*Trace, error)
+	// GetLogs returns the logs matching the filter query.
+	GetLogs(ctx context.Context, q FilterQuery) ([]*types.Log, error)
+	// SubscribeNewHeads delivers each new block (with tx hashes) to ch, filling in any blocks which
+	// were skipped by the node or missed while disconnected. Requires a websocket connection.
+	SubscribeNewHeads(ctx context.Context, ch chan<- *Block) (Subscription, error)
+	// SubscribeLogs delivers logs matching q to ch, backfilling logs which were missed while disconnected.
+	// The block range of q is ignored. Requires a websocket connection.
+	SubscribeLogs(ctx context.Context, q FilterQuery, ch chan<- *types.Log) (Subscription, error)
+	// SubscribePendingTransactions delivers the hashes of new pending transactions to ch.
+	// Requires a websocket connection.
+	SubscribePendingTransactions(ctx context.Context, ch chan<- common.Hash) (Subscription, error)
+	// GetReceipts returns the receipts for the transaction hashes, using batch requests.
+	// If any items fail, the error is a *BatchError and the failed receipts are nil.
+	GetReceipts(ctx context.Context, hashes []common.Hash) ([]*Receipt, error)
+	// GetBlocksByNumber returns the blocks numbered from start to end inclusive, using batch
+	// requests. If any items fail, the error is a *BatchError and the failed blocks are nil.
+	// Ranges of more than MaxBlockRange blocks are rejected.
+	GetBlocksByNumber(ctx context.Context, start, end *big.Int, includeTxs bool) ([]*Block, error)
+	// GetBalances returns the balances of the addresses at the given block number (nil for latest),
+	// using batch requests. If any items fail, the error is a *BatchError and the failed balances are nil.
+	GetBalances(ctx context.Context, addresses []common.Address, blockNumber *big.Int) ([]*big.Int, error)
 	Close()
 }
 
+// DefaultMaxBatchSize is the default limit on the number of requests in a single batch.
+const DefaultMaxBatchSize = 100
+
+// ClientOption configures a client created by Dial or NewClient.
+type ClientOption func(*client)
+
+// WithMaxBatchSize limits the number of requests sent in a single batch. Larger batch
+// calls are split into chunks of at most n requests.
+func WithMaxBatchSize(n int) ClientOption {
+	return func(c *client) {
+		if n > 0 {
+			c.maxBatchSize = n
+		}
+	}
+}
+
 // Dial returns a new client backed by dialing url (supported schemes "http", "https", "ws" and "wss").
-func Dial(url string) (Client, error) {
+func Dial(url string, opts ...ClientOption) (Client, error) {
 	r, err := rpc.Dial(url)
 	if err != nil {
 		return nil, err
 	}
-	return NewClient(r), nil
+	return NewClient(r, opts...), nil
 }
 
 // NewClient returns a new client backed by an existing rpc.Client.
-func NewClient(r *rpc.Client) Client {
-	return &client{r: r}
+func NewClient(r *rpc.Client, opts ...ClientOption) Client {
+	c := &client{r: r, maxBatchSize: DefaultMaxBatchSize}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 type client struct {
-	r *rpc.Client
+	r            *rpc.Client
+	maxBatchSize int
 }
 
 func (c *client) Close() {
@@ -71,13 +127,37 @@ func (c *client) Close() {
 
 func (c *client) Call(ctx context.Context, msg CallMsg) ([]byte, error) {
 	var result hexutil.Bytes
-	err := c.r.CallContext(ctx, &result, "eth_call", toCallArg(msg), "latest")
+	err := c.r.CallContext(ctx, &result, "eth_call", toCallArg(msg), toBlockNumArg(msg.BlockNumber))
 	if err != nil {
-		return nil, err
+		return nil, revertErrorFrom(err)
 	}
 	return result, err
 }
 
+func (c *client) EstimateGas(ctx context.Context, msg CallMsg) (uint64, error) {
+	var result hexutil.Uint64
+	err := c.r.CallContext(ctx, &result, "eth_estimateGas", toCallArg(msg))
+	return uint64(result), err
+}
+
+func (c *client) TraceTransaction(ctx context.Context, hash common.Hash, cfg *TraceConfig) (*Trace, error) {
+	var raw json.RawMessage
+	err := c.r.CallContext(ctx, &raw, "debug_traceTransaction", hash, toTraceConfigArg(cfg))
+	if err != nil {
+		return nil, err
+	}
+	return decodeTrace(raw, cfg)
+}
+
+func (c *client) TraceCall(ctx context.Context, msg CallMsg, cfg *TraceConfig) (*Trace, error) {
+	var raw json.RawMessage
+	err := c.r.CallContext(ctx, &raw, "debug_traceCall", toCallArg(msg), toBlockNumArg(msg.BlockNumber), toTraceConfigArg(cfg))
+	if err != nil {
+		return nil, err
+	}
+	return decodeTrace(raw, cfg)
+}
+
 func (c *client) GetBalance(ctx context.Context, address string, blockNumber *big.Int) (*big.Int, error) {
 	var result hexutil.Big
 	err := c.r.CallContext(ctx, &result, "eth_getBalance", common.HexToAddress(address), toBlockNumArg(blockNumber))
@@ -90,6 +170,26 @@ func (c *client) GetCode(ctx context.Context, address string, blockNumber *big.I
 	return result, err
 }
 
+func (c *client) GetStorageAt(ctx context.Context, address common.Address, slot common.Hash, blockNumber *big.Int) (common.Hash, error) {
+	var result hexutil.Bytes
+	err := c.r.CallContext(ctx, &result, "eth_getStorageAt", address, slot, toBlockNumArg(blockNumber))
+	return common.BytesToHash(result), err
+}
+
+func (c *client) GetProof(ctx context.Context, address common.Address, slots []common.Hash, blockNumber *big.Int) (*AccountProof, error) {
+	if slots == nil {
+		slots = []common.Hash{}
+	}
+	var p *AccountProof
+	err := c.r.CallContext(ctx, &p, "eth_getProof", address, slots, toBlockNumArg(blockNumber))
+	if err != nil {
+		return nil, err
+	} else if p == nil {
+		return nil, NotFoundErr
+	}
+	return p, nil
+}
+
 func (c *client) GetBlockByNumber(ctx context.Context, number *big.Int, includeTxs bool) (*Block, error) {
 	return c.getBlock(ctx, "eth_getBlockByNumber", toBlockNumArg(number), includeTxs)
 }
@@ -195,6 +295,12 @@ func (c *client) SendRawTransaction(ctx context.Context, tx []byte) error {
 	return c.r.CallContext(ctx, nil, "eth_sendRawTransaction", common.ToHex(tx))
 }
 
+func (c *client) GetLogs(ctx context.Context, q FilterQuery) ([]*types.Log, error) {
+	var logs []*types.Log
+	err := c.r.CallContext(ctx, &logs, "eth_getLogs", toFilterArg(q))
+	return logs, err
+}
+
 func (c *client) getBlock(ctx context.Context, method string, hashOrNum string, includeTxs bool) (*Block, error) {
 	var raw json.RawMessage
 	err := c.r.CallContext(ctx, &raw, method, hashOrNum, includeTxs)
@@ -207,18 +313,8 @@ func (c *client) getBlock(ctx context.Context, method string, hashOrNum string,
 	if err := json.Unmarshal(raw, &block); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal json response: %v", err)
 	}
-	// Quick-verify transaction and uncle lists. This mostly helps with debugging the server.
-	if block.Sha3Uncles == types.EmptyUncleHash && len(block.Uncles) > 0 {
-		return nil, fmt.Errorf("server returned non-empty uncle list but block header indicates no uncles")
-	}
-	if block.Sha3Uncles != types.EmptyUncleHash && len(block.Uncles) == 0 {
-		return nil, fmt.Errorf("server returned empty uncle list but block header indicates uncles")
-	}
-	if block.TxsRoot == types.EmptyRootHash && block.TxCount() > 0 {
-		return nil, fmt.Errorf("server returned non-empty transaction list but block header indicates no transactions")
-	}
-	if block.TxsRoot != types.EmptyRootHash && len(block.TxsRoot) == 0 {
-		return nil, fmt.Errorf("server returned empty transaction list but block header indicates transactions")
+	if err := verifyBlock(&block); err != nil {
+		return nil, err
 	}
 	// Load uncles because they are not included in the block response.
 	var uncles []*types.Header
@@ -247,6 +343,23 @@ func (c *client) getBlock(ctx context.Context, method string, hashOrNum string,
 	return &block, nil
 }
 
+// verifyBlock quick-verifies the transaction and uncle lists of block. This mostly helps with debugging the server.
+func verifyBlock(block *Block) error {
+	if block.Sha3Uncles == types.EmptyUncleHash && len(block.Uncles) > 0 {
+		return fmt.Errorf("server returned non-empty uncle list but block header indicates no uncles")
+	}
+	if block.Sha3Uncles != types.EmptyUncleHash && len(block.Uncles) == 0 {
+		return fmt.Errorf("server returned empty uncle list but block header indicates uncles")
+	}
+	if block.TxsRoot == types.EmptyRootHash && block.TxCount() > 0 {
+		return fmt.Errorf("server returned non-empty transaction list but block header indicates no transactions")
+	}
+	if block.TxsRoot != types.EmptyRootHash && len(block.TxsRoot) == 0 {
+		return fmt.Errorf("server returned empty transaction list but block header indicates transactions")
+	}
+	return nil
+}
+
 func toBlockNumArg(number *big.Int) string {
 	if number == nil {
 		return "latest"
@@ -254,6 +367,20 @@ func toBlockNumArg(number *big.Int) string {
 	return hexutil.EncodeBig(number)
 }
 
+func toFilterArg(q FilterQuery) interface{} {
+	arg := map[string]interface{}{
+		"topics": q.Topics,
+	}
+	if len(q.Addresses) > 0 {
+		arg["address"] = q.Addresses
+	}
+	if q.FromBlock != nil {
+		arg["fromBlock"] = toBlockNumArg(q.FromBlock)
+	}
+	arg["toBlock"] = toBlockNumArg(q.ToBlock)
+	return arg
+}
+
 func toCallArg(msg CallMsg) interface{} {
 	arg := map[string]interface{}{
 		"from": msg.From,
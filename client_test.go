@@ -2,13 +2,38 @@ package web3
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"math/big"
+	"path/filepath"
 )
 
-func ExampleRPCClient_GetBlockByNumber() {
+var record = flag.Bool("record", false, "record the RPC fixtures in testdata from the live networks")
+
+// dialTest returns a client which replays the fixture for network, or records a new one
+// with -record. The fixtures in testdata are synthetic: they were written by hand in the
+// shape of the networks' responses rather than recorded, so the examples only check that
+// the client decodes them, not the behavior of the live networks.
+func dialTest(network string) (Client, error) {
+	var name string
+	switch network {
+	case mainnetURL:
+		name = "mainnet"
+	case testnetURL:
+		name = "testnet"
+	default:
+		panic("unsupported network: " + network)
+	}
+	path := filepath.Join("testdata", name+".json")
+	if *record {
+		return DialRecord(network, path)
+	}
+	return DialReplay(path)
+}
+
+func ExampleClient_GetBlockByNumber() {
 	for _, network := range []string{mainnetURL, testnetURL} {
-		exampleRPCClient_GetBlockByNumber(network)
+		exampleClient_GetBlockByNumber(network)
 	}
 	// Output:
 	// Got ID.
@@ -23,8 +48,8 @@ func ExampleRPCClient_GetBlockByNumber() {
 	// Got initial alloc balance.
 }
 
-func exampleRPCClient_GetBlockByNumber(url string) {
-	c, err := Dial(url)
+func exampleClient_GetBlockByNumber(url string) {
+	c, err := dialTest(url)
 	if err != nil {
 		fmt.Printf("Failed to connect to network %q: %v\n", url, err)
 		return
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/gochain-io/web3"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// CreateAccount prints a new account, or stores it in the keystore directory when set.
+func CreateAccount(keystoreDir, pwFile string, lightKDF bool) {
+	if keystoreDir == "" {
+		acc, err := web3.CreateAccount()
+		if err != nil {
+			fatalExit(err)
+		}
+		fmt.Printf("Private key: %v\n", acc.PrivateKey())
+		fmt.Printf("Public address: %v\n", acc.PublicKey())
+		return
+	}
+	ks := web3.OpenKeystore(keystoreDir, lightKDF)
+	acct, err := ks.Create(readNewPassword(pwFile, "New password: "))
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot create account: %v", err))
+	}
+	printKeystoreAccount(acct)
+}
+
+// CreateMnemonic prints a new BIP-39 mnemonic and its first account.
+func CreateMnemonic(words int, passphrase, path string) {
+	mnemonic, err := web3.NewMnemonic(words)
+	if err != nil {
+		fatalExit(err)
+	}
+	acc, err := web3.MnemonicAccount(mnemonic, passphrase, path, 0)
+	if err != nil {
+		fatalExit(err)
+	}
+
+	switch format {
+	case "json":
+		fmt.Println(marshalJSON(map[string]string{
+			"mnemonic":   mnemonic,
+			"path":       path + "/0",
+			"address":    acc.PublicKey(),
+			"privateKey": acc.PrivateKey(),
+		}))
+		return
+	}
+
+	fmt.Printf("Mnemonic: %v\n", mnemonic)
+	fmt.Printf("Path: %v/0\n", path)
+	fmt.Printf("Private key: %v\n", acc.PrivateKey())
+	fmt.Printf("Public address: %v\n", acc.PublicKey())
+}
+
+// DeriveAccounts prints count accounts starting at index under the base path, derived
+// from the mnemonic. The mnemonic is prompted for when empty.
+func DeriveAccounts(mnemonic, passphrase, path string, index, count uint) {
+	if mnemonic == "" {
+		mnemonic = readSecret("Mnemonic: ", fmt.Errorf("Missing mnemonic: set %s or use --mnemonic", mnemonicVarName))
+	}
+	seed, err := web3.MnemonicToSeed(mnemonic, passphrase)
+	if err != nil {
+		fatalExit(err)
+	}
+	base, err := web3.ParseDerivationPath(path)
+	if err != nil {
+		fatalExit(err)
+	}
+	// Indexes from 2^31 are hardened, and cannot be derived as normal children.
+	if index >= 1<<31 || count > 1<<31-index {
+		fatalExit(fmt.Errorf("Invalid account range: index + count must not exceed %d", uint(1<<31)))
+	}
+	type derived struct {
+		Path       string `json:"path"`
+		Address    string `json:"address"`
+		PrivateKey string `json:"privateKey"`
+	}
+	var accts []derived
+	for i := index; i < index+count; i++ {
+		p := base.Child(uint32(i))
+		acc, err := web3.DeriveAccount(seed, p)
+		if err != nil {
+			fatalExit(err)
+		}
+		accts = append(accts, derived{
+			Path:       p.String(),
+			Address:    acc.PublicKey(),
+			PrivateKey: acc.PrivateKey(),
+		})
+	}
+
+	switch format {
+	case "json":
+		fmt.Println(marshalJSON(accts))
+		return
+	}
+
+	for _, a := range accts {
+		fmt.Printf("Path: %v\n", a.Path)
+		fmt.Printf("Private key: %v\n", a.PrivateKey)
+		fmt.Printf("Public address: %v\n", a.Address)
+	}
+}
+
+// VanityAccount searches for an account whose address, or the address of its first
+// contract, matches opts. Progress is reported on stderr.
+func VanityAccount(ctx context.Context, opts web3.VanityOptions, pattern string) {
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			fatalExit(fmt.Errorf("Invalid regex: %v", err))
+		}
+		opts.Regexp = re
+	}
+	// Overwrite the progress line on terminals.
+	end := "\n"
+	if terminal.IsTerminal(int(os.Stderr.Fd())) {
+		end = "\r"
+	}
+	opts.Progress = func(p web3.VanityProgress) {
+		expected, eta := "unknown", "unknown"
+		if p.ETA > 0 {
+			expected = fmt.Sprintf("~%.0f", p.Difficulty)
+			eta = p.ETA.Round(time.Second).String()
+			if opts.Regexp != nil {
+				// The difficulty only accounts for the prefix and suffix.
+				expected, eta = "at least "+expected, "at least "+eta
+			}
+		}
+		fmt.Fprintf(os.Stderr, "Attempts: %d of %s expected (%.0f/s), expected time to match: %s   %s", p.Attempts, expected, p.Rate, eta, end)
+	}
+	r, err := web3.SearchVanity(ctx, opts)
+	if end == "\r" {
+		fmt.Fprintln(os.Stderr)
+	}
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot find vanity address: %v", err))
+	}
+
+	switch format {
+	case "json":
+		out := map[string]interface{}{
+			"address":    r.Address.Hex(),
+			"privateKey": r.Account.PrivateKey(),
+			"attempts":   r.Attempts,
+		}
+		if opts.Contract {
+			out["contractAddress"] = r.Contract.Hex()
+		}
+		fmt.Println(marshalJSON(out))
+		return
+	}
+
+	fmt.Printf("Private key: %v\n", r.Account.PrivateKey())
+	fmt.Printf("Public address: %v\n", r.Address.Hex())
+	if opts.Contract {
+		fmt.Printf("First contract address: %v\n", r.Contract.Hex())
+	}
+	fmt.Printf("Attempts: %v\n", r.Attempts)
+}
+
+// ImportAccount stores the private key, or the account in the encrypted keyFile, in the
+// keystore directory. Accounts imported from key files keep their password.
+func ImportAccount(keystoreDir, privateKey, keyFile, pwFile string, lightKDF bool) {
+	if keystoreDir == "" {
+		fatalExit(errors.New("Missing --keystore directory"))
+	}
+	ks := web3.OpenKeystore(keystoreDir, lightKDF)
+	var acct web3.KeystoreAccount
+	var err error
+	switch {
+	case keyFile != "":
+		keyJSON, rerr := ioutil.ReadFile(keyFile)
+		if rerr != nil {
+			fatalExit(fmt.Errorf("Cannot read key file %q: %v", keyFile, rerr))
+		}
+		pw := readPassword(pwFile, "Password: ")
+		acct, err = ks.ImportJSON(keyJSON, pw, pw)
+	case privateKey != "":
+		key, perr := web3.ParsePrivateKey(privateKey)
+		if perr != nil {
+			fatalExit(fmt.Errorf("Invalid private key: %v", perr))
+		}
+		acct, err = ks.Import(key, readNewPassword(pwFile, "New password: "))
+	default:
+		fatalExit(fmt.Errorf("Missing private key: set %s, or use --private-key or a key file", pkVarName))
+	}
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot import account: %v", err))
+	}
+	printKeystoreAccount(acct)
+}
+
+// ExportAccount prints the decrypted private key of a keystore account.
+func ExportAccount(keystoreDir, account, pwFile string) {
+	if keystoreDir == "" {
+		fatalExit(errors.New("Missing --keystore directory"))
+	}
+	ks := web3.OpenKeystore(keystoreDir, false)
+	acc, err := ks.Export(keystoreAccount(ks, account), readPassword(pwFile, "Password: "))
+	if err != nil {
+		fatalExit(err)
+	}
+
+	switch format {
+	case "json":
+		fmt.Println(marshalJSON(map[string]string{
+			"address":    acc.PublicKey(),
+			"privateKey": acc.PrivateKey(),
+		}))
+		return
+	}
+
+	fmt.Printf("Private key: %v\n", acc.PrivateKey())
+	fmt.Printf("Public address: %v\n", acc.PublicKey())
+}
+
+// ListAccounts prints the accounts in the keystore directory.
+func ListAccounts(keystoreDir string) {
+	if keystoreDir == "" {
+		fatalExit(errors.New("Missing --keystore directory"))
+	}
+	accts := web3.OpenKeystore(keystoreDir, false).Accounts()
+
+	switch format {
+	case "json":
+		if accts == nil {
+			accts = []web3.KeystoreAccount{}
+		}
+		fmt.Println(marshalJSON(accts))
+		return
+	}
+
+	for _, a := range accts {
+		fmt.Println(a.Address.Hex(), a.Path)
+	}
+}
+
+// UpdateAccountPassword re-encrypts the key of a keystore account with a new password.
+func UpdateAccountPassword(keystoreDir, account, pwFile, newPwFile string, lightKDF bool) {
+	if keystoreDir == "" {
+		fatalExit(errors.New("Missing --keystore directory"))
+	}
+	ks := web3.OpenKeystore(keystoreDir, lightKDF)
+	addr := keystoreAccount(ks, account)
+	pw := readPassword(pwFile, "Password: ")
+	if err := ks.UpdatePassword(addr, pw, readNewPassword(newPwFile, "New password: ")); err != nil {
+		fatalExit(err)
+	}
+	fmt.Println("Updated password of", addr.Hex())
+}
+
+func printKeystoreAccount(acct web3.KeystoreAccount) {
+	switch format {
+	case "json":
+		fmt.Println(marshalJSON(acct))
+		return
+	}
+
+	fmt.Printf("Public address: %v\n", acct.Address.Hex())
+	fmt.Printf("Key file: %v\n", acct.Path)
+}
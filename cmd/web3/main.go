@@ -12,6 +12,7 @@ import (
 	"os"
 	"os/signal"
 	"sort"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -20,6 +21,7 @@ import (
 	"github.com/gochain-io/gochain/v3/common"
 	"github.com/gochain-io/gochain/v3/common/hexutil"
 	"github.com/gochain-io/gochain/v3/core/types"
+	"github.com/gochain-io/gochain/v3/crypto"
 	"github.com/gochain-io/web3"
 	"github.com/gochain-io/web3/assets"
 	"github.com/urfave/cli"
@@ -27,8 +29,12 @@ import (
 
 // Flags
 var (
-	verbose bool
-	format  string
+	verbose       bool
+	format        string
+	chainID       string
+	gasLimit      uint64
+	gasMultiplier float64
+	confirmations uint64
 )
 
 const (
@@ -37,10 +43,11 @@ const (
 ( (_-. )(_)(( (__  ) _ (  /(__)\  _)(_  )  ( 
  \___/(_____)\___)(_) (_)(__)(__)(____)(_)\_)`
 
-	pkVarName      = "WEB3_PRIVATE_KEY"
-	addrVarName    = "WEB3_ADDRESS"
-	networkVarName = "WEB3_NETWORK"
-	rpcURLVarName  = "WEB3_RPC_URL"
+	pkVarName       = "WEB3_PRIVATE_KEY"
+	addrVarName     = "WEB3_ADDRESS"
+	networkVarName  = "WEB3_NETWORK"
+	rpcURLVarName   = "WEB3_RPC_URL"
+	mnemonicVarName = "WEB3_MNEMONIC"
 )
 
 func main() {
@@ -59,6 +66,24 @@ func main() {
 	var netName, rpcUrl, function, contractAddress, toContractAddress, contractFile, privateKey, txFormat, txInputFormat, recepientAddress string
 	var amount int
 	var testnet, waitForReceipt, upgradeable bool
+	// Flags of the transaction speedup and cancel commands.
+	replaceFlags := append([]cli.Flag{
+		cli.StringFlag{
+			Name:        "private-key, pk",
+			Usage:       "Private key",
+			EnvVar:      pkVarName,
+			Destination: &privateKey,
+			Hidden:      false},
+		cli.StringFlag{
+			Name:  "gas-price",
+			Usage: "Gas price in gwei. Default: the minimum replacement price, or the network gas price if higher",
+		},
+		cli.DurationFlag{
+			Name:  "timeout",
+			Usage: "How long to wait for either transaction to be mined",
+			Value: 5 * time.Minute,
+		},
+	}, signerFlags...)
 
 	app := cli.NewApp()
 	app.Name = "web3"
@@ -92,6 +117,11 @@ func main() {
 			Usage:       "Output format. Options: json. Default: human readable output.",
 			Destination: &format,
 			Hidden:      false},
+		cli.StringFlag{
+			Name:        "chain-id",
+			Usage:       "Chain ID to sign transactions with, overriding the network's. Either is verified against the RPC endpoint.",
+			Destination: &chainID,
+			Hidden:      false},
 	}
 	var network web3.Network
 	app.Before = func(*cli.Context) error {
@@ -136,6 +166,24 @@ func main() {
 			Action: func(c *cli.Context) {
 				GetTransactionDetails(ctx, network, c.Args().First(), txInputFormat)
 			},
+			Subcommands: []cli.Command{
+				{
+					Name:  "speedup",
+					Usage: "Replace a pending transaction with an identical one at a higher gas price",
+					Flags: replaceFlags,
+					Action: func(c *cli.Context) {
+						ReplaceTransaction(ctx, network, getSigner(ctx, c, privateKey), c.Args().First(), c.String("gas-price"), c.Duration("timeout"), false)
+					},
+				},
+				{
+					Name:  "cancel",
+					Usage: "Replace a pending transaction with a zero value transfer to yourself at a higher gas price",
+					Flags: replaceFlags,
+					Action: func(c *cli.Context) {
+						ReplaceTransaction(ctx, network, getSigner(ctx, c, privateKey), c.Args().First(), c.String("gas-price"), c.Duration("timeout"), true)
+					},
+				},
+			},
 		},
 		{
 			Name:    "receipt",
@@ -152,6 +200,25 @@ func main() {
 					Hidden:      false},
 			},
 		},
+		{
+			Name:      "trace",
+			Usage:     "Trace the calls made by a transaction, with debug_traceTransaction",
+			ArgsUsage: "TX_HASH",
+			Action: func(c *cli.Context) {
+				TraceTransaction(ctx, network, c.Args().First(), c.String("tracer"), c.StringSlice("abi"))
+			},
+			Flags: []cli.Flag{
+				cli.StringSliceFlag{
+					Name:  "abi",
+					Usage: "ABI file of a contract called by the transaction, to decode calls and errors. May be repeated",
+				},
+				cli.StringFlag{
+					Name:  "tracer",
+					Usage: "Tracer: call/struct. The struct tracer lists each opcode executed",
+					Value: "call",
+				},
+			},
+		},
 		{
 			Name:    "address",
 			Aliases: []string{"addr"},
@@ -168,6 +235,26 @@ func main() {
 				GetAddressDetails(ctx, network, c.Args().First(), privateKey)
 			},
 		},
+		{
+			Name:      "storage",
+			Usage:     "Value of a contract storage slot",
+			ArgsUsage: "ADDRESS SLOT",
+			Description: "SLOT is a number (decimal integer or hexadecimal with 0x prefix), or a name hashed with keccak256 to\n" +
+				"   derive the slot, eg: gochain.proxy.target",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "block",
+					Usage: "Block number (decimal integer). Default: latest",
+				},
+				cli.BoolFlag{
+					Name:  "proof",
+					Usage: "Fetch a Merkle proof of the value and verify it against the block's state root",
+				},
+			},
+			Action: func(c *cli.Context) {
+				GetStorage(ctx, network, c.Args().Get(0), c.Args().Get(1), c.String("block"), c.Bool("proof"))
+			},
+		},
 		{
 			Name:    "contract",
 			Aliases: []string{"c"},
@@ -196,9 +283,9 @@ func main() {
 						for i, v := range c.Args().Tail() {
 							args[i] = v
 						}
-						DeploySol(ctx, network.URL, privateKey, name, upgradeable, args...)
+						DeploySol(ctx, network, getSigner(ctx, c, privateKey), name, upgradeable, args...)
 					},
-					Flags: []cli.Flag{
+					Flags: append([]cli.Flag{
 						cli.StringFlag{
 							Name:        "private-key, pk",
 							Usage:       "The private key",
@@ -210,7 +297,23 @@ func main() {
 							Usage:       "Allow contract to be upgraded",
 							Destination: &upgradeable,
 							Hidden:      false},
-					},
+						cli.Uint64Flag{
+							Name:        "gas-limit",
+							Usage:       "Gas limit for the transaction. Default: estimated by the network",
+							Destination: &gasLimit,
+							Hidden:      false},
+						cli.Float64Flag{
+							Name:        "gas-multiplier",
+							Usage:       "Multiplier applied to the estimated gas limit. Default: 1.25",
+							Destination: &gasMultiplier,
+							Hidden:      false},
+						cli.Uint64Flag{
+							Name:        "confirmations",
+							Usage:       "Number of blocks to wait for, including the one with the transaction",
+							Destination: &confirmations,
+							Value:       1,
+							Hidden:      false},
+					}, signerFlags...),
 				},
 				{
 					Name:  "list",
@@ -234,9 +337,9 @@ func main() {
 						for i, v := range c.Args() {
 							args[i] = v
 						}
-						CallContract(ctx, network.URL, privateKey, contractAddress, contractFile, function, amount, waitForReceipt, args...)
+						CallContract(ctx, network, getSigner(ctx, c, privateKey), contractAddress, contractFile, function, amount, waitForReceipt, args...)
 					},
-					Flags: []cli.Flag{
+					Flags: append([]cli.Flag{
 						cli.StringFlag{
 							Name:        "function",
 							Usage:       "Target function name",
@@ -269,15 +372,31 @@ func main() {
 							Usage:       "Wait for the receipt for transact functions",
 							Destination: &waitForReceipt,
 							Hidden:      false},
-					},
+						cli.Uint64Flag{
+							Name:        "gas-limit",
+							Usage:       "Gas limit for the transaction. Default: estimated by the network",
+							Destination: &gasLimit,
+							Hidden:      false},
+						cli.Float64Flag{
+							Name:        "gas-multiplier",
+							Usage:       "Multiplier applied to the estimated gas limit. Default: 1.25",
+							Destination: &gasMultiplier,
+							Hidden:      false},
+						cli.Uint64Flag{
+							Name:        "confirmations",
+							Usage:       "Number of blocks to wait for, including the one with the transaction",
+							Destination: &confirmations,
+							Value:       1,
+							Hidden:      false},
+					}, signerFlags...),
 				},
 				{
 					Name:  "upgrade",
 					Usage: "Upgrade contract to new address",
 					Action: func(c *cli.Context) {
-						UpgradeContract(ctx, network.URL, privateKey, contractAddress, toContractAddress, amount)
+						UpgradeContract(ctx, network, getSigner(ctx, c, privateKey), contractAddress, toContractAddress, amount)
 					},
-					Flags: []cli.Flag{
+					Flags: append([]cli.Flag{
 						cli.StringFlag{
 							Name:        "address",
 							EnvVar:      addrVarName,
@@ -300,7 +419,7 @@ func main() {
 							EnvVar:      "WEB3_PRIVATE_KEY",
 							Destination: &privateKey,
 							Hidden:      false},
-					},
+					}, signerFlags...),
 				},
 				{
 					Name:  "target",
@@ -325,9 +444,9 @@ func main() {
 						if address == "" {
 							address = contractAddress
 						}
-						PauseContract(ctx, network.URL, privateKey, address, amount)
+						PauseContract(ctx, network, getSigner(ctx, c, privateKey), address, amount)
 					},
-					Flags: []cli.Flag{
+					Flags: append([]cli.Flag{
 						cli.StringFlag{
 							Name:        "address",
 							EnvVar:      addrVarName,
@@ -345,7 +464,7 @@ func main() {
 							EnvVar:      "WEB3_PRIVATE_KEY",
 							Destination: &privateKey,
 							Hidden:      false},
-					},
+					}, signerFlags...),
 				},
 				{
 					Name:  "resume",
@@ -355,9 +474,9 @@ func main() {
 						if address == "" {
 							address = contractAddress
 						}
-						ResumeContract(ctx, network.URL, privateKey, address, amount)
+						ResumeContract(ctx, network, getSigner(ctx, c, privateKey), address, amount)
 					},
-					Flags: []cli.Flag{
+					Flags: append([]cli.Flag{
 						cli.StringFlag{
 							Name:        "address",
 							EnvVar:      addrVarName,
@@ -375,7 +494,7 @@ func main() {
 							EnvVar:      "WEB3_PRIVATE_KEY",
 							Destination: &privateKey,
 							Hidden:      false},
-					},
+					}, signerFlags...),
 				},
 			},
 		},
@@ -448,22 +567,287 @@ func main() {
 				{
 					Name:  "create",
 					Usage: "Create a new account",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "keystore",
+							Usage: "Keystore directory to store the account in, encrypted. Default: print the private key",
+						},
+						cli.StringFlag{
+							Name:  "password-file",
+							Usage: "File containing the password for the key file. Default: prompt for the password",
+						},
+						cli.BoolFlag{
+							Name:  "lightkdf",
+							Usage: "Encrypt the key file with weaker scrypt parameters, which are faster to decrypt",
+						},
+						cli.BoolFlag{
+							Name:  "mnemonic",
+							Usage: "Create a BIP-39 mnemonic, and print its first account",
+						},
+						cli.IntFlag{
+							Name:  "words",
+							Usage: "Number of words of the mnemonic: 12, 15, 18, 21 or 24",
+							Value: 12,
+						},
+						cli.StringFlag{
+							Name:  "passphrase",
+							Usage: "Optional BIP-39 passphrase protecting the mnemonic",
+						},
+						cli.StringFlag{
+							Name:  "path",
+							Usage: "Base HD derivation path, to which the account index is appended",
+							Value: web3.DefaultBaseHDPath,
+						},
+					},
 					Action: func(c *cli.Context) {
-						acc, err := web3.CreateAccount()
-						if err != nil {
-							fatalExit(err)
+						if c.Bool("mnemonic") {
+							if c.String("keystore") != "" {
+								fatalExit(errors.New("Cannot use --mnemonic with --keystore: import a derived private key instead"))
+							}
+							CreateMnemonic(c.Int("words"), c.String("passphrase"), c.String("path"))
+							return
 						}
-						fmt.Printf("Private key: %v\n", acc.PrivateKey())
-						fmt.Printf("Public address: %v\n", acc.PublicKey())
+						CreateAccount(c.String("keystore"), c.String("password-file"), c.Bool("lightkdf"))
+					},
+				},
+				{
+					Name:  "derive",
+					Usage: "Derive accounts from a BIP-39 mnemonic",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:   "mnemonic",
+							Usage:  "The mnemonic. Default: prompt for the mnemonic",
+							EnvVar: mnemonicVarName,
+						},
+						cli.StringFlag{
+							Name:  "passphrase",
+							Usage: "Optional BIP-39 passphrase protecting the mnemonic",
+						},
+						cli.StringFlag{
+							Name:  "path",
+							Usage: "Base HD derivation path, to which the account index is appended",
+							Value: web3.DefaultBaseHDPath,
+						},
+						cli.UintFlag{
+							Name:  "index",
+							Usage: "Index of the first account",
+						},
+						cli.UintFlag{
+							Name:  "count",
+							Usage: "Number of accounts",
+							Value: 1,
+						},
+					},
+					Action: func(c *cli.Context) {
+						DeriveAccounts(c.String("mnemonic"), c.String("passphrase"), c.String("path"), c.Uint("index"), c.Uint("count"))
+					},
+				},
+				{
+					Name:  "vanity",
+					Usage: "Search for an account whose address matches a pattern, using all CPU cores",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "prefix",
+							Usage: "Hex digits the address starts with, eg: 0xc0ffee",
+						},
+						cli.StringFlag{
+							Name:  "suffix",
+							Usage: "Hex digits the address ends with",
+						},
+						cli.StringFlag{
+							Name:  "regex",
+							Usage: "Regular expression matched against the 40 hex digits of the address, without 0x",
+						},
+						cli.BoolFlag{
+							Name:  "checksum",
+							Usage: "Match the case of the patterns against the EIP-55 checksummed address",
+						},
+						cli.BoolFlag{
+							Name:  "contract",
+							Usage: "Match the address of the first contract deployed by the account, instead of the account",
+						},
+						cli.IntFlag{
+							Name:  "workers",
+							Usage: "Number of parallel workers. Default: the number of CPU cores",
+						},
+					},
+					Action: func(c *cli.Context) {
+						VanityAccount(ctx, web3.VanityOptions{
+							Prefix:        c.String("prefix"),
+							Suffix:        c.String("suffix"),
+							CaseSensitive: c.Bool("checksum"),
+							Contract:      c.Bool("contract"),
+							Workers:       c.Int("workers"),
+						}, c.String("regex"))
+					},
+				},
+				{
+					Name:      "import",
+					Usage:     "Import a private key or an encrypted key file into a keystore directory",
+					ArgsUsage: "[KEY_FILE]",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "keystore",
+							Usage: "Keystore directory to import the account into",
+						},
+						cli.StringFlag{
+							Name:   "private-key, pk",
+							Usage:  "Private key to import, if no key file is given",
+							EnvVar: pkVarName,
+						},
+						cli.StringFlag{
+							Name:  "password-file",
+							Usage: "File containing the password for the key file. Default: prompt for the password",
+						},
+						cli.BoolFlag{
+							Name:  "lightkdf",
+							Usage: "Encrypt the key file with weaker scrypt parameters, which are faster to decrypt",
+						},
+					},
+					Action: func(c *cli.Context) {
+						ImportAccount(c.String("keystore"), c.String("private-key"), c.Args().First(), c.String("password-file"), c.Bool("lightkdf"))
+					},
+				},
+				{
+					Name:  "export",
+					Usage: "Print the decrypted private key of a keystore account",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "keystore",
+							Usage: "Keystore directory",
+						},
+						cli.StringFlag{
+							Name:  "account",
+							Usage: "Address of the account, if the keystore directory has several",
+						},
+						cli.StringFlag{
+							Name:  "password-file",
+							Usage: "File containing the password for the key file. Default: prompt for the password",
+						},
+					},
+					Action: func(c *cli.Context) {
+						ExportAccount(c.String("keystore"), c.String("account"), c.String("password-file"))
+					},
+				},
+				{
+					Name:  "list",
+					Usage: "List the accounts in a keystore directory",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "keystore",
+							Usage: "Keystore directory",
+						},
+					},
+					Action: func(c *cli.Context) {
+						ListAccounts(c.String("keystore"))
 					},
 				},
+				{
+					Name:  "update-password",
+					Usage: "Change the password of a keystore account",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "keystore",
+							Usage: "Keystore directory",
+						},
+						cli.StringFlag{
+							Name:  "account",
+							Usage: "Address of the account, if the keystore directory has several",
+						},
+						cli.StringFlag{
+							Name:  "password-file",
+							Usage: "File containing the current password. Default: prompt for the password",
+						},
+						cli.StringFlag{
+							Name:  "new-password-file",
+							Usage: "File containing the new password. Default: prompt for the password",
+						},
+						cli.BoolFlag{
+							Name:  "lightkdf",
+							Usage: "Encrypt the key file with weaker scrypt parameters, which are faster to decrypt",
+						},
+					},
+					Action: func(c *cli.Context) {
+						UpdateAccountPassword(c.String("keystore"), c.String("account"), c.String("password-file"), c.String("new-password-file"), c.Bool("lightkdf"))
+					},
+				},
+			},
+		},
+		{
+			Name:      "sign",
+			Usage:     "Sign a message with the Ethereum signed message prefix, as in personal_sign",
+			ArgsUsage: "MESSAGE",
+			Flags: append([]cli.Flag{
+				cli.StringFlag{
+					Name:        "private-key, pk",
+					Usage:       "The private key",
+					EnvVar:      pkVarName,
+					Destination: &privateKey,
+				},
+				cli.StringFlag{
+					Name:  "input",
+					Usage: "Message format: utf8/hex",
+					Value: "utf8",
+				},
+			}, signerFlags...),
+			Action: func(c *cli.Context) {
+				SignMessage(ctx, getSigner(ctx, c, privateKey), c.Args().First(), c.String("input"))
+			},
+		},
+		{
+			Name:      "verify",
+			Usage:     "Recover the signer of a message signed with the Ethereum signed message prefix",
+			ArgsUsage: "MESSAGE SIGNATURE",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "input",
+					Usage: "Message format: utf8/hex",
+					Value: "utf8",
+				},
+				cli.StringFlag{
+					Name:  "address",
+					Usage: "Expected signer address. Exits with an error if the signature is not from it",
+				},
+			},
+			Action: func(c *cli.Context) {
+				VerifyMessage(c.Args().Get(0), c.Args().Get(1), c.String("input"), c.String("address"))
+			},
+		},
+		{
+			Name:      "sign-typed",
+			Usage:     "Sign EIP-712 typed structured data, as in eth_signTypedData_v4",
+			ArgsUsage: "FILE.json",
+			Flags: append([]cli.Flag{
+				cli.StringFlag{
+					Name:        "private-key, pk",
+					Usage:       "The private key",
+					EnvVar:      pkVarName,
+					Destination: &privateKey,
+				},
+			}, signerFlags...),
+			Action: func(c *cli.Context) {
+				SignTypedData(ctx, getSigner(ctx, c, privateKey), c.Args().First())
+			},
+		},
+		{
+			Name:      "verify-typed",
+			Usage:     "Recover the signer of EIP-712 typed structured data",
+			ArgsUsage: "FILE.json SIGNATURE",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "address",
+					Usage: "Expected signer address. Exits with an error if the signature is not from it",
+				},
+			},
+			Action: func(c *cli.Context) {
+				VerifyTypedData(c.Args().Get(0), c.Args().Get(1), c.String("address"))
 			},
 		},
 		{
 			Name:    "send",
 			Usage:   fmt.Sprintf("Transfer GO to an account (web3 send -to 0xb 10go/eth/nanogo/gwei/attogo/wei)"),
 			Aliases: []string{"transfer"},
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
 					Name:        "private-key,pk",
 					Usage:       "Private key",
@@ -477,16 +861,37 @@ func main() {
 					Destination: &recepientAddress,
 					Usage:       "The recepient address",
 					Hidden:      false},
-			},
+				cli.Uint64Flag{
+					Name:        "gas-limit",
+					Usage:       "Gas limit for the transaction. Default: estimated by the network",
+					Destination: &gasLimit,
+					Hidden:      false},
+				cli.Float64Flag{
+					Name:        "gas-multiplier",
+					Usage:       "Multiplier applied to the estimated gas limit. Default: 1.25",
+					Destination: &gasMultiplier,
+					Hidden:      false},
+				cli.BoolFlag{
+					Name:        "wait",
+					Usage:       "Wait for the receipt",
+					Destination: &waitForReceipt,
+					Hidden:      false},
+				cli.Uint64Flag{
+					Name:        "confirmations",
+					Usage:       "Number of blocks to wait for, including the one with the transaction",
+					Destination: &confirmations,
+					Value:       1,
+					Hidden:      false},
+			}, signerFlags...),
 			Action: func(c *cli.Context) {
-				Send(ctx, network.URL, privateKey, recepientAddress, c.Args().First())
+				Send(ctx, network, getSigner(ctx, c, privateKey), recepientAddress, c.Args().First(), waitForReceipt)
 			},
 		},
 		{
 			Name:  "env",
 			Usage: "List environment variables",
 			Action: func(c *cli.Context) {
-				varNames := []string{addrVarName, pkVarName, networkVarName, rpcURLVarName}
+				varNames := []string{addrVarName, pkVarName, networkVarName, rpcURLVarName, mnemonicVarName}
 				sort.Strings(varNames)
 				for _, name := range varNames {
 					fmt.Printf("%s=%s\n", name, os.Getenv(name))
@@ -823,6 +1228,7 @@ func GetTransactionReceipt(ctx context.Context, rpcURL, txhash, contractFile str
 	if err != nil {
 		fatalExit(fmt.Errorf("Failed to get transaction receipt: %v", err))
 	}
+	setRevertReason(ctx, client, r, contractFile)
 	if verbose {
 		fmt.Println("Transaction Receipt Details:")
 	}
@@ -878,6 +1284,99 @@ func GetAddressDetails(ctx context.Context, network web3.Network, addrHash, priv
 	}
 }
 
+// parseSlot parses a storage slot number, or derives the slot from a name by hashing it,
+// as done for the unstructured storage of the upgradeable proxy.
+func parseSlot(slot string) (common.Hash, error) {
+	if strings.HasPrefix(slot, "0x") {
+		i, ok := new(big.Int).SetString(slot[2:], 16)
+		if !ok || i.BitLen() > 256 {
+			return common.Hash{}, fmt.Errorf("invalid hexadecimal slot %q", slot)
+		}
+		return common.BigToHash(i), nil
+	}
+	if i, ok := new(big.Int).SetString(slot, 10); ok {
+		if i.Sign() < 0 || i.BitLen() > 256 {
+			return common.Hash{}, fmt.Errorf("slot out of range %q", slot)
+		}
+		return common.BigToHash(i), nil
+	}
+	return crypto.Keccak256Hash([]byte(slot)), nil
+}
+
+func GetStorage(ctx context.Context, network web3.Network, address, slotArg, blockArg string, proof bool) {
+	if address == "" || slotArg == "" {
+		fatalExit(errors.New("Missing arguments. Must be specified as: ADDRESS SLOT"))
+	}
+	if !common.IsHexAddress(address) {
+		fatalExit(fmt.Errorf("Invalid address %q", address))
+	}
+	addr := common.HexToAddress(address)
+	slot, err := parseSlot(slotArg)
+	if err != nil {
+		fatalExit(err)
+	}
+	var blockN *big.Int
+	if blockArg != "" {
+		blockN, err = web3.ParseBigInt(blockArg)
+		if err != nil {
+			fatalExit(fmt.Errorf("Block must be a number (decimal integer) %q: %v", blockArg, err))
+		}
+	}
+	client, err := web3.Dial(network.URL)
+	if err != nil {
+		fatalExit(fmt.Errorf("Failed to connect to %q: %v", network.URL, err))
+	}
+	defer client.Close()
+
+	var value common.Hash
+	var block *web3.Block
+	var p *web3.AccountProof
+	if proof {
+		// Pin the block, so that the proof is for the same state root.
+		block, err = client.GetBlockByNumber(ctx, blockN, false)
+		if err != nil {
+			fatalExit(fmt.Errorf("Cannot get block details from the network: %v", err))
+		}
+		p, err = client.GetProof(ctx, addr, []common.Hash{slot}, block.Number)
+		if err != nil {
+			fatalExit(fmt.Errorf("Cannot get storage proof from the network: %v", err))
+		}
+		if err := web3.VerifyProof(block.StateRoot, p); err != nil {
+			fatalExit(fmt.Errorf("Proof verification failed: %v", err))
+		}
+		if len(p.StorageProof) != 1 || p.StorageProof[0].Key != slot {
+			fatalExit(errors.New("Proof verification failed: missing storage proof for slot"))
+		}
+		value = common.BigToHash(p.StorageProof[0].Value)
+	} else {
+		value, err = client.GetStorageAt(ctx, addr, slot, blockN)
+		if err != nil {
+			fatalExit(fmt.Errorf("Cannot get storage from the network: %v", err))
+		}
+	}
+
+	switch format {
+	case "json":
+		data := struct {
+			Slot  common.Hash        `json:"slot"`
+			Value common.Hash        `json:"value"`
+			Block *big.Int           `json:"block,omitempty"`
+			Proof *web3.AccountProof `json:"proof,omitempty"`
+		}{Slot: slot, Value: value, Proof: p}
+		if block != nil {
+			data.Block = block.Number
+		}
+		fmt.Println(marshalJSON(&data))
+		return
+	}
+
+	fmt.Println("Slot:", slot.Hex())
+	fmt.Println("Value:", value.Hex())
+	if block != nil {
+		fmt.Printf("Verified against state root %s of block %s\n", block.StateRoot.Hex(), block.Number)
+	}
+}
+
 func GetSnapshot(ctx context.Context, rpcURL string) {
 	client, err := web3.Dial(rpcURL)
 	if err != nil {
@@ -1019,15 +1518,19 @@ func BuildSol(ctx context.Context, filename, compiler string) {
 	}
 }
 
-func DeploySol(ctx context.Context, rpcURL, privateKey, contractName string, upgradeable bool, params ...interface{}) {
+func DeploySol(ctx context.Context, network web3.Network, signer web3.Signer, contractName string, upgradeable bool, params ...interface{}) {
+	if signer == nil {
+		fatalExit(errNoSigner)
+	}
 	if contractName == "" {
 		fatalExit(errors.New("Missing contract name arg."))
 	}
-	client, err := web3.Dial(rpcURL)
+	client, err := web3.Dial(network.URL)
 	if err != nil {
-		fatalExit(fmt.Errorf("Failed to connect to %q: %v", rpcURL, err))
+		fatalExit(fmt.Errorf("Failed to connect to %q: %v", network.URL, err))
 	}
 	defer client.Close()
+	opts := getTxOpts(ctx, client, network)
 	bin, err := ioutil.ReadFile(contractName)
 	if err != nil {
 		fatalExit(fmt.Errorf("Cannot read the bin file %q: %v", contractName, err))
@@ -1041,12 +1544,11 @@ func DeploySol(ctx context.Context, rpcURL, privateKey, contractName string, upg
 		}
 		abi = string(b)
 	}
-	tx, err := web3.DeployContract(ctx, client, privateKey, string(bin), abi, params...)
+	tx, err := web3.DeployContract(ctx, client, signer, opts, string(bin), abi, params...)
 	if err != nil {
 		fatalExit(fmt.Errorf("Cannot deploy the contract: %v", err))
 	}
-	waitCtx, _ := context.WithTimeout(ctx, 60*time.Second)
-	receipt, err := web3.WaitForReceipt(waitCtx, client, tx.Hash)
+	receipt, err := waitForTx(ctx, client, tx.Hash, 60*time.Second)
 	if err != nil {
 		fatalExit(fmt.Errorf("Cannot get the receipt: %v", err))
 	}
@@ -1065,12 +1567,11 @@ func DeploySol(ctx context.Context, rpcURL, privateKey, contractName string, upg
 	}
 
 	// Deploy proxy contract.
-	proxyTx, err := web3.DeployContract(ctx, client, privateKey, string(assets.OwnerUpgradeableProxyCode(receipt.ContractAddress)), "")
+	proxyTx, err := web3.DeployContract(ctx, client, signer, opts, string(assets.OwnerUpgradeableProxyCode(receipt.ContractAddress)), "")
 	if err != nil {
 		log.Fatalf("Cannot deploy the upgradeable proxy contract: %v", err)
 	}
-	waitCtx, _ = context.WithTimeout(ctx, 60*time.Second)
-	proxyReceipt, err := web3.WaitForReceipt(waitCtx, client, proxyTx.Hash)
+	proxyReceipt, err := waitForTx(ctx, client, proxyTx.Hash, 60*time.Second)
 	if err != nil {
 		log.Fatalf("Cannot get the upgradeable proxy receipt: %v", err)
 	}
@@ -1091,15 +1592,15 @@ func ListContract(contractFile string) {
 	}
 
 	for _, method := range myabi.Methods {
-		fmt.Println(method)
+		fmt.Println(web3.MethodString(method))
 	}
 
 }
 
-func CallContract(ctx context.Context, rpcURL, privateKey, contractAddress, contractFile, functionName string, amount int, waitForReceipt bool, parameters ...interface{}) {
-	client, err := web3.Dial(rpcURL)
+func CallContract(ctx context.Context, network web3.Network, signer web3.Signer, contractAddress, contractFile, functionName string, amount int, waitForReceipt bool, parameters ...interface{}) {
+	client, err := web3.Dial(network.URL)
 	if err != nil {
-		fatalExit(fmt.Errorf("Failed to connect to %q: %v", rpcURL, err))
+		fatalExit(fmt.Errorf("Failed to connect to %q: %v", network.URL, err))
 	}
 	defer client.Close()
 	myabi := getAbi(contractFile)
@@ -1109,20 +1610,22 @@ func CallContract(ctx context.Context, rpcURL, privateKey, contractAddress, cont
 	}
 	if myabi.Methods[functionName].Const {
 		res, err := web3.CallConstantFunction(ctx, client, *myabi, contractAddress, functionName, parameters...)
+		if rerr, ok := err.(*web3.RevertError); ok {
+			if reason, ok := web3.DecodeRevert(rerr.Data, getAbiErrors(contractFile)); ok {
+				rerr.Reason = reason
+			}
+		}
 		if err != nil {
 			fatalExit(fmt.Errorf("Cannot call the contract: %v", err))
 		}
-		switch format {
-		case "json":
-			m := make(map[string]interface{})
-			m["response"] = res
-			fmt.Println(marshalJSON(m))
-			return
-		}
-		fmt.Println(res)
+		printCallResult(myabi.Methods[functionName].Outputs, res)
 		return
 	}
-	tx, err := web3.CallTransactFunction(ctx, client, *myabi, contractAddress, privateKey, functionName, amount, parameters...)
+	if signer == nil {
+		fatalExit(errNoSigner)
+	}
+	opts := getTxOpts(ctx, client, network)
+	tx, err := web3.CallTransactFunction(ctx, client, *myabi, contractAddress, signer, opts, functionName, amount, parameters...)
 	if err != nil {
 		fatalExit(fmt.Errorf("Cannot call the contract: %v", err))
 	}
@@ -1130,21 +1633,67 @@ func CallContract(ctx context.Context, rpcURL, privateKey, contractAddress, cont
 		fmt.Println("Transaction address:", tx.Hash.Hex())
 		return
 	}
-	ctx, _ = context.WithTimeout(ctx, 10*time.Second)
-	receipt, err := web3.WaitForReceipt(ctx, client, tx.Hash)
+	receipt, err := waitForTx(ctx, client, tx.Hash, 10*time.Second)
 	if err != nil {
 		fatalExit(fmt.Errorf("Cannot get the receipt: %v", err))
 	}
+	setRevertReason(ctx, client, receipt, contractFile)
 	printReceiptDetails(receipt, myabi)
 
 }
 
-func Send(ctx context.Context, rpcURL, privateKey, toAddress, amount string) {
-	client, err := web3.Dial(rpcURL)
+// printCallResult prints the decoded return values of a constant call to outputs.
+func printCallResult(outputs abi.Arguments, res interface{}) {
+	var response interface{}
+	fields := make(map[string]interface{})
+	switch len(outputs) {
+	case 0:
+	case 1:
+		response = web3.FormatValue(outputs[0].Type, res)
+	default:
+		values := res.([]interface{})
+		formatted := make([]interface{}, len(values))
+		for i, v := range values {
+			formatted[i] = web3.FormatValue(outputs[i].Type, v)
+			if name := outputs[i].Name; name != "" {
+				fields[name] = formatted[i]
+			}
+		}
+		response = formatted
+	}
+	switch format {
+	case "json":
+		m := make(map[string]interface{})
+		m["response"] = response
+		if len(fields) > 0 {
+			m["fields"] = fields
+		}
+		fmt.Println(marshalJSON(m))
+		return
+	}
+	if values, ok := response.([]interface{}); ok && len(outputs) > 1 {
+		for i, v := range values {
+			name := outputs[i].Name
+			if name == "" {
+				name = strconv.Itoa(i)
+			}
+			fmt.Printf("%s: %v\n", name, v)
+		}
+		return
+	}
+	fmt.Println(response)
+}
+
+func Send(ctx context.Context, network web3.Network, signer web3.Signer, toAddress, amount string, waitForReceipt bool) {
+	if signer == nil {
+		fatalExit(errNoSigner)
+	}
+	client, err := web3.Dial(network.URL)
 	if err != nil {
-		fatalExit(fmt.Errorf("Failed to connect to %q: %v", rpcURL, err))
+		fatalExit(fmt.Errorf("Failed to connect to %q: %v", network.URL, err))
 	}
 	defer client.Close()
+	opts := getTxOpts(ctx, client, network)
 	nAmount, err := web3.ParseAmount(amount)
 	if err != nil {
 		fatalExit(fmt.Errorf("Cannot parse amount: %v", err))
@@ -1153,11 +1702,101 @@ func Send(ctx context.Context, rpcURL, privateKey, toAddress, amount string) {
 		fatalExit(errors.New("The recepient address cannot be empty"))
 	}
 	address := common.HexToAddress(toAddress)
-	tx, err := web3.Send(ctx, client, privateKey, address, nAmount)
+	tx, err := web3.Send(ctx, client, signer, opts, address, nAmount)
 	if err != nil {
 		fatalExit(fmt.Errorf("Cannot create transaction: %v", err))
 	}
-	fmt.Println("Transaction address:", tx.Hash.Hex())
+	if !waitForReceipt {
+		fmt.Println("Transaction address:", tx.Hash.Hex())
+		return
+	}
+	receipt, err := waitForTx(ctx, client, tx.Hash, 60*time.Second)
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot get the receipt: %v", err))
+	}
+	printReceiptDetails(receipt, nil)
+}
+
+// waitForTx waits for the receipt of hash with the number of --confirmations. The
+// timeout only applies while waiting for the transaction to be mined.
+func waitForTx(ctx context.Context, client web3.Client, hash common.Hash, timeout time.Duration) (*web3.Receipt, error) {
+	mineCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	timer := time.AfterFunc(timeout, cancel)
+	defer timer.Stop()
+	return web3.WaitForConfirmations(mineCtx, client, hash, confirmations, func(r *web3.Receipt, n uint64) {
+		if r == nil {
+			return
+		}
+		timer.Stop()
+		if confirmations > 1 {
+			fmt.Fprintf(os.Stderr, "Block #%d: %d/%d confirmations\n", r.BlockNumber, n, confirmations)
+		}
+	})
+}
+
+// ReplaceTransaction replaces the pending transaction txhash, either with a sped up copy
+// or with a cancellation, and waits up to timeout for either to be mined.
+func ReplaceTransaction(ctx context.Context, network web3.Network, signer web3.Signer, txhash, gasPrice string, timeout time.Duration, cancel bool) {
+	if signer == nil {
+		fatalExit(errNoSigner)
+	}
+	if txhash == "" {
+		fatalExit(errors.New("Missing transaction hash"))
+	}
+	client, err := web3.Dial(network.URL)
+	if err != nil {
+		fatalExit(fmt.Errorf("Failed to connect to %q: %v", network.URL, err))
+	}
+	defer client.Close()
+	var price *big.Int
+	if gasPrice != "" {
+		price, err = parseGwei(gasPrice)
+		if err != nil {
+			fatalExit(fmt.Errorf("Cannot parse gas price: %v", err))
+		}
+	}
+	opts := getTxOpts(ctx, client, network)
+	hash := common.HexToHash(txhash)
+	var tx *web3.Transaction
+	if cancel {
+		tx, err = web3.CancelTransaction(ctx, client, signer, opts, hash, price)
+	} else {
+		tx, err = web3.SpeedUpTransaction(ctx, client, signer, opts, hash, price)
+	}
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot replace transaction: %v", err))
+	}
+	if format != "json" {
+		fmt.Println("Replacement transaction address:", tx.Hash.Hex())
+		fmt.Println("Gas Price:", web3.WeiAsGwei(tx.GasPrice), "gwei")
+		fmt.Println("Waiting for the transaction to be mined...")
+	}
+	waitCtx, cancelWait := context.WithTimeout(ctx, timeout)
+	defer cancelWait()
+	receipt, err := web3.WaitForAnyReceipt(waitCtx, client, tx.Hash, hash)
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot get the receipt: %v", err))
+	}
+	if receipt.TxHash == hash && format != "json" {
+		fmt.Println("The original transaction was mined instead of the replacement.")
+	}
+	printReceiptDetails(receipt, nil)
+}
+
+// parseGwei parses a decimal amount of gwei, like 1.5, optionally followed by gwei, and
+// returns it in wei.
+func parseGwei(s string) (*big.Int, error) {
+	s = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(s)), "gwei")
+	r, ok := new(big.Rat).SetString(s)
+	if !ok || r.Sign() < 0 {
+		return nil, fmt.Errorf("invalid amount %q: must be a decimal number of gwei", s)
+	}
+	r.Mul(r, big.NewRat(1e9, 1))
+	if !r.IsInt() {
+		return nil, fmt.Errorf("invalid amount %q: more than 9 decimal places", s)
+	}
+	return r.Num(), nil
 }
 
 func printReceiptDetails(r *web3.Receipt, myabi *abi.ABI) {
@@ -1165,10 +1804,18 @@ func printReceiptDetails(r *web3.Receipt, myabi *abi.ABI) {
 	var err error
 	if myabi != nil {
 		logs, err = web3.ParseLogs(*myabi, r.Logs)
-		r.ParsedLogs = logs
 		if err != nil {
-			fatalExit(fmt.Errorf("Cannot parse the receipt logs: %v", err))
+			// The logs which could be decoded are still printed.
+			fmt.Fprintf(os.Stderr, "WARNING: Cannot parse all receipt logs: %v\n", err)
 		}
+		for _, ev := range logs {
+			for _, input := range myabi.Events[ev.Name].Inputs {
+				if v, ok := ev.Fields[input.Name]; ok {
+					ev.Fields[input.Name] = web3.FormatValue(input.Type, v)
+				}
+			}
+		}
+		r.ParsedLogs = logs
 	}
 	switch format {
 	case "json":
@@ -1199,6 +1846,9 @@ func printReceiptDetails(r *web3.Receipt, myabi *abi.ABI) {
 		status = fmt.Sprintf("%d (unrecognized status)", r.Status)
 	}
 	fmt.Println("Status:", status)
+	if r.RevertReason != "" {
+		fmt.Println("Revert Reason:", r.RevertReason)
+	}
 	fmt.Println("Post State:", "0x"+common.Bytes2Hex(r.PostState))
 	fmt.Println("Bloom:", "0x"+common.Bytes2Hex(r.Bloom.Bytes()))
 	fmt.Println("Logs:", r.Logs)
@@ -1207,17 +1857,21 @@ func printReceiptDetails(r *web3.Receipt, myabi *abi.ABI) {
 	}
 }
 
-func UpgradeContract(ctx context.Context, rpcURL, privateKey, contractAddress, newTargetAddress string, amount int) {
-	client, err := web3.Dial(rpcURL)
+func UpgradeContract(ctx context.Context, network web3.Network, signer web3.Signer, contractAddress, newTargetAddress string, amount int) {
+	if signer == nil {
+		fatalExit(errNoSigner)
+	}
+	client, err := web3.Dial(network.URL)
 	if err != nil {
-		log.Fatalf("Failed to connect to %q: %v", rpcURL, err)
+		log.Fatalf("Failed to connect to %q: %v", network.URL, err)
 	}
 	defer client.Close()
+	opts := getTxOpts(ctx, client, network)
 	myabi, err := abi.JSON(strings.NewReader(assets.UpgradeableProxyABI))
 	if err != nil {
 		log.Fatalf("Cannot initialize ABI: %v", err)
 	}
-	tx, err := web3.CallTransactFunction(ctx, client, myabi, contractAddress, privateKey, "upgrade", amount, newTargetAddress)
+	tx, err := web3.CallTransactFunction(ctx, client, myabi, contractAddress, signer, opts, "upgrade", amount, newTargetAddress)
 	if err != nil {
 		log.Fatalf("Cannot upgrade the contract: %v", err)
 	}
@@ -1251,17 +1905,21 @@ func GetTargetContract(ctx context.Context, rpcURL, contractAddress string) {
 	}
 }
 
-func PauseContract(ctx context.Context, rpcURL, privateKey, contractAddress string, amount int) {
-	client, err := web3.Dial(rpcURL)
+func PauseContract(ctx context.Context, network web3.Network, signer web3.Signer, contractAddress string, amount int) {
+	if signer == nil {
+		fatalExit(errNoSigner)
+	}
+	client, err := web3.Dial(network.URL)
 	if err != nil {
-		log.Fatalf("Failed to connect to %q: %v", rpcURL, err)
+		log.Fatalf("Failed to connect to %q: %v", network.URL, err)
 	}
 	defer client.Close()
+	opts := getTxOpts(ctx, client, network)
 	myabi, err := abi.JSON(strings.NewReader(assets.UpgradeableProxyABI))
 	if err != nil {
 		log.Fatalf("Cannot initialize ABI: %v", err)
 	}
-	tx, err := web3.CallTransactFunction(ctx, client, myabi, contractAddress, privateKey, "pause", amount)
+	tx, err := web3.CallTransactFunction(ctx, client, myabi, contractAddress, signer, opts, "pause", amount)
 	if err != nil {
 		log.Fatalf("Cannot pause the contract: %v", err)
 	}
@@ -1273,17 +1931,21 @@ func PauseContract(ctx context.Context, rpcURL, privateKey, contractAddress stri
 	fmt.Println("Transaction address:", receipt.TxHash.Hex())
 }
 
-func ResumeContract(ctx context.Context, rpcURL, privateKey, contractAddress string, amount int) {
-	client, err := web3.Dial(rpcURL)
+func ResumeContract(ctx context.Context, network web3.Network, signer web3.Signer, contractAddress string, amount int) {
+	if signer == nil {
+		fatalExit(errNoSigner)
+	}
+	client, err := web3.Dial(network.URL)
 	if err != nil {
-		log.Fatalf("Failed to connect to %q: %v", rpcURL, err)
+		log.Fatalf("Failed to connect to %q: %v", network.URL, err)
 	}
 	defer client.Close()
+	opts := getTxOpts(ctx, client, network)
 	myabi, err := abi.JSON(strings.NewReader(assets.UpgradeableProxyABI))
 	if err != nil {
 		log.Fatalf("Cannot initialize ABI: %v", err)
 	}
-	tx, err := web3.CallTransactFunction(ctx, client, myabi, contractAddress, privateKey, "resume", amount)
+	tx, err := web3.CallTransactFunction(ctx, client, myabi, contractAddress, signer, opts, "resume", amount)
 	if err != nil {
 		log.Fatalf("Cannot resume the contract: %v", err)
 	}
@@ -1295,6 +1957,28 @@ func ResumeContract(ctx context.Context, rpcURL, privateKey, contractAddress str
 	fmt.Println("Transaction address:", receipt.TxHash.Hex())
 }
 
+// getTxOpts returns the transaction options for network. The chain id, either set explicitly or
+// configured for the network, is verified against the node before signing.
+func getTxOpts(ctx context.Context, client web3.Client, network web3.Network) *web3.TxOpts {
+	id := network.ChainID
+	if chainID != "" {
+		var err error
+		id, err = web3.ParseBigInt(chainID)
+		if err != nil {
+			fatalExit(fmt.Errorf("Invalid chain id %q: %v", chainID, err))
+		}
+	}
+	if id != nil {
+		if err := web3.CheckChainID(ctx, client, id); err != nil {
+			fatalExit(fmt.Errorf("Refusing to sign: %v", err))
+		}
+	}
+	if gasMultiplier < 0 {
+		fatalExit(fmt.Errorf("Invalid gas multiplier %v: must not be negative", gasMultiplier))
+	}
+	return &web3.TxOpts{ChainID: id, GasLimit: gasLimit, GasMultiplier: gasMultiplier}
+}
+
 func marshalJSON(data interface{}) string {
 	b, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
@@ -1316,6 +2000,36 @@ func getAbi(contractFile string) *abi.ABI {
 	}
 	return abi
 }
+
+// getAbiErrors returns the custom errors declared in the ABI file contractFile, if any.
+func getAbiErrors(contractFile string) map[string]abi.Method {
+	f, err := os.Open(contractFile)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	errs, err := web3.ParseABIErrors(f)
+	if err != nil {
+		return nil
+	}
+	return errs
+}
+
+// setRevertReason sets the revert reason of r if the transaction failed, by replaying it.
+func setRevertReason(ctx context.Context, client web3.Client, r *web3.Receipt, contractFile string) {
+	if r.Status != types.ReceiptStatusFailed || r.RevertReason != "" {
+		return
+	}
+	reason, err := web3.RevertReason(ctx, client, r, getAbiErrors(contractFile))
+	if err != nil {
+		if verbose {
+			log.Printf("Cannot get the revert reason: %v", err)
+		}
+		return
+	}
+	r.RevertReason = reason
+}
+
 func fatalExit(err error) {
 	fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
 	os.Exit(1)
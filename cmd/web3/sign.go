@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/common/hexutil"
+	"github.com/gochain-io/web3"
+)
+
+// SignMessage prints the signature of message with the standard Ethereum signed message
+// prefix, as in personal_sign.
+func SignMessage(ctx context.Context, signer web3.Signer, message, inputFormat string) {
+	if signer == nil {
+		fatalExit(errNoSigner)
+	}
+	msg := parseMessage(message, inputFormat)
+	sig, err := signer.SignMessage(ctx, msg)
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot sign message: %v", err))
+	}
+
+	switch format {
+	case "json":
+		fmt.Println(marshalJSON(map[string]string{
+			"address":   signer.Address().Hex(),
+			"message":   hexutil.Encode(msg),
+			"signature": hexutil.Encode(sig),
+		}))
+		return
+	}
+
+	fmt.Println("Address:", signer.Address().Hex())
+	fmt.Println("Signature:", hexutil.Encode(sig))
+}
+
+// VerifyMessage prints the address which signed message. If address is set, it fails
+// unless the signer matches.
+func VerifyMessage(message, signature, inputFormat, address string) {
+	if signature == "" {
+		fatalExit(errors.New("Missing signature"))
+	}
+	sig, err := hexutil.Decode(signature)
+	if err != nil {
+		fatalExit(fmt.Errorf("Invalid signature hex: %v", err))
+	}
+	if address != "" && !common.IsHexAddress(address) {
+		fatalExit(fmt.Errorf("Invalid address: %q", address))
+	}
+	signer, err := web3.RecoverAddress(parseMessage(message, inputFormat), sig)
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot recover signer: %v", err))
+	}
+	printSigner(signer, address)
+}
+
+// SignTypedData prints the EIP-712 signature of the typed data document in file.
+func SignTypedData(ctx context.Context, signer web3.Signer, file string) {
+	if signer == nil {
+		fatalExit(errNoSigner)
+	}
+	td := readTypedData(file)
+	hash, err := td.Hash()
+	if err != nil {
+		fatalExit(fmt.Errorf("Invalid typed data: %v", err))
+	}
+	sig, err := signer.SignTypedData(ctx, td)
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot sign typed data: %v", err))
+	}
+
+	switch format {
+	case "json":
+		fmt.Println(marshalJSON(map[string]string{
+			"address":   signer.Address().Hex(),
+			"hash":      hexutil.Encode(hash),
+			"signature": hexutil.Encode(sig),
+		}))
+		return
+	}
+
+	fmt.Println("Address:", signer.Address().Hex())
+	fmt.Println("Hash:", hexutil.Encode(hash))
+	fmt.Println("Signature:", hexutil.Encode(sig))
+}
+
+// VerifyTypedData prints the address which signed the typed data document in file. If
+// address is set, it fails unless the signer matches.
+func VerifyTypedData(file, signature, address string) {
+	if signature == "" {
+		fatalExit(errors.New("Missing signature"))
+	}
+	sig, err := hexutil.Decode(signature)
+	if err != nil {
+		fatalExit(fmt.Errorf("Invalid signature hex: %v", err))
+	}
+	if address != "" && !common.IsHexAddress(address) {
+		fatalExit(fmt.Errorf("Invalid address: %q", address))
+	}
+	signer, err := web3.RecoverTypedDataAddress(readTypedData(file), sig)
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot recover signer: %v", err))
+	}
+	printSigner(signer, address)
+}
+
+func readTypedData(file string) *web3.TypedData {
+	if file == "" {
+		fatalExit(errors.New("Missing typed data file"))
+	}
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot read typed data file %q: %v", file, err))
+	}
+	td, err := web3.ParseTypedData(b)
+	if err != nil {
+		fatalExit(err)
+	}
+	return td
+}
+
+// printSigner prints the recovered signer, and fails if it is not the expected address.
+func printSigner(signer common.Address, address string) {
+	valid := address == "" || signer == common.HexToAddress(address)
+
+	switch format {
+	case "json":
+		fmt.Println(marshalJSON(map[string]interface{}{
+			"address": signer.Hex(),
+			"valid":   valid,
+		}))
+	default:
+		fmt.Println("Signer:", signer.Hex())
+	}
+	if !valid {
+		fmt.Fprintf(os.Stderr, "ERROR: Signature is not from %s\n", common.HexToAddress(address).Hex())
+		os.Exit(1)
+	}
+}
+
+// parseMessage returns the bytes of message in inputFormat: utf8 or hex.
+func parseMessage(message, inputFormat string) []byte {
+	switch inputFormat {
+	case "utf8":
+		return []byte(message)
+	case "hex":
+		b, err := hexutil.Decode(message)
+		if err != nil {
+			fatalExit(fmt.Errorf("Invalid message hex: %v", err))
+		}
+		return b
+	}
+	fatalExit(fmt.Errorf(`Unrecognized input format %q: expected "utf8" or "hex"`, inputFormat))
+	return nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/web3"
+	"github.com/urfave/cli"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// signerFlags select a signer other than a raw private key. They are accepted by
+// every command which signs transactions.
+var signerFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "keystore",
+		Usage: "Encrypted keystore file or directory to sign with, instead of the private key",
+	},
+	cli.StringFlag{
+		Name:  "account",
+		Usage: "Address of the account to sign with, if the keystore directory has several",
+	},
+	cli.StringFlag{
+		Name:  "password-file",
+		Usage: "File containing the keystore password. Default: prompt for the password",
+	},
+	cli.StringFlag{
+		Name:  "signer-url",
+		Usage: "URL of a remote signer (e.g. clef) to sign with, instead of the private key",
+	},
+	cli.StringFlag{
+		Name:  "from",
+		Usage: "Address of the remote signer account to sign with",
+	},
+}
+
+var errNoSigner = fmt.Errorf("Missing signer: set %s, or use --private-key, --keystore or --signer-url", pkVarName)
+
+// getSigner returns the signer selected by the flags of c, or nil if none was selected.
+// The keystore and remote signer flags take precedence over the private key.
+func getSigner(ctx context.Context, c *cli.Context, privateKey string) web3.Signer {
+	keystore, signerURL := c.String("keystore"), c.String("signer-url")
+	switch {
+	case keystore != "" && signerURL != "":
+		fatalExit(errors.New("Cannot set both --keystore and --signer-url"))
+	case keystore != "":
+		return getKeystoreSigner(keystore, c.String("account"), c.String("password-file"))
+	case signerURL != "":
+		from := c.String("from")
+		if !common.IsHexAddress(from) {
+			fatalExit(fmt.Errorf("Invalid or missing --from address for remote signer: %q", from))
+		}
+		s, err := web3.DialRemoteSigner(ctx, signerURL, common.HexToAddress(from))
+		if err != nil {
+			fatalExit(fmt.Errorf("Failed to connect to remote signer %q: %v", signerURL, err))
+		}
+		return s
+	case privateKey != "":
+		acct, err := web3.ParsePrivateKey(privateKey)
+		if err != nil {
+			fatalExit(fmt.Errorf("Invalid private key: %v", err))
+		}
+		return web3.NewAccountSigner(acct)
+	}
+	return nil
+}
+
+// getKeystoreSigner returns a signer for the keystore file at path, or for the account
+// in the keystore directory at path.
+func getKeystoreSigner(path, account, pwFile string) web3.Signer {
+	info, err := os.Stat(path)
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot open keystore: %v", err))
+	}
+	if !info.IsDir() {
+		s, err := web3.OpenKeystoreSigner(path, readPassword(pwFile, "Password: "))
+		if err != nil {
+			fatalExit(err)
+		}
+		return s
+	}
+	ks := web3.OpenKeystore(path, false)
+	s, err := ks.Signer(keystoreAccount(ks, account), readPassword(pwFile, "Password: "))
+	if err != nil {
+		fatalExit(err)
+	}
+	return s
+}
+
+// keystoreAccount returns the address of account, or of the only account in ks when
+// account is empty.
+func keystoreAccount(ks *web3.Keystore, account string) common.Address {
+	if account != "" {
+		if !common.IsHexAddress(account) {
+			fatalExit(fmt.Errorf("Invalid --account address: %q", account))
+		}
+		return common.HexToAddress(account)
+	}
+	accts := ks.Accounts()
+	switch len(accts) {
+	case 0:
+		fatalExit(errors.New("No accounts in keystore"))
+	case 1:
+		return accts[0].Address
+	}
+	fatalExit(fmt.Errorf("Missing --account: keystore has %d accounts", len(accts)))
+	return common.Address{}
+}
+
+// readPassword returns the password in pwFile, or prompts for it on the terminal when
+// pwFile is empty.
+func readPassword(pwFile, prompt string) string {
+	if pwFile != "" {
+		b, err := ioutil.ReadFile(pwFile)
+		if err != nil {
+			fatalExit(fmt.Errorf("Cannot read password file %q: %v", pwFile, err))
+		}
+		return strings.TrimRight(string(b), "\r\n")
+	}
+	return readSecret(prompt, errors.New("Cannot prompt for the password: set --password-file"))
+}
+
+// readSecret prompts for a secret on the terminal, without echoing it. It fails with
+// errNoTerminal if stdin is not a terminal.
+func readSecret(prompt string, errNoTerminal error) string {
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		fatalExit(errNoTerminal)
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	b, err := terminal.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot read from the terminal: %v", err))
+	}
+	return string(b)
+}
+
+// readNewPassword is like readPassword, but asks for the password twice when prompting.
+func readNewPassword(pwFile, prompt string) string {
+	pw := readPassword(pwFile, prompt)
+	if pwFile == "" && readPassword("", "Repeat password: ") != pw {
+		fatalExit(errors.New("Passwords do not match"))
+	}
+	return pw
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gochain-io/gochain/v3/accounts/abi"
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/common/hexutil"
+	"github.com/gochain-io/web3"
+)
+
+// TraceTransaction prints the call tree of a mined transaction, or its opcodes with the
+// struct tracer. Calls to methods of the contractFiles ABIs are decoded.
+func TraceTransaction(ctx context.Context, network web3.Network, txhash, tracer string, contractFiles []string) {
+	if txhash == "" {
+		fatalExit(fmt.Errorf("Missing transaction hash"))
+	}
+	cfg := &web3.TraceConfig{}
+	switch tracer {
+	case "call":
+		cfg.Tracer = web3.CallTracer
+	case "struct":
+		cfg.Tracer = web3.StructLogger
+		cfg.DisableMemory = true
+	default:
+		fatalExit(fmt.Errorf(`Unrecognized tracer %q: must be "call" or "struct"`, tracer))
+	}
+	var abis []*abi.ABI
+	errs := map[string]abi.Method{}
+	for _, file := range contractFiles {
+		abis = append(abis, getAbi(file))
+		for name, m := range getAbiErrors(file) {
+			errs[name] = m
+		}
+	}
+	client, err := web3.Dial(network.URL)
+	if err != nil {
+		fatalExit(fmt.Errorf("Failed to connect to %q: %v", network.URL, err))
+	}
+	defer client.Close()
+	trace, err := client.TraceTransaction(ctx, common.HexToHash(txhash), cfg)
+	if err != nil {
+		fatalExit(fmt.Errorf("Cannot trace the transaction: %v", err))
+	}
+
+	switch format {
+	case "json":
+		fmt.Println(marshalJSON(trace))
+		return
+	}
+
+	if trace.Execution != nil {
+		printExecutionTrace(trace.Execution)
+		return
+	}
+	if trace.Call == nil {
+		fatalExit(fmt.Errorf("The node returned no call trace for the transaction"))
+	}
+	printCallFrame(trace.Call, "", "", abis, errs)
+}
+
+func printExecutionTrace(t *web3.ExecutionTrace) {
+	fmt.Printf("%-6s %-16s %-10s %-8s %s\n", "PC", "OP", "GAS", "COST", "DEPTH")
+	for _, l := range t.StructLogs {
+		fmt.Printf("%-6d %-16s %-10d %-8d %d", l.Pc, l.Op, l.Gas, l.GasCost, l.Depth)
+		if l.Error != "" {
+			fmt.Print(" ERROR: ", l.Error)
+		}
+		fmt.Println()
+	}
+	fmt.Println("Gas Used:", t.Gas)
+	fmt.Println("Failed:", t.Failed)
+	if len(t.ReturnValue) > 0 {
+		fmt.Println("Return Value:", hexutil.Encode(t.ReturnValue))
+	}
+}
+
+// printCallFrame prints f as a line of a tree, followed by its calls. The prefixes are
+// for the first line, and for the lines of the calls.
+func printCallFrame(f *web3.CallFrame, prefix, childPrefix string, abis []*abi.ABI, errs map[string]abi.Method) {
+	line := []string{prefix + f.Type, f.To.Hex()}
+	if f.Type != "CREATE" && f.Type != "CREATE2" && f.Type != "SELFDESTRUCT" {
+		line = append(line, formatCallInput(f.Input, abis))
+	}
+	if f.Value != nil && f.Value.Sign() > 0 {
+		line = append(line, "value: "+f.Value.String())
+	}
+	if f.GasUsed > 0 {
+		line = append(line, fmt.Sprintf("gas: %d", f.GasUsed))
+	}
+	if f.Error != "" {
+		msg := f.Error
+		if reason, ok := web3.DecodeRevert(f.Output, errs); ok {
+			msg += ": " + reason
+		}
+		line = append(line, "ERROR: "+msg)
+	}
+	fmt.Println(strings.Join(line, " "))
+	for i, c := range f.Calls {
+		if i == len(f.Calls)-1 {
+			printCallFrame(c, childPrefix+"└─ ", childPrefix+"   ", abis, errs)
+		} else {
+			printCallFrame(c, childPrefix+"├─ ", childPrefix+"│  ", abis, errs)
+		}
+	}
+}
+
+// formatCallInput returns the decoded method call for input, or its selector if no ABI
+// declares the method.
+func formatCallInput(input []byte, abis []*abi.ABI) string {
+	if len(input) == 0 {
+		return "()"
+	}
+	for _, myabi := range abis {
+		method, values, err := web3.ParseInput(*myabi, input)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "WARNING:", err)
+			continue
+		}
+		if method == nil {
+			continue
+		}
+		args := make([]string, len(values))
+		for i, v := range values {
+			args[i] = fmt.Sprint(web3.FormatValue(method.Inputs[i].Type, v))
+			if name := method.Inputs[i].Name; name != "" {
+				args[i] = name + ": " + args[i]
+			}
+		}
+		return method.Name + "(" + strings.Join(args, ", ") + ")"
+	}
+	if len(input) < 4 {
+		return hexutil.Encode(input)
+	}
+	return hexutil.Encode(input[:4]) + "(...)"
+}
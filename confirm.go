@@ -0,0 +1,91 @@
+package web3
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/gochain-io/gochain/v3/common"
+)
+
+const (
+	minConfirmationPoll = 500 * time.Millisecond
+	maxConfirmationPoll = 16 * time.Second
+)
+
+// ConfirmationProgress is called by WaitForConfirmations each time the number of
+// confirmations of the transaction changes. receipt is nil while the transaction is
+// pending, including after being dropped from the canonical chain by a reorg.
+type ConfirmationProgress func(receipt *Receipt, confirmations uint64)
+
+// WaitForConfirmations waits until the transaction hash is included in the canonical
+// chain with at least confirmations blocks, counting the block which includes it, or ctx
+// is cancelled. Before returning, the receipt's block is re-checked against the canonical
+// chain, in case of a reorg. The node is polled with exponential backoff, which is reset
+// whenever a new block is seen. progress may be nil.
+func WaitForConfirmations(ctx context.Context, client Client, hash common.Hash, confirmations uint64, progress ConfirmationProgress) (*Receipt, error) {
+	if confirmations == 0 {
+		confirmations = 1
+	}
+	var lastHead *big.Int
+	lastConfs := ^uint64(0)
+	delay := minConfirmationPoll
+	for {
+		head, err := client.GetBlockByNumber(ctx, nil, false)
+		if err != nil {
+			return nil, fmt.Errorf("cannot get latest block: %v", err)
+		}
+		if lastHead == nil || head.Number.Cmp(lastHead) != 0 {
+			lastHead = head.Number
+			delay = minConfirmationPoll
+		}
+		receipt, confs, err := confirmedReceipt(ctx, client, hash, head.Number)
+		if err != nil {
+			return nil, err
+		}
+		if confs != lastConfs {
+			lastConfs = confs
+			if progress != nil {
+				progress(receipt, confs)
+			}
+		}
+		if confs >= confirmations {
+			return receipt, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxConfirmationPoll {
+			delay = maxConfirmationPoll
+		}
+	}
+}
+
+// confirmedReceipt returns the receipt of hash and its number of confirmations at head,
+// or a nil receipt if the transaction is not in the canonical chain.
+func confirmedReceipt(ctx context.Context, client Client, hash common.Hash, head *big.Int) (*Receipt, uint64, error) {
+	receipt, err := client.GetTransactionReceipt(ctx, hash)
+	if err == NotFoundErr {
+		return nil, 0, nil
+	} else if err != nil {
+		return nil, 0, fmt.Errorf("cannot get the receipt: %v", err)
+	}
+	block, err := client.GetBlockByNumber(ctx, new(big.Int).SetUint64(receipt.BlockNumber), false)
+	if err == NotFoundErr {
+		return nil, 0, nil
+	} else if err != nil {
+		return nil, 0, fmt.Errorf("cannot get block %d: %v", receipt.BlockNumber, err)
+	}
+	if block.Hash != receipt.BlockHash {
+		// Reorged out of the canonical chain. It may be included again in another block.
+		return nil, 0, nil
+	}
+	if head.Uint64() < receipt.BlockNumber {
+		// The receipt is from a block newer than the head we fetched.
+		return receipt, 1, nil
+	}
+	return receipt, head.Uint64() - receipt.BlockNumber + 1, nil
+}
@@ -0,0 +1,120 @@
+package web3
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/gochain-io/gochain/v3/common"
+)
+
+// confirmStep is the state of the chain seen by one poll of WaitForConfirmations.
+type confirmStep struct {
+	head      int64
+	canonical map[uint64]common.Hash // block hashes by number
+	receipt   *Receipt               // nil while pending
+}
+
+// newConfirmMock returns a MockClient which moves to the next step each time the head is
+// polled, and records the time of each poll.
+func newConfirmMock(steps []confirmStep, polls *[]time.Time) *MockClient {
+	var m MockClient
+	step := -1
+	m.GetBlockByNumberFunc = func(ctx context.Context, number *big.Int, includeTxs bool) (*Block, error) {
+		if number == nil {
+			if step < len(steps)-1 {
+				step++
+			}
+			*polls = append(*polls, time.Now())
+			number = big.NewInt(steps[step].head)
+		}
+		hash, ok := steps[step].canonical[number.Uint64()]
+		if !ok {
+			return nil, NotFoundErr
+		}
+		return &Block{Number: number, Hash: hash}, nil
+	}
+	m.GetTransactionReceiptFunc = func(ctx context.Context, hash common.Hash) (*Receipt, error) {
+		if r := steps[step].receipt; r != nil {
+			return r, nil
+		}
+		return nil, NotFoundErr
+	}
+	return &m
+}
+
+func TestWaitForConfirmationsReorg(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	a, b, c := common.HexToHash("0xa"), common.HexToHash("0xb"), common.HexToHash("0xc")
+	hash := common.HexToHash("0x01")
+	inA := &Receipt{TxHash: hash, BlockNumber: 10, BlockHash: a}
+	inC := &Receipt{TxHash: hash, BlockNumber: 11, BlockHash: c}
+	steps := []confirmStep{
+		{head: 9, canonical: map[uint64]common.Hash{9: {9}}},
+		{head: 10, canonical: map[uint64]common.Hash{10: a}, receipt: inA},
+		{head: 11, canonical: map[uint64]common.Hash{10: a, 11: {11}}, receipt: inA},
+		// Block 10 is replaced by b at the same height, but the node still has the old
+		// receipt.
+		{head: 11, canonical: map[uint64]common.Hash{10: b, 11: {12}}, receipt: inA},
+		// The transaction is included again in block 11.
+		{head: 12, canonical: map[uint64]common.Hash{10: b, 11: c, 12: {12}}, receipt: inC},
+		{head: 13, canonical: map[uint64]common.Hash{10: b, 11: c, 13: {13}}, receipt: inC},
+	}
+	var polls []time.Time
+	type update struct {
+		block uint64 // 0 when pending
+		confs uint64
+	}
+	var updates []update
+	r, err := WaitForConfirmations(ctx, newConfirmMock(steps, &polls), hash, 3, func(r *Receipt, confs uint64) {
+		u := update{confs: confs}
+		if r != nil {
+			u.block = r.BlockNumber
+		}
+		updates = append(updates, u)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.BlockNumber != 11 || r.BlockHash != c {
+		t.Errorf("expected receipt in block 11 %s but got block %d %s", c.Hex(), r.BlockNumber, r.BlockHash.Hex())
+	}
+	exp := []update{{0, 0}, {10, 1}, {10, 2}, {0, 0}, {11, 2}, {11, 3}}
+	if len(updates) != len(exp) {
+		t.Fatalf("expected progress %v but got %v", exp, updates)
+	}
+	for i := range exp {
+		if updates[i] != exp[i] {
+			t.Fatalf("expected progress %v but got %v", exp, updates)
+		}
+	}
+	if len(polls) != len(steps) {
+		t.Fatalf("expected %d polls but got %d", len(steps), len(polls))
+	}
+	// The head did not change with the reorg, so the poll after it is backed off.
+	if d := polls[4].Sub(polls[3]); d < 2*minConfirmationPoll {
+		t.Errorf("expected backoff of at least %s after the reorg but polled after %s", 2*minConfirmationPoll, d)
+	}
+}
+
+func TestWaitForConfirmationsBackoff(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 4*minConfirmationPoll)
+	defer cancel()
+	steps := []confirmStep{{head: 10, canonical: map[uint64]common.Hash{10: {10}}}}
+	var polls []time.Time
+	_, err := WaitForConfirmations(ctx, newConfirmMock(steps, &polls), common.HexToHash("0x01"), 1, nil)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expected %v but got %v", context.DeadlineExceeded, err)
+	}
+	// Polls at 0, 1 and 3 times the minimum interval, since the head never changes.
+	if len(polls) != 3 {
+		t.Fatalf("expected 3 polls but got %d", len(polls))
+	}
+	for i, min := range []time.Duration{minConfirmationPoll, 2 * minConfirmationPoll} {
+		if d := polls[i+1].Sub(polls[i]); d < min {
+			t.Errorf("poll %d: expected delay of at least %s but got %s", i+1, min, d)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package web3
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/gochain-io/gochain/v3/common/math"
+	"github.com/gochain-io/gochain/v3/crypto"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+var (
+	bip39Words   = strings.Fields(bip39English)
+	bip39Indexes = func() map[string]int {
+		m := make(map[string]int, len(bip39Words))
+		for i, w := range bip39Words {
+			m[w] = i
+		}
+		return m
+	}()
+)
+
+// NewMnemonic returns a random BIP-39 mnemonic of 12, 15, 18, 21 or 24 words.
+func NewMnemonic(words int) (string, error) {
+	if words%3 != 0 || words < 12 || words > 24 {
+		return "", fmt.Errorf("invalid mnemonic length %d: must be 12, 15, 18, 21 or 24 words", words)
+	}
+	entropy := make([]byte, words/3*4)
+	if _, err := rand.Read(entropy); err != nil {
+		return "", err
+	}
+	return EntropyToMnemonic(entropy)
+}
+
+// EntropyToMnemonic returns the BIP-39 mnemonic encoding entropy, which must be 16, 20,
+// 24, 28 or 32 bytes.
+func EntropyToMnemonic(entropy []byte) (string, error) {
+	if len(entropy)%4 != 0 || len(entropy) < 16 || len(entropy) > 32 {
+		return "", fmt.Errorf("invalid entropy length %d: must be 16, 20, 24, 28 or 32 bytes", len(entropy))
+	}
+	// The entropy is followed by 1 checksum bit for every 4 bytes, and split into 11
+	// bit indexes of words.
+	checksum := sha256.Sum256(entropy)
+	bits := new(big.Int).SetBytes(entropy)
+	checksumBits := uint(len(entropy) / 4)
+	bits.Lsh(bits, checksumBits)
+	bits.Or(bits, big.NewInt(int64(checksum[0]>>(8-checksumBits))))
+
+	words := make([]string, (len(entropy)*8+int(checksumBits))/11)
+	mask := big.NewInt(2047)
+	for i := len(words) - 1; i >= 0; i-- {
+		words[i] = bip39Words[new(big.Int).And(bits, mask).Int64()]
+		bits.Rsh(bits, 11)
+	}
+	return strings.Join(words, " "), nil
+}
+
+// ValidateMnemonic returns an error if mnemonic is not a valid BIP-39 mnemonic, with an
+// unknown word or a wrong checksum.
+func ValidateMnemonic(mnemonic string) error {
+	_, err := mnemonicToEntropy(mnemonic)
+	return err
+}
+
+func mnemonicToEntropy(mnemonic string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	if len(words)%3 != 0 || len(words) < 12 || len(words) > 24 {
+		return nil, fmt.Errorf("invalid mnemonic length %d: must be 12, 15, 18, 21 or 24 words", len(words))
+	}
+	bits := new(big.Int)
+	for _, w := range words {
+		i, ok := bip39Indexes[strings.ToLower(w)]
+		if !ok {
+			return nil, fmt.Errorf("invalid mnemonic word %q", w)
+		}
+		bits.Lsh(bits, 11)
+		bits.Or(bits, big.NewInt(int64(i)))
+	}
+	checksumBits := uint(len(words) / 3)
+	checksum := new(big.Int).And(bits, big.NewInt(1<<checksumBits-1)).Int64()
+	bits.Rsh(bits, checksumBits)
+	entropy := math.PaddedBigBytes(bits, len(words)/3*4)
+	if sum := sha256.Sum256(entropy); int64(sum[0]>>(8-checksumBits)) != checksum {
+		return nil, errors.New("invalid mnemonic checksum")
+	}
+	return entropy, nil
+}
+
+// MnemonicToSeed validates mnemonic, and returns the BIP-39 seed derived from it and the
+// optional passphrase. The passphrase is used as given, without Unicode normalization.
+func MnemonicToSeed(mnemonic, passphrase string) ([]byte, error) {
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+	normalized := strings.ToLower(strings.Join(strings.Fields(mnemonic), " "))
+	return pbkdf2.Key([]byte(normalized), []byte("mnemonic"+passphrase), 2048, 64, sha512.New), nil
+}
+
+// DefaultBaseHDPath is the BIP-44 path of Ethereum accounts, to which the account index
+// is appended: m/44'/60'/0'/0/i.
+const DefaultBaseHDPath = "m/44'/60'/0'/0"
+
+// hardened is the offset of hardened BIP-32 child indexes.
+const hardened = 0x80000000
+
+// DerivationPath is a BIP-32 path of child indexes. Hardened indexes include the
+// 0x80000000 offset.
+type DerivationPath []uint32
+
+// ParseDerivationPath parses a path like m/44'/60'/0'/0/0. Hardened indexes are marked
+// with ' or h.
+func ParseDerivationPath(path string) (DerivationPath, error) {
+	parts := strings.Split(strings.TrimSpace(path), "/")
+	if parts[0] != "m" {
+		return nil, fmt.Errorf("invalid derivation path %q: must start with m", path)
+	}
+	var p DerivationPath
+	for _, part := range parts[1:] {
+		offset := uint32(0)
+		if strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h") {
+			offset = hardened
+			part = part[:len(part)-1]
+		}
+		i, err := strconv.ParseUint(part, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("invalid derivation path %q: invalid index %q", path, part)
+		}
+		p = append(p, uint32(i)+offset)
+	}
+	return p, nil
+}
+
+// Child returns a copy of p with index appended.
+func (p DerivationPath) Child(index uint32) DerivationPath {
+	return append(append(DerivationPath{}, p...), index)
+}
+
+func (p DerivationPath) String() string {
+	var sb strings.Builder
+	sb.WriteString("m")
+	for _, i := range p {
+		if i >= hardened {
+			fmt.Fprintf(&sb, "/%d'", i-hardened)
+		} else {
+			fmt.Fprintf(&sb, "/%d", i)
+		}
+	}
+	return sb.String()
+}
+
+// DeriveAccount returns the account at path in the BIP-32 tree of the seed.
+func DeriveAccount(seed []byte, path DerivationPath) (*Account, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, fmt.Errorf("invalid seed length %d: must be 16 to 64 bytes", len(seed))
+	}
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chainCode := new(big.Int).SetBytes(sum[:32]), sum[32:]
+	n := crypto.S256().Params().N
+	if key.Sign() == 0 || key.Cmp(n) >= 0 {
+		return nil, errors.New("invalid master key: use another seed")
+	}
+
+	for depth, index := range path {
+		// Hardened children are derived from the private key, others from the
+		// compressed public key.
+		data := make([]byte, 37)
+		if index >= hardened {
+			copy(data[1:], math.PaddedBigBytes(key, 32))
+		} else {
+			priv, err := crypto.ToECDSA(math.PaddedBigBytes(key, 32))
+			if err != nil {
+				return nil, err
+			}
+			copy(data, crypto.CompressPubkey(&priv.PublicKey))
+		}
+		binary.BigEndian.PutUint32(data[33:], index)
+		mac := hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+		tweak := new(big.Int).SetBytes(sum[:32])
+		if tweak.Cmp(n) >= 0 {
+			return nil, fmt.Errorf("invalid child key at %s: use the next index", path[:depth+1])
+		}
+		key.Add(key, tweak).Mod(key, n)
+		if key.Sign() == 0 {
+			return nil, fmt.Errorf("invalid child key at %s: use the next index", path[:depth+1])
+		}
+		chainCode = sum[32:]
+	}
+
+	priv, err := crypto.ToECDSA(math.PaddedBigBytes(key, 32))
+	if err != nil {
+		return nil, err
+	}
+	return &Account{key: priv}, nil
+}
+
+// MnemonicAccount returns the account with index under the base derivation path, in the
+// tree of the seed of mnemonic and passphrase. An empty base uses DefaultBaseHDPath.
+func MnemonicAccount(mnemonic, passphrase, base string, index uint32) (*Account, error) {
+	if base == "" {
+		base = DefaultBaseHDPath
+	}
+	path, err := ParseDerivationPath(base)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := MnemonicToSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return DeriveAccount(seed, path.Child(index))
+}
@@ -0,0 +1,117 @@
+package web3
+
+import (
+	"encoding/hex"
+	"hash/crc32"
+	"strings"
+	"testing"
+)
+
+func TestMnemonic(t *testing.T) {
+	// The checksum of the wordlist published with the specification.
+	if sum := crc32.ChecksumIEEE([]byte(bip39English)); sum != 0xc1dbd296 || len(bip39Words) != 2048 {
+		t.Fatalf("invalid wordlist: checksum %x, %d words", sum, len(bip39Words))
+	}
+
+	// Test vectors from the BIP-39 specification, with the passphrase TREZOR.
+	for _, test := range []struct {
+		entropy, mnemonic, seed string
+	}{
+		{
+			entropy:  "00000000000000000000000000000000",
+			mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			seed:     "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		},
+		{
+			entropy:  "7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+			mnemonic: "legal winner thank year wave sausage worth useful legal winner thank yellow",
+			seed:     "2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+		},
+		{
+			entropy:  "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			mnemonic: strings.Repeat("zoo ", 23) + "vote",
+			seed:     "dd48c104698c30cfe2b6142103248622fb7bb0ff692eebb00089b32d22484e1613912f0a5b694407be899ffd31ed3992c456cdf60f5d4564b8ba3f05a69890ad",
+		},
+	} {
+		entropy, _ := hex.DecodeString(test.entropy)
+		mnemonic, err := EntropyToMnemonic(entropy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mnemonic != test.mnemonic {
+			t.Errorf("expected mnemonic %q but got %q", test.mnemonic, mnemonic)
+		}
+		seed, err := MnemonicToSeed(mnemonic, "TREZOR")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(seed); got != test.seed {
+			t.Errorf("%s: expected seed %s but got %s", mnemonic, test.seed, got)
+		}
+	}
+
+	for _, words := range []int{12, 24} {
+		mnemonic, err := NewMnemonic(words)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := len(strings.Fields(mnemonic)); got != words {
+			t.Errorf("expected %d words but got %d", words, got)
+		}
+		if err := ValidateMnemonic(mnemonic); err != nil {
+			t.Errorf("%s: %v", mnemonic, err)
+		}
+	}
+	if _, err := NewMnemonic(13); err == nil {
+		t.Error("expected error for 13 words")
+	}
+	for _, mnemonic := range []string{
+		strings.Repeat("abandon ", 12),
+		strings.Repeat("abandon ", 11) + "notaword",
+		strings.Repeat("abandon ", 10) + "about",
+	} {
+		if err := ValidateMnemonic(mnemonic); err == nil {
+			t.Errorf("%s: expected error", mnemonic)
+		}
+	}
+}
+
+func TestDeriveAccount(t *testing.T) {
+	// Test vector 1 from the BIP-32 specification.
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	path, err := ParseDerivationPath("m/0'/1/2h/2/1000000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := path.String(); s != "m/0'/1/2'/2/1000000000" {
+		t.Errorf("unexpected path %s", s)
+	}
+	acct, err := DeriveAccount(seed, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "0x471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8"; acct.PrivateKey() != exp {
+		t.Errorf("expected private key %s but got %s", exp, acct.PrivateKey())
+	}
+
+	// The development accounts of hardhat and anvil.
+	const mnemonic = "test test test test test test test test test test test junk"
+	for i, exp := range []string{
+		"0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266",
+		"0x70997970C51812dc3A010C7d01b50e0d17dc79C8",
+	} {
+		acct, err := MnemonicAccount(mnemonic, "", "", uint32(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if acct.PublicKey() != exp {
+			t.Errorf("account %d: expected %s but got %s", i, exp, acct.PublicKey())
+		}
+	}
+
+	for _, p := range []string{"", "44'/60'", "m/x", "m/2147483648"} {
+		if _, err := ParseDerivationPath(p); err == nil {
+			t.Errorf("%q: expected error", p)
+		}
+	}
+}
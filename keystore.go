@@ -0,0 +1,122 @@
+package web3
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/gochain-io/gochain/v3/accounts"
+	"github.com/gochain-io/gochain/v3/accounts/keystore"
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/crypto"
+)
+
+// Keystore is a directory of accounts stored in scrypt encrypted V3 keystore files, the
+// format used by geth and most wallets.
+type Keystore struct {
+	ks *keystore.KeyStore
+}
+
+// KeystoreAccount is an account stored in a Keystore.
+type KeystoreAccount struct {
+	Address common.Address `json:"address"`
+	Path    string         `json:"path"` // the key file
+}
+
+// OpenKeystore returns the Keystore in dir. The directory is created when the first
+// account is stored. With lightKDF, new key files are encrypted with weaker scrypt
+// parameters, which are much faster to decrypt.
+func OpenKeystore(dir string, lightKDF bool) *Keystore {
+	n, p := keystore.StandardScryptN, keystore.StandardScryptP
+	if lightKDF {
+		n, p = keystore.LightScryptN, keystore.LightScryptP
+	}
+	return &Keystore{ks: keystore.NewKeyStore(dir, n, p)}
+}
+
+// Accounts returns the accounts in the keystore, ordered by key file.
+func (k *Keystore) Accounts() []KeystoreAccount {
+	var accts []KeystoreAccount
+	for _, a := range k.ks.Accounts() {
+		accts = append(accts, KeystoreAccount{Address: a.Address, Path: a.URL.Path})
+	}
+	return accts
+}
+
+// Create generates a new account, and stores it encrypted with password.
+func (k *Keystore) Create(password string) (KeystoreAccount, error) {
+	a, err := k.ks.NewAccount(password)
+	if err != nil {
+		return KeystoreAccount{}, err
+	}
+	return KeystoreAccount{Address: a.Address, Path: a.URL.Path}, nil
+}
+
+// Import stores the key of acct encrypted with password.
+func (k *Keystore) Import(acct *Account, password string) (KeystoreAccount, error) {
+	a, err := k.ks.ImportECDSA(acct.key, password)
+	if err != nil {
+		return KeystoreAccount{}, err
+	}
+	return KeystoreAccount{Address: a.Address, Path: a.URL.Path}, nil
+}
+
+// ImportJSON stores the key from the encrypted keyJSON, re-encrypted with newPassword.
+func (k *Keystore) ImportJSON(keyJSON []byte, password, newPassword string) (KeystoreAccount, error) {
+	key, err := keystore.DecryptKey(keyJSON, password)
+	if err != nil {
+		return KeystoreAccount{}, fmt.Errorf("cannot decrypt key: %v", err)
+	}
+	if k.ks.HasAddress(key.Address) {
+		return KeystoreAccount{}, fmt.Errorf("account %s already exists", key.Address.Hex())
+	}
+	return k.Import(&Account{key: key.PrivateKey}, newPassword)
+}
+
+// Export decrypts the key of the account for address.
+func (k *Keystore) Export(address common.Address, password string) (*Account, error) {
+	a, err := k.find(address)
+	if err != nil {
+		return nil, err
+	}
+	keyJSON, err := ioutil.ReadFile(a.URL.Path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read key file: %v", err)
+	}
+	key, err := keystore.DecryptKey(keyJSON, password)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt key file %s: %v", a.URL.Path, err)
+	}
+	if got := crypto.PubkeyToAddress(key.PrivateKey.PublicKey); got != address {
+		return nil, fmt.Errorf("key file %s contains the key of %s", a.URL.Path, got.Hex())
+	}
+	return &Account{key: key.PrivateKey}, nil
+}
+
+// UpdatePassword re-encrypts the key of the account for address with newPassword.
+func (k *Keystore) UpdatePassword(address common.Address, password, newPassword string) error {
+	a, err := k.find(address)
+	if err != nil {
+		return err
+	}
+	if err := k.ks.Update(a, password, newPassword); err != nil {
+		return fmt.Errorf("cannot update key file %s: %v", a.URL.Path, err)
+	}
+	return nil
+}
+
+// Signer returns a Signer for the account for address, decrypted with password.
+func (k *Keystore) Signer(address common.Address, password string) (Signer, error) {
+	acct, err := k.Export(address, password)
+	if err != nil {
+		return nil, err
+	}
+	return NewAccountSigner(acct), nil
+}
+
+func (k *Keystore) find(address common.Address) (accounts.Account, error) {
+	a, err := k.ks.Find(accounts.Account{Address: address})
+	if err == keystore.ErrNoMatch {
+		return a, fmt.Errorf("account %s not found in keystore", address.Hex())
+	}
+	return a, err
+}
@@ -0,0 +1,74 @@
+package web3
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/gochain-io/gochain/v3/common"
+)
+
+func TestKeystore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "web3-keystore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ks := OpenKeystore(dir, true)
+
+	created, err := ks.Create("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	acct, err := CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := ks.Import(acct, "bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.Address != common.HexToAddress(acct.PublicKey()) {
+		t.Errorf("expected imported address %s but got %s", acct.PublicKey(), imported.Address.Hex())
+	}
+	if _, err := ks.Import(acct, "bar"); err == nil {
+		t.Error("expected error importing an existing account")
+	}
+
+	// The accounts are found when the directory is opened again.
+	ks = OpenKeystore(dir, true)
+	if accts := ks.Accounts(); len(accts) != 2 {
+		t.Fatalf("expected 2 accounts but got %d", len(accts))
+	}
+
+	if _, err := ks.Export(imported.Address, "foo"); err == nil {
+		t.Error("expected error for wrong password")
+	}
+	exported, err := ks.Export(imported.Address, "bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exported.PrivateKey() != acct.PrivateKey() {
+		t.Errorf("expected private key %s but got %s", acct.PrivateKey(), exported.PrivateKey())
+	}
+	if _, err := ks.Export(common.HexToAddress("0x1234"), "bar"); err == nil {
+		t.Error("expected error for unknown account")
+	}
+
+	if err := ks.UpdatePassword(created.Address, "foo", "baz"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.Signer(created.Address, "foo"); err == nil {
+		t.Error("expected error for old password")
+	}
+	s, err := ks.Signer(created.Address, "baz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Address() != created.Address {
+		t.Errorf("expected signer address %s but got %s", created.Address.Hex(), s.Address().Hex())
+	}
+	if _, err := OpenKeystoreSigner(created.Path, "baz"); err != nil {
+		t.Errorf("cannot open key file: %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package web3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/gochain-io/gochain/v3/accounts/abi"
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/crypto"
+)
+
+// tt256 is 2^256, used to encode negative integers as two's complement topics.
+var tt256 = new(big.Int).Lsh(big.NewInt(1), 256)
+
+// FilterEvents queries the logs matching q and decodes them with myabi, like ParseLogs.
+func FilterEvents(ctx context.Context, client Client, myabi abi.ABI, q FilterQuery) ([]Event, error) {
+	logs, err := client.GetLogs(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	return ParseLogs(myabi, logs)
+}
+
+// EventTopics builds a topic filter matching event, for use in FilterQuery.Topics.
+// args correspond to the indexed inputs of the event, in order. A nil arg matches any
+// value, and a []interface{} arg matches any of the contained values. Trailing
+// indexed inputs may be omitted.
+func EventTopics(event abi.Event, args ...interface{}) ([][]common.Hash, error) {
+	indexed := getInputs(event.Inputs, true)
+	if len(args) > len(indexed) {
+		return nil, fmt.Errorf("too many arguments for event %q: expected at most %d, given %d", event.Name, len(indexed), len(args))
+	}
+	topics := [][]common.Hash{{EventID(event)}}
+	for i, arg := range args {
+		input := indexed[i]
+		var alts []interface{}
+		switch arg := arg.(type) {
+		case nil:
+		case []interface{}:
+			alts = arg
+		default:
+			alts = []interface{}{arg}
+		}
+		var hashes []common.Hash
+		for _, alt := range alts {
+			h, err := topicFor(input.Type, alt)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for indexed input %q: %v", input.Name, err)
+			}
+			hashes = append(hashes, h)
+		}
+		topics = append(topics, hashes)
+	}
+	// Drop trailing wildcards.
+	for len(topics) > 1 && len(topics[len(topics)-1]) == 0 {
+		topics = topics[:len(topics)-1]
+	}
+	return topics, nil
+}
+
+// topicFor encodes v as a topic for an indexed input of type t.
+func topicFor(t abi.Type, v interface{}) (common.Hash, error) {
+	switch t.T {
+	case abi.AddressTy:
+		switch v := v.(type) {
+		case common.Address:
+			return common.BytesToHash(v.Bytes()), nil
+		case string:
+			if !common.IsHexAddress(v) {
+				return common.Hash{}, fmt.Errorf("invalid address %q", v)
+			}
+			return common.BytesToHash(common.HexToAddress(v).Bytes()), nil
+		}
+	case abi.BoolTy:
+		if b, ok := v.(bool); ok {
+			if b {
+				return common.BigToHash(big.NewInt(1)), nil
+			}
+			return common.Hash{}, nil
+		}
+	case abi.IntTy, abi.UintTy:
+		i, err := topicInt(v)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		if i.Sign() < 0 {
+			if t.T == abi.UintTy {
+				return common.Hash{}, fmt.Errorf("negative value %s for unsigned type %s", i, t)
+			}
+			i = new(big.Int).Add(tt256, i)
+		}
+		return common.BigToHash(i), nil
+	case abi.FixedBytesTy:
+		var h common.Hash
+		switch v := v.(type) {
+		case common.Hash:
+			return v, nil
+		case []byte:
+			if len(v) > t.Size {
+				return common.Hash{}, fmt.Errorf("%d bytes exceeds size of %s", len(v), t)
+			}
+			copy(h[:], v)
+			return h, nil
+		default:
+			rv := reflect.ValueOf(v)
+			if rv.Kind() == reflect.Array && rv.Type().Elem().Kind() == reflect.Uint8 && rv.Len() == t.Size {
+				reflect.Copy(reflect.ValueOf(h[:]), rv)
+				return h, nil
+			}
+		}
+	case abi.StringTy:
+		if s, ok := v.(string); ok {
+			return crypto.Keccak256Hash([]byte(s)), nil
+		}
+	case abi.BytesTy:
+		if b, ok := v.([]byte); ok {
+			return crypto.Keccak256Hash(b), nil
+		}
+	default:
+		return common.Hash{}, fmt.Errorf("unsupported indexed type %s", typeString(t))
+	}
+	return common.Hash{}, fmt.Errorf("cannot use %T as %s", v, t)
+}
+
+// topicInt converts the supported integer representations to a big.Int.
+func topicInt(v interface{}) (*big.Int, error) {
+	switch v := v.(type) {
+	case *big.Int:
+		if v == nil {
+			return nil, errors.New("nil integer")
+		}
+		return v, nil
+	case string:
+		return ParseBigInt(v)
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Int).SetUint64(rv.Uint()), nil
+	}
+	return nil, fmt.Errorf("cannot use %T as integer", v)
+}
+
+// IndexedHash is the value of an indexed event input of a dynamic type (string, bytes,
+// arrays or tuples). Only the keccak256 hash of such values is logged, so the original
+// value cannot be recovered.
+type IndexedHash common.Hash
+
+func (h IndexedHash) String() string {
+	return "keccak256(" + common.Hash(h).Hex() + ")"
+}
+
+func (h IndexedHash) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]common.Hash{"keccak256": common.Hash(h)})
+}
+
+// decodeTopic decodes topic as the value of an indexed input of type t. Values of
+// dynamic types are returned as an IndexedHash.
+func decodeTopic(t abi.Type, topic common.Hash) (interface{}, error) {
+	switch t.T {
+	case abi.AddressTy:
+		return common.BytesToAddress(topic[:]), nil
+	case abi.BoolTy:
+		switch topic.Big().Uint64() {
+		case 0:
+			return false, nil
+		case 1:
+			return true, nil
+		}
+		return nil, fmt.Errorf("invalid bool topic %s", topic.Hex())
+	case abi.IntTy, abi.UintTy:
+		i := topic.Big()
+		if t.T == abi.IntTy && i.Bit(255) == 1 {
+			i.Sub(i, tt256)
+		}
+		if !intInRange(t, i) {
+			return nil, fmt.Errorf("topic %s out of range for %s", topic.Hex(), t)
+		}
+		return intValue(t, i).Interface(), nil
+	case abi.FixedBytesTy:
+		arr := reflect.New(t.Type).Elem()
+		reflect.Copy(arr, reflect.ValueOf(topic[:t.Size]))
+		return arr.Interface(), nil
+	case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy, tupleTy:
+		return IndexedHash(topic), nil
+	}
+	return nil, fmt.Errorf("unsupported indexed type %s", typeString(t))
+}
@@ -0,0 +1,159 @@
+package web3
+
+import (
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gochain-io/gochain/v3/accounts/abi"
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/crypto"
+)
+
+const topicsTestABI = `[{"type":"event","name":"E","inputs":[
+	{"name":"a","type":"address","indexed":true},
+	{"name":"b","type":"uint256","indexed":true},
+	{"name":"c","type":"int8","indexed":true},
+	{"name":"d","type":"uint256","indexed":false}
+]},{"type":"event","name":"T","inputs":[
+	{"name":"a","type":"bool","indexed":true},
+	{"name":"b","type":"bytes4","indexed":true},
+	{"name":"c","type":"string","indexed":true},
+	{"name":"d","type":"bytes","indexed":true}
+]},{"type":"event","name":"A","inputs":[
+	{"name":"a","type":"uint256[]","indexed":true}
+]}]`
+
+func TestEventTopics(t *testing.T) {
+	myabi, err := abi.JSON(strings.NewReader(topicsTestABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, tt := myabi.Events["E"], myabi.Events["T"]
+	addr := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	hash := func(s string) common.Hash { return common.HexToHash(s) }
+	for _, test := range []struct {
+		name  string
+		event abi.Event
+		args  []interface{}
+		exp   [][]common.Hash
+	}{
+		{"no args", e, nil, [][]common.Hash{{e.Id()}}},
+		{"trailing wildcards", e, []interface{}{addr, nil}, [][]common.Hash{{e.Id()}, {hash("0x01")}}},
+		{"only wildcards", e, []interface{}{nil, nil, nil}, [][]common.Hash{{e.Id()}}},
+		{"inner wildcard", e, []interface{}{nil, big.NewInt(2)}, [][]common.Hash{{e.Id()}, nil, {hash("0x02")}}},
+		{"address string", e, []interface{}{"0x0000000000000000000000000000000000000001"}, [][]common.Hash{{e.Id()}, {hash("0x01")}}},
+		{"alternatives", e, []interface{}{nil, []interface{}{"1", "16", uint8(3)}}, [][]common.Hash{{e.Id()}, nil, {hash("0x01"), hash("0x10"), hash("0x03")}}},
+		{"negative int", e, []interface{}{nil, nil, -1}, [][]common.Hash{{e.Id()}, nil, nil, {hash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")}}},
+		{"bool", tt, []interface{}{true}, [][]common.Hash{{tt.Id()}, {hash("0x01")}}},
+		{"false", tt, []interface{}{false}, [][]common.Hash{{tt.Id()}, {{}}}},
+		{"bytes4 array", tt, []interface{}{nil, [4]byte{1, 2, 3, 4}}, [][]common.Hash{{tt.Id()}, nil, {hash("0x0102030400000000000000000000000000000000000000000000000000000000")}}},
+		{"bytes4 slice", tt, []interface{}{nil, []byte{1, 2}}, [][]common.Hash{{tt.Id()}, nil, {hash("0x0102000000000000000000000000000000000000000000000000000000000000")}}},
+		{"string", tt, []interface{}{nil, nil, "hi"}, [][]common.Hash{{tt.Id()}, nil, nil, {crypto.Keccak256Hash([]byte("hi"))}}},
+		{"bytes", tt, []interface{}{nil, nil, nil, []byte{0xff}}, [][]common.Hash{{tt.Id()}, nil, nil, nil, {crypto.Keccak256Hash([]byte{0xff})}}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := EventTopics(test.event, test.args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.exp) {
+				t.Errorf("expected %x but got %x", test.exp, got)
+			}
+		})
+	}
+}
+
+func TestEventTopicsErrors(t *testing.T) {
+	myabi, err := abi.JSON(strings.NewReader(topicsTestABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, tt, a := myabi.Events["E"], myabi.Events["T"], myabi.Events["A"]
+	for _, test := range []struct {
+		event abi.Event
+		args  []interface{}
+		err   string
+	}{
+		{e, []interface{}{nil, nil, nil, nil}, `too many arguments for event "E": expected at most 3, given 4`},
+		{e, []interface{}{"0x1234"}, `invalid value for indexed input "a": invalid address "0x1234"`},
+		{e, []interface{}{nil, -1}, `invalid value for indexed input "b": negative value -1 for unsigned type uint256`},
+		{e, []interface{}{nil, 1.5}, `invalid value for indexed input "b": cannot use float64 as integer`},
+		{e, []interface{}{nil, (*big.Int)(nil)}, `invalid value for indexed input "b": nil integer`},
+		{tt, []interface{}{"true"}, `invalid value for indexed input "a": cannot use string as bool`},
+		{tt, []interface{}{nil, []byte{1, 2, 3, 4, 5}}, `invalid value for indexed input "b": 5 bytes exceeds size of bytes4`},
+		{tt, []interface{}{nil, [2]byte{}}, `invalid value for indexed input "b": cannot use [2]uint8 as bytes4`},
+		{a, []interface{}{[]interface{}{[]int{1}}}, `invalid value for indexed input "a": unsupported indexed type uint256[]`},
+	} {
+		_, err := EventTopics(test.event, test.args...)
+		if err == nil {
+			t.Errorf("expected error for %v", test.args)
+		} else if err.Error() != test.err {
+			t.Errorf("expected error %q but got %q", test.err, err)
+		}
+	}
+}
+
+func TestDecodeTopic(t *testing.T) {
+	myabi, err := abi.JSON(strings.NewReader(topicsTestABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, tt := myabi.Events["E"], myabi.Events["T"]
+	for _, test := range []struct {
+		typ abi.Type
+		v   interface{}
+	}{
+		{e.Inputs[0].Type, common.HexToAddress("0x0000000000000000000000000000000000000001")},
+		{e.Inputs[1].Type, big.NewInt(1 << 40)},
+		{e.Inputs[2].Type, int8(-128)},
+		{tt.Inputs[0].Type, true},
+		{tt.Inputs[1].Type, [4]byte{1, 2, 3, 4}},
+	} {
+		h, err := topicFor(test.typ, test.v)
+		if err != nil {
+			t.Fatalf("%s: %v", test.typ, err)
+		}
+		got, err := decodeTopic(test.typ, h)
+		if err != nil {
+			t.Fatalf("%s: %v", test.typ, err)
+		}
+		if !reflect.DeepEqual(got, test.v) {
+			t.Errorf("%s: expected %v but got %v", test.typ, test.v, got)
+		}
+	}
+	h := crypto.Keccak256Hash([]byte("hi"))
+	if got, err := decodeTopic(tt.Inputs[2].Type, h); err != nil || got != IndexedHash(h) {
+		t.Errorf("expected indexed hash %s but got %v (%v)", h.Hex(), got, err)
+	}
+}
+
+func TestToFilterArg(t *testing.T) {
+	arg := toFilterArg(FilterQuery{}).(map[string]interface{})
+	if _, ok := arg["fromBlock"]; ok {
+		t.Errorf("expected fromBlock to be omitted but got %v", arg["fromBlock"])
+	}
+	if arg["toBlock"] != "latest" {
+		t.Errorf("expected toBlock latest but got %v", arg["toBlock"])
+	}
+	if _, ok := arg["address"]; ok {
+		t.Errorf("expected address to be omitted but got %v", arg["address"])
+	}
+
+	addr := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	arg = toFilterArg(FilterQuery{
+		FromBlock: big.NewInt(0),
+		ToBlock:   big.NewInt(16),
+		Addresses: []common.Address{addr},
+	}).(map[string]interface{})
+	if arg["fromBlock"] != "0x0" {
+		t.Errorf("expected fromBlock 0x0 but got %v", arg["fromBlock"])
+	}
+	if arg["toBlock"] != "0x10" {
+		t.Errorf("expected toBlock 0x10 but got %v", arg["toBlock"])
+	}
+	if got := arg["address"].([]common.Address); len(got) != 1 || got[0] != addr {
+		t.Errorf("unexpected address %v", got)
+	}
+}
@@ -0,0 +1,427 @@
+package web3
+
+import (
+	"context"
+	"log"
+	"math/big"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/core/types"
+)
+
+// Middleware intercepts the calls made through a Client returned by Wrap. method is the
+// name of the Client method, e.g. "GetBalance", and call performs it, or invokes the next
+// middleware. A Middleware may modify ctx, and may invoke call any number of times.
+type Middleware func(ctx context.Context, method string, call func(context.Context) error) error
+
+// Wrap returns a Client which passes every call to client through middlewares, with the
+// first being outermost. Wrapped clients may be wrapped again, so middleware stacks.
+func Wrap(client Client, middlewares ...Middleware) Client {
+	return &wrappedClient{client: client, middlewares: middlewares}
+}
+
+// RetryOptions configures the Retry middleware. Zero values select the defaults.
+type RetryOptions struct {
+	// Attempts is the maximum number of attempts, including the first. Default: 5.
+	Attempts int
+	// MinBackoff is the delay before the first retry, which doubles for each subsequent
+	// retry. Default: 250ms.
+	MinBackoff time.Duration
+	// MaxBackoff limits the delay between retries. Default: 10s.
+	MaxBackoff time.Duration
+}
+
+// Retry returns a Middleware which retries calls failing with transient errors: connection
+// failures, timeouts, and HTTP 429 or 5xx responses. SendRawTransaction is only retried if
+// the transaction cannot have reached the node, since otherwise it may be sent twice.
+func Retry(opts RetryOptions) Middleware {
+	if opts.Attempts <= 0 {
+		opts.Attempts = 5
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = 250 * time.Millisecond
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 10 * time.Second
+	}
+	return func(ctx context.Context, method string, call func(context.Context) error) error {
+		backoff := opts.MinBackoff
+		for attempt := 1; ; attempt++ {
+			err := call(ctx)
+			if err == nil || attempt >= opts.Attempts || ctx.Err() != nil {
+				return err
+			}
+			if method == "SendRawTransaction" {
+				if !isUnsent(err) {
+					return err
+				}
+			} else if !isTransient(err) {
+				return err
+			}
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(backoff):
+			}
+			if backoff *= 2; backoff > opts.MaxBackoff {
+				backoff = opts.MaxBackoff
+			}
+		}
+	}
+}
+
+// isTransient reports whether a call failing with err may succeed if retried.
+func isTransient(err error) bool {
+	return isFailover(err) || isUnsent(err) || err == context.DeadlineExceeded
+}
+
+// isUnsent reports whether err means the request was not processed: either the
+// connection failed, or the request was rejected by rate limiting.
+func isUnsent(err error) bool {
+	if ue, ok := err.(*url.Error); ok {
+		err = ue.Err
+	}
+	if oe, ok := err.(*net.OpError); ok && oe.Op == "dial" {
+		return true
+	}
+	return strings.HasPrefix(err.Error(), "429 ")
+}
+
+// RateLimit returns a Middleware which limits calls to perSecond on average, with bursts
+// of up to burst calls, using a token bucket. Calls wait for a token or until their
+// context is done.
+func RateLimit(perSecond float64, burst int) Middleware {
+	if perSecond <= 0 {
+		panic("web3: RateLimit requires a positive rate")
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	b := &tokenBucket{rate: perSecond, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+	return func(ctx context.Context, method string, call func(context.Context) error) error {
+		if err := b.wait(ctx); err != nil {
+			return err
+		}
+		return call(ctx)
+	}
+}
+
+type tokenBucket struct {
+	rate, burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// wait takes a token, waiting for one to become available if necessary.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	// Take the token now, even if it goes negative, so waiters are served in order.
+	b.tokens--
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+	if delay == 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
+
+// Timeout returns a Middleware which bounds each call to d. When combined with Retry,
+// Timeout should be inner so that it applies to each attempt.
+func Timeout(d time.Duration) Middleware {
+	return func(ctx context.Context, method string, call func(context.Context) error) error {
+		ctx, cancel := context.WithTimeout(ctx, d)
+		defer cancel()
+		return call(ctx)
+	}
+}
+
+// Logging returns a Middleware which logs each call with its duration and error using
+// logf, or the standard logger if nil.
+func Logging(logf func(format string, args ...interface{})) Middleware {
+	if logf == nil {
+		logf = log.Printf
+	}
+	return func(ctx context.Context, method string, call func(context.Context) error) error {
+		start := time.Now()
+		err := call(ctx)
+		if err != nil {
+			logf("web3: %s failed after %s: %v", method, time.Since(start), err)
+		} else {
+			logf("web3: %s took %s", method, time.Since(start))
+		}
+		return err
+	}
+}
+
+type wrappedClient struct {
+	client      Client
+	middlewares []Middleware
+}
+
+// do invokes call for method through the middlewares.
+func (w *wrappedClient) do(ctx context.Context, method string, call func(context.Context) error) error {
+	for i := len(w.middlewares) - 1; i >= 0; i-- {
+		mw, next := w.middlewares[i], call
+		call = func(ctx context.Context) error { return mw(ctx, method, next) }
+	}
+	return call(ctx)
+}
+
+func (w *wrappedClient) Close() {
+	w.client.Close()
+}
+
+func (w *wrappedClient) GetBalance(ctx context.Context, address string, blockNumber *big.Int) (*big.Int, error) {
+	var r *big.Int
+	err := w.do(ctx, "GetBalance", func(ctx context.Context) (err error) {
+		r, err = w.client.GetBalance(ctx, address, blockNumber)
+		return
+	})
+	return r, err
+}
+
+func (w *wrappedClient) GetCode(ctx context.Context, address string, blockNumber *big.Int) ([]byte, error) {
+	var r []byte
+	err := w.do(ctx, "GetCode", func(ctx context.Context) (err error) {
+		r, err = w.client.GetCode(ctx, address, blockNumber)
+		return
+	})
+	return r, err
+}
+
+func (w *wrappedClient) GetStorageAt(ctx context.Context, address common.Address, slot common.Hash, blockNumber *big.Int) (common.Hash, error) {
+	var r common.Hash
+	err := w.do(ctx, "GetStorageAt", func(ctx context.Context) (err error) {
+		r, err = w.client.GetStorageAt(ctx, address, slot, blockNumber)
+		return
+	})
+	return r, err
+}
+
+func (w *wrappedClient) GetProof(ctx context.Context, address common.Address, slots []common.Hash, blockNumber *big.Int) (*AccountProof, error) {
+	var r *AccountProof
+	err := w.do(ctx, "GetProof", func(ctx context.Context) (err error) {
+		r, err = w.client.GetProof(ctx, address, slots, blockNumber)
+		return
+	})
+	return r, err
+}
+
+func (w *wrappedClient) GetBlockByNumber(ctx context.Context, number *big.Int, includeTxs bool) (*Block, error) {
+	var r *Block
+	err := w.do(ctx, "GetBlockByNumber", func(ctx context.Context) (err error) {
+		r, err = w.client.GetBlockByNumber(ctx, number, includeTxs)
+		return
+	})
+	return r, err
+}
+
+func (w *wrappedClient) GetBlockByHash(ctx context.Context, hash string, includeTxs bool) (*Block, error) {
+	var r *Block
+	err := w.do(ctx, "GetBlockByHash", func(ctx context.Context) (err error) {
+		r, err = w.client.GetBlockByHash(ctx, hash, includeTxs)
+		return
+	})
+	return r, err
+}
+
+func (w *wrappedClient) GetTransactionByHash(ctx context.Context, hash common.Hash) (*Transaction, error) {
+	var r *Transaction
+	err := w.do(ctx, "GetTransactionByHash", func(ctx context.Context) (err error) {
+		r, err = w.client.GetTransactionByHash(ctx, hash)
+		return
+	})
+	return r, err
+}
+
+func (w *wrappedClient) GetSnapshot(ctx context.Context) (*Snapshot, error) {
+	var r *Snapshot
+	err := w.do(ctx, "GetSnapshot", func(ctx context.Context) (err error) {
+		r, err = w.client.GetSnapshot(ctx)
+		return
+	})
+	return r, err
+}
+
+func (w *wrappedClient) GetID(ctx context.Context) (*ID, error) {
+	var r *ID
+	err := w.do(ctx, "GetID", func(ctx context.Context) (err error) {
+		r, err = w.client.GetID(ctx)
+		return
+	})
+	return r, err
+}
+
+func (w *wrappedClient) GetTransactionReceipt(ctx context.Context, hash common.Hash) (*Receipt, error) {
+	var r *Receipt
+	err := w.do(ctx, "GetTransactionReceipt", func(ctx context.Context) (err error) {
+		r, err = w.client.GetTransactionReceipt(ctx, hash)
+		return
+	})
+	return r, err
+}
+
+func (w *wrappedClient) GetChainID(ctx context.Context) (*big.Int, error) {
+	var r *big.Int
+	err := w.do(ctx, "GetChainID", func(ctx context.Context) (err error) {
+		r, err = w.client.GetChainID(ctx)
+		return
+	})
+	return r, err
+}
+
+func (w *wrappedClient) GetNetworkID(ctx context.Context) (*big.Int, error) {
+	var r *big.Int
+	err := w.do(ctx, "GetNetworkID", func(ctx context.Context) (err error) {
+		r, err = w.client.GetNetworkID(ctx)
+		return
+	})
+	return r, err
+}
+
+func (w *wrappedClient) GetGasPrice(ctx context.Context) (*big.Int, error) {
+	var r *big.Int
+	err := w.do(ctx, "GetGasPrice", func(ctx context.Context) (err error) {
+		r, err = w.client.GetGasPrice(ctx)
+		return
+	})
+	return r, err
+}
+
+func (w *wrappedClient) GetPendingTransactionCount(ctx context.Context, account common.Address) (uint64, error) {
+	var r uint64
+	err := w.do(ctx, "GetPendingTransactionCount", func(ctx context.Context) (err error) {
+		r, err = w.client.GetPendingTransactionCount(ctx, account)
+		return
+	})
+	return r, err
+}
+
+func (w *wrappedClient) SendRawTransaction(ctx context.Context, tx []byte) error {
+	return w.do(ctx, "SendRawTransaction", func(ctx context.Context) error {
+		return w.client.SendRawTransaction(ctx, tx)
+	})
+}
+
+func (w *wrappedClient) Call(ctx context.Context, msg CallMsg) ([]byte, error) {
+	var r []byte
+	err := w.do(ctx, "Call", func(ctx context.Context) (err error) {
+		r, err = w.client.Call(ctx, msg)
+		return
+	})
+	return r, err
+}
+
+func (w *wrappedClient) EstimateGas(ctx context.Context, msg CallMsg) (uint64, error) {
+	var r uint64
+	err := w.do(ctx, "EstimateGas", func(ctx context.Context) (err error) {
+		r, err = w.client.EstimateGas(ctx, msg)
+		return
+	})
+	return r, err
+}
+
+func (w *wrappedClient) TraceTransaction(ctx context.Context, hash common.Hash, cfg *TraceConfig) (*Trace, error) {
+	var r *Trace
+	err := w.do(ctx, "TraceTransaction", func(ctx context.Context) (err error) {
+		r, err = w.client.TraceTransaction(ctx, hash, cfg)
+		return
+	})
+	return r, err
+}
+
+func (w *wrappedClient) TraceCall(ctx context.Context, msg CallMsg, cfg *TraceConfig) (*Trace, error) {
+	var r *Trace
+	err := w.do(ctx, "TraceCall", func(ctx context.Context) (err error) {
+		r, err = w.client.TraceCall(ctx, msg, cfg)
+		return
+	})
+	return r, err
+}
+
+func (w *wrappedClient) GetLogs(ctx context.Context, q FilterQuery) ([]*types.Log, error) {
+	var r []*types.Log
+	err := w.do(ctx, "GetLogs", func(ctx context.Context) (err error) {
+		r, err = w.client.GetLogs(ctx, q)
+		return
+	})
+	return r, err
+}
+
+func (w *wrappedClient) SubscribeNewHeads(ctx context.Context, ch chan<- *Block) (Subscription, error) {
+	var r Subscription
+	err := w.do(ctx, "SubscribeNewHeads", func(ctx context.Context) (err error) {
+		r, err = w.client.SubscribeNewHeads(ctx, ch)
+		return
+	})
+	return r, err
+}
+
+func (w *wrappedClient) SubscribeLogs(ctx context.Context, q FilterQuery, ch chan<- *types.Log) (Subscription, error) {
+	var r Subscription
+	err := w.do(ctx, "SubscribeLogs", func(ctx context.Context) (err error) {
+		r, err = w.client.SubscribeLogs(ctx, q, ch)
+		return
+	})
+	return r, err
+}
+
+func (w *wrappedClient) SubscribePendingTransactions(ctx context.Context, ch chan<- common.Hash) (Subscription, error) {
+	var r Subscription
+	err := w.do(ctx, "SubscribePendingTransactions", func(ctx context.Context) (err error) {
+		r, err = w.client.SubscribePendingTransactions(ctx, ch)
+		return
+	})
+	return r, err
+}
+
+func (w *wrappedClient) GetReceipts(ctx context.Context, hashes []common.Hash) ([]*Receipt, error) {
+	var r []*Receipt
+	err := w.do(ctx, "GetReceipts", func(ctx context.Context) (err error) {
+		r, err = w.client.GetReceipts(ctx, hashes)
+		return
+	})
+	return r, err
+}
+
+func (w *wrappedClient) GetBlocksByNumber(ctx context.Context, start, end *big.Int, includeTxs bool) ([]*Block, error) {
+	var r []*Block
+	err := w.do(ctx, "GetBlocksByNumber", func(ctx context.Context) (err error) {
+		r, err = w.client.GetBlocksByNumber(ctx, start, end, includeTxs)
+		return
+	})
+	return r, err
+}
+
+func (w *wrappedClient) GetBalances(ctx context.Context, addresses []common.Address, blockNumber *big.Int) ([]*big.Int, error) {
+	var r []*big.Int
+	err := w.do(ctx, "GetBalances", func(ctx context.Context) (err error) {
+		r, err = w.client.GetBalances(ctx, addresses, blockNumber)
+		return
+	})
+	return r, err
+}
@@ -0,0 +1,198 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	opts := RetryOptions{Attempts: 3, MinBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+	for _, test := range []struct {
+		name     string
+		send     bool
+		errs     []error // the error of each attempt, then success
+		attempts int
+		fail     bool
+	}{
+		{"success", false, nil, 1, false},
+		{"transient", false, []error{io.EOF, errors.New("503 Service Unavailable")}, 3, false},
+		{"exhausted", false, []error{io.EOF, io.EOF, io.EOF, io.EOF}, 3, true},
+		{"permanent", false, []error{errors.New("execution reverted")}, 1, true},
+		{"rate limited", false, []error{errors.New("429 Too Many Requests")}, 2, false},
+		{"timeout", false, []error{context.DeadlineExceeded}, 2, false},
+		{"send unsent", true, []error{dialErr, errors.New("429 Too Many Requests")}, 3, false},
+		{"send eof", true, []error{io.EOF}, 1, true},
+		{"send server error", true, []error{errors.New("502 Bad Gateway")}, 1, true},
+		{"send timeout", true, []error{context.DeadlineExceeded}, 1, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var attempts int
+			call := func() error {
+				attempts++
+				if attempts <= len(test.errs) {
+					return test.errs[attempts-1]
+				}
+				return nil
+			}
+			var m MockClient
+			m.GetBalanceFunc = func(ctx context.Context, address string, blockNumber *big.Int) (*big.Int, error) {
+				return big.NewInt(1), call()
+			}
+			m.SendRawTransactionFunc = func(ctx context.Context, tx []byte) error {
+				return call()
+			}
+			c := Wrap(&m, Retry(opts))
+			var err error
+			if test.send {
+				err = c.SendRawTransaction(ctx, []byte{1})
+			} else {
+				_, err = c.GetBalance(ctx, "", nil)
+			}
+			if attempts != test.attempts {
+				t.Errorf("expected %d attempts but got %d", test.attempts, attempts)
+			}
+			if (err != nil) != test.fail {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	var times []time.Time
+	var m MockClient
+	m.GetGasPriceFunc = func(ctx context.Context) (*big.Int, error) {
+		times = append(times, time.Now())
+		return nil, io.EOF
+	}
+	opts := RetryOptions{Attempts: 4, MinBackoff: 20 * time.Millisecond, MaxBackoff: 30 * time.Millisecond}
+	if _, err := Wrap(&m, Retry(opts)).GetGasPrice(context.Background()); err != io.EOF {
+		t.Fatalf("expected %v but got %v", io.EOF, err)
+	}
+	if len(times) != 4 {
+		t.Fatalf("expected 4 attempts but got %d", len(times))
+	}
+	for i, min := range []time.Duration{20 * time.Millisecond, 30 * time.Millisecond, 30 * time.Millisecond} {
+		if d := times[i+1].Sub(times[i]); d < min {
+			t.Errorf("retry %d: expected backoff of at least %s but got %s", i+1, min, d)
+		}
+	}
+
+	// Cancellation interrupts the backoff.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	times = nil
+	opts = RetryOptions{Attempts: 10, MinBackoff: time.Hour}
+	start := time.Now()
+	if _, err := Wrap(&m, Retry(opts)).GetGasPrice(ctx); err != io.EOF {
+		t.Errorf("expected %v but got %v", io.EOF, err)
+	}
+	if len(times) != 1 {
+		t.Errorf("expected 1 attempt but got %d", len(times))
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("expected the backoff to be cancelled but returned after %s", d)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	var mu sync.Mutex
+	var times []time.Time
+	var m MockClient
+	m.GetGasPriceFunc = func(ctx context.Context) (*big.Int, error) {
+		mu.Lock()
+		times = append(times, time.Now())
+		mu.Unlock()
+		return big.NewInt(1), nil
+	}
+	const rate = 20 // per second, so one call every 50ms
+	c := Wrap(&m, RateLimit(rate, 2))
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.GetGasPrice(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if len(times) != 6 {
+		t.Fatalf("expected 6 calls but got %d", len(times))
+	}
+	// The burst of 2 is immediate, and the other 4 calls are paced.
+	if d := time.Since(start); d < 4*time.Second/rate {
+		t.Errorf("expected 6 calls to take at least %s but took %s", 4*time.Second/rate, d)
+	}
+	var immediate int
+	for _, at := range times {
+		if at.Sub(start) < time.Second/rate/2 {
+			immediate++
+		}
+	}
+	if immediate > 2 {
+		t.Errorf("expected at most 2 immediate calls but got %d", immediate)
+	}
+
+	// Waiting for a token is bounded by the context, and returns the token.
+	c = Wrap(&m, RateLimit(1, 1))
+	if _, err := c.GetGasPrice(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.GetGasPrice(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected %v but got %v", context.DeadlineExceeded, err)
+	}
+	if len(times) != 7 {
+		t.Errorf("expected the call to be skipped but got %d calls", len(times))
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	var deadlines []time.Duration
+	var m MockClient
+	m.GetGasPriceFunc = func(ctx context.Context) (*big.Int, error) {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			t.Fatal("expected a deadline")
+		}
+		deadlines = append(deadlines, time.Until(deadline))
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	// Timeout is inner, so it bounds each attempt rather than the whole call.
+	c := Wrap(&m, Retry(RetryOptions{Attempts: 2, MinBackoff: time.Millisecond}), Timeout(20*time.Millisecond))
+	if _, err := c.GetGasPrice(context.Background()); err != context.DeadlineExceeded {
+		t.Errorf("expected %v but got %v", context.DeadlineExceeded, err)
+	}
+	if len(deadlines) != 2 {
+		t.Fatalf("expected 2 attempts but got %d", len(deadlines))
+	}
+	for i, d := range deadlines {
+		if d > 20*time.Millisecond || d <= 0 {
+			t.Errorf("attempt %d: expected a deadline of about 20ms but got %s", i+1, d)
+		}
+	}
+
+	// A shorter deadline of the caller is kept.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	deadlines = nil
+	if _, err := Wrap(&m, Timeout(time.Hour)).GetGasPrice(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected %v but got %v", context.DeadlineExceeded, err)
+	}
+	if len(deadlines) != 1 || deadlines[0] > 5*time.Millisecond {
+		t.Errorf("expected the caller's deadline but got %v", deadlines)
+	}
+}
@@ -0,0 +1,241 @@
+package web3
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/core/types"
+)
+
+// MockClient is a programmable Client for tests. Each method calls the corresponding
+// func field, or returns an error if it is nil. Close calls CloseFunc, if set.
+//
+//	c := &web3.MockClient{
+//		GetBalanceFunc: func(ctx context.Context, address string, blockNumber *big.Int) (*big.Int, error) {
+//			return big.NewInt(1), nil
+//		},
+//	}
+type MockClient struct {
+	GetBalanceFunc                   func(ctx context.Context, address string, blockNumber *big.Int) (*big.Int, error)
+	GetCodeFunc                      func(ctx context.Context, address string, blockNumber *big.Int) ([]byte, error)
+	GetStorageAtFunc                 func(ctx context.Context, address common.Address, slot common.Hash, blockNumber *big.Int) (common.Hash, error)
+	GetProofFunc                     func(ctx context.Context, address common.Address, slots []common.Hash, blockNumber *big.Int) (*AccountProof, error)
+	GetBlockByNumberFunc             func(ctx context.Context, number *big.Int, includeTxs bool) (*Block, error)
+	GetBlockByHashFunc               func(ctx context.Context, hash string, includeTxs bool) (*Block, error)
+	GetTransactionByHashFunc         func(ctx context.Context, hash common.Hash) (*Transaction, error)
+	GetSnapshotFunc                  func(ctx context.Context) (*Snapshot, error)
+	GetIDFunc                        func(ctx context.Context) (*ID, error)
+	GetTransactionReceiptFunc        func(ctx context.Context, hash common.Hash) (*Receipt, error)
+	GetChainIDFunc                   func(ctx context.Context) (*big.Int, error)
+	GetNetworkIDFunc                 func(ctx context.Context) (*big.Int, error)
+	GetGasPriceFunc                  func(ctx context.Context) (*big.Int, error)
+	GetPendingTransactionCountFunc   func(ctx context.Context, account common.Address) (uint64, error)
+	SendRawTransactionFunc           func(ctx context.Context, tx []byte) error
+	CallFunc                         func(ctx context.Context, msg CallMsg) ([]byte, error)
+	EstimateGasFunc                  func(ctx context.Context, msg CallMsg) (uint64, error)
+	TraceTransactionFunc             func(ctx context.Context, hash common.Hash, cfg *TraceConfig) (*Trace, error)
+	TraceCallFunc                    func(ctx context.Context, msg CallMsg, cfg *TraceConfig) (*Trace, error)
+	GetLogsFunc                      func(ctx context.Context, q FilterQuery) ([]*types.Log, error)
+	SubscribeNewHeadsFunc            func(ctx context.Context, ch chan<- *Block) (Subscription, error)
+	SubscribeLogsFunc                func(ctx context.Context, q FilterQuery, ch chan<- *types.Log) (Subscription, error)
+	SubscribePendingTransactionsFunc func(ctx context.Context, ch chan<- common.Hash) (Subscription, error)
+	GetReceiptsFunc                  func(ctx context.Context, hashes []common.Hash) ([]*Receipt, error)
+	GetBlocksByNumberFunc            func(ctx context.Context, start, end *big.Int, includeTxs bool) ([]*Block, error)
+	GetBalancesFunc                  func(ctx context.Context, addresses []common.Address, blockNumber *big.Int) ([]*big.Int, error)
+	CloseFunc                        func()
+}
+
+// notMocked returns the error for calls to a method with a nil func field.
+func notMocked(method string) error {
+	return fmt.Errorf("MockClient.%sFunc not set", method)
+}
+
+func (m *MockClient) GetBalance(ctx context.Context, address string, blockNumber *big.Int) (*big.Int, error) {
+	if m.GetBalanceFunc == nil {
+		return nil, notMocked("GetBalance")
+	}
+	return m.GetBalanceFunc(ctx, address, blockNumber)
+}
+
+func (m *MockClient) GetCode(ctx context.Context, address string, blockNumber *big.Int) ([]byte, error) {
+	if m.GetCodeFunc == nil {
+		return nil, notMocked("GetCode")
+	}
+	return m.GetCodeFunc(ctx, address, blockNumber)
+}
+
+func (m *MockClient) GetStorageAt(ctx context.Context, address common.Address, slot common.Hash, blockNumber *big.Int) (common.Hash, error) {
+	if m.GetStorageAtFunc == nil {
+		return common.Hash{}, notMocked("GetStorageAt")
+	}
+	return m.GetStorageAtFunc(ctx, address, slot, blockNumber)
+}
+
+func (m *MockClient) GetProof(ctx context.Context, address common.Address, slots []common.Hash, blockNumber *big.Int) (*AccountProof, error) {
+	if m.GetProofFunc == nil {
+		return nil, notMocked("GetProof")
+	}
+	return m.GetProofFunc(ctx, address, slots, blockNumber)
+}
+
+func (m *MockClient) GetBlockByNumber(ctx context.Context, number *big.Int, includeTxs bool) (*Block, error) {
+	if m.GetBlockByNumberFunc == nil {
+		return nil, notMocked("GetBlockByNumber")
+	}
+	return m.GetBlockByNumberFunc(ctx, number, includeTxs)
+}
+
+func (m *MockClient) GetBlockByHash(ctx context.Context, hash string, includeTxs bool) (*Block, error) {
+	if m.GetBlockByHashFunc == nil {
+		return nil, notMocked("GetBlockByHash")
+	}
+	return m.GetBlockByHashFunc(ctx, hash, includeTxs)
+}
+
+func (m *MockClient) GetTransactionByHash(ctx context.Context, hash common.Hash) (*Transaction, error) {
+	if m.GetTransactionByHashFunc == nil {
+		return nil, notMocked("GetTransactionByHash")
+	}
+	return m.GetTransactionByHashFunc(ctx, hash)
+}
+
+func (m *MockClient) GetSnapshot(ctx context.Context) (*Snapshot, error) {
+	if m.GetSnapshotFunc == nil {
+		return nil, notMocked("GetSnapshot")
+	}
+	return m.GetSnapshotFunc(ctx)
+}
+
+func (m *MockClient) GetID(ctx context.Context) (*ID, error) {
+	if m.GetIDFunc == nil {
+		return nil, notMocked("GetID")
+	}
+	return m.GetIDFunc(ctx)
+}
+
+func (m *MockClient) GetTransactionReceipt(ctx context.Context, hash common.Hash) (*Receipt, error) {
+	if m.GetTransactionReceiptFunc == nil {
+		return nil, notMocked("GetTransactionReceipt")
+	}
+	return m.GetTransactionReceiptFunc(ctx, hash)
+}
+
+func (m *MockClient) GetChainID(ctx context.Context) (*big.Int, error) {
+	if m.GetChainIDFunc == nil {
+		return nil, notMocked("GetChainID")
+	}
+	return m.GetChainIDFunc(ctx)
+}
+
+func (m *MockClient) GetNetworkID(ctx context.Context) (*big.Int, error) {
+	if m.GetNetworkIDFunc == nil {
+		return nil, notMocked("GetNetworkID")
+	}
+	return m.GetNetworkIDFunc(ctx)
+}
+
+func (m *MockClient) GetGasPrice(ctx context.Context) (*big.Int, error) {
+	if m.GetGasPriceFunc == nil {
+		return nil, notMocked("GetGasPrice")
+	}
+	return m.GetGasPriceFunc(ctx)
+}
+
+func (m *MockClient) GetPendingTransactionCount(ctx context.Context, account common.Address) (uint64, error) {
+	if m.GetPendingTransactionCountFunc == nil {
+		return 0, notMocked("GetPendingTransactionCount")
+	}
+	return m.GetPendingTransactionCountFunc(ctx, account)
+}
+
+func (m *MockClient) SendRawTransaction(ctx context.Context, tx []byte) error {
+	if m.SendRawTransactionFunc == nil {
+		return notMocked("SendRawTransaction")
+	}
+	return m.SendRawTransactionFunc(ctx, tx)
+}
+
+func (m *MockClient) Call(ctx context.Context, msg CallMsg) ([]byte, error) {
+	if m.CallFunc == nil {
+		return nil, notMocked("Call")
+	}
+	return m.CallFunc(ctx, msg)
+}
+
+func (m *MockClient) EstimateGas(ctx context.Context, msg CallMsg) (uint64, error) {
+	if m.EstimateGasFunc == nil {
+		return 0, notMocked("EstimateGas")
+	}
+	return m.EstimateGasFunc(ctx, msg)
+}
+
+func (m *MockClient) TraceTransaction(ctx context.Context, hash common.Hash, cfg *TraceConfig) (*Trace, error) {
+	if m.TraceTransactionFunc == nil {
+		return nil, notMocked("TraceTransaction")
+	}
+	return m.TraceTransactionFunc(ctx, hash, cfg)
+}
+
+func (m *MockClient) TraceCall(ctx context.Context, msg CallMsg, cfg *TraceConfig) (*Trace, error) {
+	if m.TraceCallFunc == nil {
+		return nil, notMocked("TraceCall")
+	}
+	return m.TraceCallFunc(ctx, msg, cfg)
+}
+
+func (m *MockClient) GetLogs(ctx context.Context, q FilterQuery) ([]*types.Log, error) {
+	if m.GetLogsFunc == nil {
+		return nil, notMocked("GetLogs")
+	}
+	return m.GetLogsFunc(ctx, q)
+}
+
+func (m *MockClient) SubscribeNewHeads(ctx context.Context, ch chan<- *Block) (Subscription, error) {
+	if m.SubscribeNewHeadsFunc == nil {
+		return nil, notMocked("SubscribeNewHeads")
+	}
+	return m.SubscribeNewHeadsFunc(ctx, ch)
+}
+
+func (m *MockClient) SubscribeLogs(ctx context.Context, q FilterQuery, ch chan<- *types.Log) (Subscription, error) {
+	if m.SubscribeLogsFunc == nil {
+		return nil, notMocked("SubscribeLogs")
+	}
+	return m.SubscribeLogsFunc(ctx, q, ch)
+}
+
+func (m *MockClient) SubscribePendingTransactions(ctx context.Context, ch chan<- common.Hash) (Subscription, error) {
+	if m.SubscribePendingTransactionsFunc == nil {
+		return nil, notMocked("SubscribePendingTransactions")
+	}
+	return m.SubscribePendingTransactionsFunc(ctx, ch)
+}
+
+func (m *MockClient) GetReceipts(ctx context.Context, hashes []common.Hash) ([]*Receipt, error) {
+	if m.GetReceiptsFunc == nil {
+		return nil, notMocked("GetReceipts")
+	}
+	return m.GetReceiptsFunc(ctx, hashes)
+}
+
+func (m *MockClient) GetBlocksByNumber(ctx context.Context, start, end *big.Int, includeTxs bool) ([]*Block, error) {
+	if m.GetBlocksByNumberFunc == nil {
+		return nil, notMocked("GetBlocksByNumber")
+	}
+	return m.GetBlocksByNumberFunc(ctx, start, end, includeTxs)
+}
+
+func (m *MockClient) GetBalances(ctx context.Context, addresses []common.Address, blockNumber *big.Int) ([]*big.Int, error) {
+	if m.GetBalancesFunc == nil {
+		return nil, notMocked("GetBalances")
+	}
+	return m.GetBalancesFunc(ctx, addresses, blockNumber)
+}
+
+func (m *MockClient) Close() {
+	if m.CloseFunc != nil {
+		m.CloseFunc()
+	}
+}
@@ -0,0 +1,509 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/core/types"
+	"github.com/gochain-io/gochain/v3/rpc"
+)
+
+// ErrNoHealthyEndpoints is returned by a multi-endpoint client which has no endpoints to
+// send a request to.
+var ErrNoHealthyEndpoints = errors.New("no healthy endpoints")
+
+// MultiOptions configures a multi-endpoint client. Zero values select the defaults.
+type MultiOptions struct {
+	// MaxLag is the number of blocks an endpoint may lag behind the highest known head
+	// before it stops serving requests. Default: 5.
+	MaxLag uint64
+	// CheckInterval is the time between health checks. Default: 15s.
+	CheckInterval time.Duration
+	// CheckTimeout bounds each health check request. Default: 5s.
+	CheckTimeout time.Duration
+}
+
+func (o *MultiOptions) setDefaults() {
+	if o.MaxLag == 0 {
+		o.MaxLag = 5
+	}
+	if o.CheckInterval == 0 {
+		o.CheckInterval = 15 * time.Second
+	}
+	if o.CheckTimeout == 0 {
+		o.CheckTimeout = 5 * time.Second
+	}
+}
+
+// DialMulti returns a Client which spreads requests over the endpoints at urls. See
+// NewMultiClient. Endpoints which fail to dial are skipped, unless all of them fail.
+func DialMulti(urls []string, opts MultiOptions, clientOpts ...ClientOption) (Client, error) {
+	var clients []Client
+	var lastErr error
+	for _, url := range urls {
+		c, err := Dial(url, clientOpts...)
+		if err != nil {
+			lastErr = fmt.Errorf("failed to dial %q: %v", url, err)
+			continue
+		}
+		clients = append(clients, c)
+	}
+	if len(clients) == 0 {
+		if lastErr == nil {
+			lastErr = errors.New("no urls")
+		}
+		return nil, lastErr
+	}
+	return NewMultiClient(clients, opts), nil
+}
+
+// NewMultiClient returns a Client which routes each request to the healthiest of clients,
+// among those whose head block is within opts.MaxLag of the highest head of the healthy
+// endpoints. The highest head is preferred, so that reads do not go backwards, then the
+// lowest latency. Health is checked periodically in the background, and heads are also
+// updated from the blocks and receipts returned between checks. Requests which fail with
+// a connection or HTTP 5xx error are retried on the next endpoint, and the failed
+// endpoint is avoided until it passes a health check, unless no endpoint is healthy. Raw
+// transactions are only retried if they cannot have reached the failed endpoint, so they
+// are not broadcast twice. Closing the returned Client closes all of clients.
+func NewMultiClient(clients []Client, opts MultiOptions) Client {
+	opts.setDefaults()
+	m := &multiClient{opts: opts, quit: make(chan struct{})}
+	for _, c := range clients {
+		m.endpoints = append(m.endpoints, &endpoint{client: c})
+	}
+	m.check()
+	go m.loop()
+	return m
+}
+
+type multiClient struct {
+	opts      MultiOptions
+	endpoints []*endpoint
+	quit      chan struct{}
+	closeOnce sync.Once
+
+	mu      sync.RWMutex
+	maxHead uint64
+}
+
+type endpoint struct {
+	client Client
+
+	mu      sync.RWMutex
+	healthy bool
+	latency time.Duration
+	head    uint64
+}
+
+func (m *multiClient) loop() {
+	t := time.NewTicker(m.opts.CheckInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-m.quit:
+			return
+		case <-t.C:
+			m.check()
+		}
+	}
+}
+
+// check concurrently measures the latency and head of each endpoint.
+func (m *multiClient) check() {
+	var wg sync.WaitGroup
+	for _, e := range m.endpoints {
+		wg.Add(1)
+		go func(e *endpoint) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), m.opts.CheckTimeout)
+			defer cancel()
+			start := time.Now()
+			block, err := e.client.GetBlockByNumber(ctx, nil, false)
+			latency := time.Since(start)
+			e.mu.Lock()
+			defer e.mu.Unlock()
+			e.healthy = err == nil
+			if err == nil {
+				e.latency = latency
+				e.head = block.Number.Uint64()
+			}
+		}(e)
+	}
+	wg.Wait()
+	// Only the endpoints which are healthy now count, so that a failed endpoint which was
+	// ahead does not exclude all the others.
+	var maxHead uint64
+	for _, e := range m.endpoints {
+		e.mu.RLock()
+		if e.healthy && e.head > maxHead {
+			maxHead = e.head
+		}
+		e.mu.RUnlock()
+	}
+	m.mu.Lock()
+	m.maxHead = maxHead
+	m.mu.Unlock()
+}
+
+// observe records that e has block number, so its head is at least that high. This
+// enforces the maximum lag between health checks.
+func (m *multiClient) observe(e *endpoint, number uint64) {
+	e.mu.Lock()
+	if number > e.head {
+		e.head = number
+	}
+	e.mu.Unlock()
+	m.mu.Lock()
+	if number > m.maxHead {
+		m.maxHead = number
+	}
+	m.mu.Unlock()
+}
+
+// candidates returns the healthy endpoints within the maximum lag, highest head first and
+// then fastest first. If none are healthy, all the endpoints are returned in the same
+// order, since a single failed request is enough to mark an endpoint unhealthy until the
+// next health check.
+func (m *multiClient) candidates() []*endpoint {
+	m.mu.RLock()
+	maxHead := m.maxHead
+	m.mu.RUnlock()
+	type candidate struct {
+		e       *endpoint
+		head    uint64
+		latency time.Duration
+	}
+	var cs, all []candidate
+	for _, e := range m.endpoints {
+		e.mu.RLock()
+		c := candidate{e: e, head: e.head, latency: e.latency}
+		if e.healthy && e.head+m.opts.MaxLag >= maxHead {
+			cs = append(cs, c)
+		}
+		all = append(all, c)
+		e.mu.RUnlock()
+	}
+	if len(cs) == 0 {
+		cs = all
+	}
+	sort.Slice(cs, func(i, j int) bool {
+		if cs[i].head != cs[j].head {
+			return cs[i].head > cs[j].head
+		}
+		return cs[i].latency < cs[j].latency
+	})
+	es := make([]*endpoint, len(cs))
+	for i := range cs {
+		es[i] = cs[i].e
+	}
+	return es
+}
+
+// do calls fn with each candidate endpoint in turn, until it succeeds or fails with an
+// error other than a connection failure.
+func (m *multiClient) do(ctx context.Context, fn func(Client) error) error {
+	return m.try(ctx, isFailover, func(e *endpoint) error {
+		return fn(e.client)
+	})
+}
+
+// try calls fn with each candidate endpoint in turn, until it succeeds or fails with an
+// error which retry rejects. Endpoints failing with a connection error are avoided until
+// the next health check, while any others are healthy.
+func (m *multiClient) try(ctx context.Context, retry func(error) bool, fn func(*endpoint) error) error {
+	es := m.candidates()
+	if len(es) == 0 {
+		return ErrNoHealthyEndpoints
+	}
+	var err error
+	for _, e := range es {
+		err = fn(e)
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+		if isFailover(err) {
+			e.mu.Lock()
+			e.healthy = false
+			e.mu.Unlock()
+		}
+		if !retry(err) {
+			return err
+		}
+	}
+	return err
+}
+
+var httpServerError = regexp.MustCompile(`^5\d\d `)
+
+// isFailover reports whether err indicates that the endpoint itself is failing, rather
+// than the request.
+func isFailover(err error) bool {
+	if _, ok := err.(net.Error); ok {
+		return true
+	}
+	switch err {
+	case io.EOF, io.ErrUnexpectedEOF, rpc.ErrClientQuit:
+		return true
+	}
+	return httpServerError.MatchString(err.Error())
+}
+
+func (m *multiClient) Close() {
+	m.closeOnce.Do(func() {
+		close(m.quit)
+		for _, e := range m.endpoints {
+			e.client.Close()
+		}
+	})
+}
+
+func (m *multiClient) GetBalance(ctx context.Context, address string, blockNumber *big.Int) (*big.Int, error) {
+	var r *big.Int
+	err := m.do(ctx, func(c Client) (err error) {
+		r, err = c.GetBalance(ctx, address, blockNumber)
+		return
+	})
+	return r, err
+}
+
+func (m *multiClient) GetCode(ctx context.Context, address string, blockNumber *big.Int) ([]byte, error) {
+	var r []byte
+	err := m.do(ctx, func(c Client) (err error) {
+		r, err = c.GetCode(ctx, address, blockNumber)
+		return
+	})
+	return r, err
+}
+
+func (m *multiClient) GetStorageAt(ctx context.Context, address common.Address, slot common.Hash, blockNumber *big.Int) (common.Hash, error) {
+	var r common.Hash
+	err := m.do(ctx, func(c Client) (err error) {
+		r, err = c.GetStorageAt(ctx, address, slot, blockNumber)
+		return
+	})
+	return r, err
+}
+
+func (m *multiClient) GetProof(ctx context.Context, address common.Address, slots []common.Hash, blockNumber *big.Int) (*AccountProof, error) {
+	var r *AccountProof
+	err := m.do(ctx, func(c Client) (err error) {
+		r, err = c.GetProof(ctx, address, slots, blockNumber)
+		return
+	})
+	return r, err
+}
+
+func (m *multiClient) GetBlockByNumber(ctx context.Context, number *big.Int, includeTxs bool) (*Block, error) {
+	var r *Block
+	err := m.try(ctx, isFailover, func(e *endpoint) (err error) {
+		r, err = e.client.GetBlockByNumber(ctx, number, includeTxs)
+		if err == nil && r != nil && r.Number != nil {
+			m.observe(e, r.Number.Uint64())
+		}
+		return
+	})
+	return r, err
+}
+
+func (m *multiClient) GetBlockByHash(ctx context.Context, hash string, includeTxs bool) (*Block, error) {
+	var r *Block
+	err := m.try(ctx, isFailover, func(e *endpoint) (err error) {
+		r, err = e.client.GetBlockByHash(ctx, hash, includeTxs)
+		if err == nil && r != nil && r.Number != nil {
+			m.observe(e, r.Number.Uint64())
+		}
+		return
+	})
+	return r, err
+}
+
+func (m *multiClient) GetTransactionByHash(ctx context.Context, hash common.Hash) (*Transaction, error) {
+	var r *Transaction
+	err := m.do(ctx, func(c Client) (err error) {
+		r, err = c.GetTransactionByHash(ctx, hash)
+		return
+	})
+	return r, err
+}
+
+func (m *multiClient) GetSnapshot(ctx context.Context) (*Snapshot, error) {
+	var r *Snapshot
+	err := m.do(ctx, func(c Client) (err error) {
+		r, err = c.GetSnapshot(ctx)
+		return
+	})
+	return r, err
+}
+
+func (m *multiClient) GetID(ctx context.Context) (*ID, error) {
+	var r *ID
+	err := m.do(ctx, func(c Client) (err error) {
+		r, err = c.GetID(ctx)
+		return
+	})
+	return r, err
+}
+
+func (m *multiClient) GetTransactionReceipt(ctx context.Context, hash common.Hash) (*Receipt, error) {
+	var r *Receipt
+	err := m.try(ctx, isFailover, func(e *endpoint) (err error) {
+		r, err = e.client.GetTransactionReceipt(ctx, hash)
+		if err == nil && r != nil {
+			m.observe(e, r.BlockNumber)
+		}
+		return
+	})
+	return r, err
+}
+
+func (m *multiClient) GetChainID(ctx context.Context) (*big.Int, error) {
+	var r *big.Int
+	err := m.do(ctx, func(c Client) (err error) {
+		r, err = c.GetChainID(ctx)
+		return
+	})
+	return r, err
+}
+
+func (m *multiClient) GetNetworkID(ctx context.Context) (*big.Int, error) {
+	var r *big.Int
+	err := m.do(ctx, func(c Client) (err error) {
+		r, err = c.GetNetworkID(ctx)
+		return
+	})
+	return r, err
+}
+
+func (m *multiClient) GetGasPrice(ctx context.Context) (*big.Int, error) {
+	var r *big.Int
+	err := m.do(ctx, func(c Client) (err error) {
+		r, err = c.GetGasPrice(ctx)
+		return
+	})
+	return r, err
+}
+
+func (m *multiClient) GetPendingTransactionCount(ctx context.Context, account common.Address) (uint64, error) {
+	var r uint64
+	err := m.do(ctx, func(c Client) (err error) {
+		r, err = c.GetPendingTransactionCount(ctx, account)
+		return
+	})
+	return r, err
+}
+
+func (m *multiClient) SendRawTransaction(ctx context.Context, tx []byte) error {
+	// Other failures may happen after the node received the transaction.
+	return m.try(ctx, isUnsent, func(e *endpoint) error {
+		return e.client.SendRawTransaction(ctx, tx)
+	})
+}
+
+func (m *multiClient) Call(ctx context.Context, msg CallMsg) ([]byte, error) {
+	var r []byte
+	err := m.do(ctx, func(c Client) (err error) {
+		r, err = c.Call(ctx, msg)
+		return
+	})
+	return r, err
+}
+
+func (m *multiClient) EstimateGas(ctx context.Context, msg CallMsg) (uint64, error) {
+	var r uint64
+	err := m.do(ctx, func(c Client) (err error) {
+		r, err = c.EstimateGas(ctx, msg)
+		return
+	})
+	return r, err
+}
+
+func (m *multiClient) TraceTransaction(ctx context.Context, hash common.Hash, cfg *TraceConfig) (*Trace, error) {
+	var r *Trace
+	err := m.do(ctx, func(c Client) (err error) {
+		r, err = c.TraceTransaction(ctx, hash, cfg)
+		return
+	})
+	return r, err
+}
+
+func (m *multiClient) TraceCall(ctx context.Context, msg CallMsg, cfg *TraceConfig) (*Trace, error) {
+	var r *Trace
+	err := m.do(ctx, func(c Client) (err error) {
+		r, err = c.TraceCall(ctx, msg, cfg)
+		return
+	})
+	return r, err
+}
+
+func (m *multiClient) GetLogs(ctx context.Context, q FilterQuery) ([]*types.Log, error) {
+	var r []*types.Log
+	err := m.do(ctx, func(c Client) (err error) {
+		r, err = c.GetLogs(ctx, q)
+		return
+	})
+	return r, err
+}
+
+func (m *multiClient) SubscribeNewHeads(ctx context.Context, ch chan<- *Block) (Subscription, error) {
+	var r Subscription
+	err := m.do(ctx, func(c Client) (err error) {
+		r, err = c.SubscribeNewHeads(ctx, ch)
+		return
+	})
+	return r, err
+}
+
+func (m *multiClient) SubscribeLogs(ctx context.Context, q FilterQuery, ch chan<- *types.Log) (Subscription, error) {
+	var r Subscription
+	err := m.do(ctx, func(c Client) (err error) {
+		r, err = c.SubscribeLogs(ctx, q, ch)
+		return
+	})
+	return r, err
+}
+
+func (m *multiClient) SubscribePendingTransactions(ctx context.Context, ch chan<- common.Hash) (Subscription, error) {
+	var r Subscription
+	err := m.do(ctx, func(c Client) (err error) {
+		r, err = c.SubscribePendingTransactions(ctx, ch)
+		return
+	})
+	return r, err
+}
+
+func (m *multiClient) GetReceipts(ctx context.Context, hashes []common.Hash) ([]*Receipt, error) {
+	var r []*Receipt
+	err := m.do(ctx, func(c Client) (err error) {
+		r, err = c.GetReceipts(ctx, hashes)
+		return
+	})
+	return r, err
+}
+
+func (m *multiClient) GetBlocksByNumber(ctx context.Context, start, end *big.Int, includeTxs bool) ([]*Block, error) {
+	var r []*Block
+	err := m.do(ctx, func(c Client) (err error) {
+		r, err = c.GetBlocksByNumber(ctx, start, end, includeTxs)
+		return
+	})
+	return r, err
+}
+
+func (m *multiClient) GetBalances(ctx context.Context, addresses []common.Address, blockNumber *big.Int) ([]*big.Int, error) {
+	var r []*big.Int
+	err := m.do(ctx, func(c Client) (err error) {
+		r, err = c.GetBalances(ctx, addresses, blockNumber)
+		return
+	})
+	return r, err
+}
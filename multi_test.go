@@ -0,0 +1,230 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// multiMock is a MockClient endpoint for a multi-endpoint client, with a head block, a
+// latency, and an error for all calls when failing.
+type multiMock struct {
+	MockClient
+	name    string
+	latency time.Duration
+
+	mu    sync.Mutex
+	head  int64
+	err   error
+	calls int // calls other than health checks
+}
+
+func newMultiMock(name string, head int64, latency time.Duration) *multiMock {
+	m := &multiMock{name: name, head: head, latency: latency}
+	m.GetBlockByNumberFunc = func(ctx context.Context, number *big.Int, includeTxs bool) (*Block, error) {
+		time.Sleep(m.latency)
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.err != nil {
+			return nil, m.err
+		}
+		return &Block{Number: big.NewInt(m.head)}, nil
+	}
+	m.GetBalanceFunc = func(ctx context.Context, address string, blockNumber *big.Int) (*big.Int, error) {
+		if err := m.call(); err != nil {
+			return nil, err
+		}
+		return big.NewInt(m.head), nil
+	}
+	m.SendRawTransactionFunc = func(ctx context.Context, tx []byte) error {
+		return m.call()
+	}
+	return m
+}
+
+func (m *multiMock) call() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	return m.err
+}
+
+func (m *multiMock) set(head int64, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.head, m.err = head, err
+}
+
+// newTestMultiClient returns a multi-endpoint client whose health is only checked when
+// the test calls check.
+func newTestMultiClient(mocks ...*multiMock) *multiClient {
+	var clients []Client
+	for _, m := range mocks {
+		clients = append(clients, m)
+	}
+	return NewMultiClient(clients, MultiOptions{CheckInterval: time.Hour}).(*multiClient)
+}
+
+func expectCandidates(t *testing.T, mc *multiClient, exp ...*multiMock) {
+	t.Helper()
+	cs := mc.candidates()
+	var names, expNames []string
+	for _, e := range cs {
+		names = append(names, e.client.(*multiMock).name)
+	}
+	for _, m := range exp {
+		expNames = append(expNames, m.name)
+	}
+	if len(names) != len(expNames) {
+		t.Fatalf("expected candidates %v but got %v", expNames, names)
+	}
+	for i := range names {
+		if names[i] != expNames[i] {
+			t.Fatalf("expected candidates %v but got %v", expNames, names)
+		}
+	}
+}
+
+func TestMultiClientFailover(t *testing.T) {
+	ctx := context.Background()
+	fast, slow := newMultiMock("fast", 100, 0), newMultiMock("slow", 100, 20*time.Millisecond)
+	mc := newTestMultiClient(fast, slow)
+	defer mc.Close()
+	expectCandidates(t, mc, fast, slow)
+
+	fast.set(101, errConnReset)
+	b, err := mc.GetBalance(ctx, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Int64() != 100 {
+		t.Errorf("expected the slow endpoint's balance but got %s", b)
+	}
+	if fast.calls != 1 || slow.calls != 1 {
+		t.Errorf("expected 1 call to each endpoint but got %d and %d", fast.calls, slow.calls)
+	}
+	// The failed endpoint is avoided until it passes a health check.
+	expectCandidates(t, mc, slow)
+	mc.check()
+	expectCandidates(t, mc, slow)
+
+	// Recovery.
+	fast.set(101, nil)
+	mc.check()
+	expectCandidates(t, mc, fast, slow)
+	if b, err := mc.GetBalance(ctx, "", nil); err != nil || b.Int64() != 101 {
+		t.Errorf("expected the fast endpoint's balance but got %v (%v)", b, err)
+	}
+
+	// Other errors are returned without failover.
+	fast.set(101, errors.New("execution reverted"))
+	if _, err := mc.GetBalance(ctx, "", nil); err == nil || err.Error() != "execution reverted" {
+		t.Errorf("expected execution reverted but got %v", err)
+	}
+	if slow.calls != 1 {
+		t.Errorf("expected no failover but the slow endpoint was called %d times", slow.calls)
+	}
+	expectCandidates(t, mc, fast, slow)
+
+	fast.set(101, errConnReset)
+	slow.set(100, errConnReset)
+	if _, err := mc.GetBalance(ctx, "", nil); err != errConnReset {
+		t.Errorf("expected %v but got %v", errConnReset, err)
+	}
+	// With no healthy endpoints left, all of them are still tried before the next check.
+	expectCandidates(t, mc, fast, slow)
+	slow.set(100, nil)
+	if b, err := mc.GetBalance(ctx, "", nil); err != nil || b.Int64() != 100 {
+		t.Errorf("expected the slow endpoint's balance but got %v (%v)", b, err)
+	}
+
+	empty := newTestMultiClient()
+	defer empty.Close()
+	if _, err := empty.GetBalance(ctx, "", nil); err != ErrNoHealthyEndpoints {
+		t.Errorf("expected %v but got %v", ErrNoHealthyEndpoints, err)
+	}
+}
+
+func TestMultiClientLag(t *testing.T) {
+	ctx := context.Background()
+	a, b := newMultiMock("a", 100, 0), newMultiMock("b", 94, 20*time.Millisecond)
+	mc := newTestMultiClient(a, b)
+	defer mc.Close()
+	expectCandidates(t, mc, a)
+
+	b.set(95, nil)
+	mc.check()
+	expectCandidates(t, mc, a, b)
+
+	// The highest head is preferred over the lowest latency.
+	b.set(100, nil)
+	a.set(99, nil)
+	mc.check()
+	expectCandidates(t, mc, b, a)
+	a.set(100, nil)
+	b.set(95, nil)
+	mc.check()
+	expectCandidates(t, mc, a, b)
+
+	// Heads returned between health checks are enforced immediately.
+	a.set(101, nil)
+	if _, err := mc.GetBlockByNumber(ctx, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	expectCandidates(t, mc, a)
+
+	// When the endpoint which was ahead fails, the others are no longer lagging.
+	a.set(101, errConnReset)
+	mc.check()
+	expectCandidates(t, mc, b)
+	if bal, err := mc.GetBalance(ctx, "", nil); err != nil || bal.Int64() != 95 {
+		t.Errorf("expected a balance from b but got %v (%v)", bal, err)
+	}
+}
+
+func TestMultiClientSendRawTransaction(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		name     string
+		err      error
+		failover bool
+	}{
+		{"connection reset", errConnReset, false},
+		{"eof", io.EOF, false},
+		{"server error", errors.New("502 Bad Gateway"), false},
+		{"dial", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, true},
+		{"rate limited", errors.New("429 Too Many Requests"), true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			fast, slow := newMultiMock("fast", 100, 0), newMultiMock("slow", 100, 20*time.Millisecond)
+			mc := newTestMultiClient(fast, slow)
+			defer mc.Close()
+			fast.set(100, test.err)
+			err := mc.SendRawTransaction(ctx, []byte{1})
+			if test.failover {
+				if err != nil {
+					t.Errorf("expected failover but got %v", err)
+				}
+				if slow.calls != 1 {
+					t.Errorf("expected 1 call to the slow endpoint but got %d", slow.calls)
+				}
+				return
+			}
+			if err != test.err {
+				t.Errorf("expected %v but got %v", test.err, err)
+			}
+			if slow.calls != 0 {
+				t.Errorf("expected the transaction not to be sent again but got %d calls", slow.calls)
+			}
+			if isFailover(test.err) {
+				// The failing endpoint is still avoided by later requests.
+				expectCandidates(t, mc, slow)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package web3
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gochain-io/gochain/v3/common"
+)
+
+// nonceResyncInterval is how often Next resyncs an address with the node.
+const nonceResyncInterval = 30 * time.Second
+
+// NonceManager hands out transaction nonces for sending addresses, so that concurrent
+// senders from the same account don't collide. Nonces are synced from the node's pending
+// transaction count when first used and then periodically, and counted locally in
+// between. It is safe for concurrent use, and may be shared via TxOpts.Nonces.
+type NonceManager struct {
+	client         Client
+	resyncInterval time.Duration
+
+	mu       sync.Mutex
+	accounts map[common.Address]*nonceAccount
+}
+
+type nonceAccount struct {
+	mu       sync.Mutex
+	synced   time.Time // zero until the first sync
+	next     uint64
+	inFlight map[uint64]struct{}
+	released []uint64 // sorted nonces below next which were handed out but not used
+}
+
+// NewNonceManager returns a NonceManager which syncs nonces from client.
+func NewNonceManager(client Client) *NonceManager {
+	return &NonceManager{
+		client:         client,
+		resyncInterval: nonceResyncInterval,
+		accounts:       make(map[common.Address]*nonceAccount),
+	}
+}
+
+func (m *NonceManager) account(address common.Address) *nonceAccount {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a, ok := m.accounts[address]
+	if !ok {
+		a = &nonceAccount{inFlight: make(map[uint64]struct{})}
+		m.accounts[address] = a
+	}
+	return a
+}
+
+// Next reserves and returns the next nonce for address. The nonce is tracked as in-flight
+// until it is observed as used by a resync. If it ends up unused, it must be given back
+// with Release so that it doesn't leave a gap.
+func (m *NonceManager) Next(ctx context.Context, address common.Address) (uint64, error) {
+	a := m.account(address)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if time.Since(a.synced) > m.resyncInterval {
+		// Only the first sync is required. Later ones fall back to the local count.
+		if err := m.sync(ctx, address, a); err != nil && a.synced.IsZero() {
+			return 0, err
+		}
+	}
+	var nonce uint64
+	if len(a.released) > 0 {
+		nonce, a.released = a.released[0], a.released[1:]
+	} else {
+		nonce = a.next
+		a.next++
+	}
+	a.inFlight[nonce] = struct{}{}
+	return nonce, nil
+}
+
+// Release gives back a nonce reserved by Next which was not used, e.g. because the
+// transaction failed to sign or was rejected by the node.
+func (m *NonceManager) Release(address common.Address, nonce uint64) {
+	a := m.account(address)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.inFlight[nonce]; !ok {
+		return
+	}
+	delete(a.inFlight, nonce)
+	if nonce+1 == a.next {
+		a.next--
+		return
+	}
+	i := sort.Search(len(a.released), func(i int) bool { return a.released[i] >= nonce })
+	a.released = append(a.released, 0)
+	copy(a.released[i+1:], a.released[i:])
+	a.released[i] = nonce
+}
+
+// Resync refreshes the state for address from the node's pending transaction count.
+// Nonces below the count are no longer in-flight, and the next nonce is advanced past
+// the count if it had fallen behind, e.g. due to transactions sent by another process.
+// With nothing in-flight, the next nonce is the count, even if that is lower, e.g. after
+// pending transactions were dropped by the node.
+func (m *NonceManager) Resync(ctx context.Context, address common.Address) error {
+	a := m.account(address)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return m.sync(ctx, address, a)
+}
+
+// sync must be called with a.mu held.
+func (m *NonceManager) sync(ctx context.Context, address common.Address, a *nonceAccount) error {
+	pending, err := m.client.GetPendingTransactionCount(ctx, address)
+	if err != nil {
+		return err
+	}
+	for n := range a.inFlight {
+		if n < pending {
+			delete(a.inFlight, n)
+		}
+	}
+	i := sort.Search(len(a.released), func(i int) bool { return a.released[i] >= pending })
+	a.released = a.released[i:]
+	if len(a.inFlight) == 0 || a.next < pending {
+		a.next = pending
+		a.released = nil
+	}
+	a.synced = time.Now()
+	return nil
+}
+
+// InFlight returns the nonces for address which have been handed out but not yet observed
+// as mined, in ascending order.
+func (m *NonceManager) InFlight(address common.Address) []uint64 {
+	a := m.account(address)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	nonces := make([]uint64, 0, len(a.inFlight))
+	for n := range a.inFlight {
+		nonces = append(nonces, n)
+	}
+	sort.Slice(nonces, func(i, j int) bool { return nonces[i] < nonces[j] })
+	return nonces
+}
+
+// isRejected reports whether err from sending a transaction means that the node did not
+// accept it, so its nonce is unused. Connection failures and timeouts are ambiguous, since
+// the node may have received the transaction before the failure.
+func isRejected(ctx context.Context, err error) bool {
+	if isUnsent(err) {
+		return true
+	}
+	return ctx.Err() == nil && !isFailover(err)
+}
+
+// isNonceTooLow reports whether err is the node rejecting a transaction with a nonce
+// which has already been used.
+func isNonceTooLow(err error) bool {
+	return strings.Contains(err.Error(), "nonce too low")
+}
+
+// isKnownTransaction reports whether err is the node rejecting a transaction which it
+// already has, i.e. it was sent before.
+func isKnownTransaction(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "known transaction") || strings.Contains(msg, "already known")
+}
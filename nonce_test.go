@@ -0,0 +1,212 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/core/types"
+	"github.com/gochain-io/gochain/v3/rlp"
+)
+
+// fakeNonceClient tracks the account nonce like a node, rejecting used nonces.
+// Unimplemented Client methods panic.
+type fakeNonceClient struct {
+	Client
+
+	mu      sync.Mutex
+	pending uint64
+	sent    []uint64
+	err     error // returned by SendRawTransaction, without sending
+}
+
+func (c *fakeNonceClient) GetPendingTransactionCount(ctx context.Context, account common.Address) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pending, nil
+}
+
+func (c *fakeNonceClient) GetGasPrice(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(1), nil
+}
+
+func (c *fakeNonceClient) SendRawTransaction(ctx context.Context, raw []byte) error {
+	var tx types.Transaction
+	if err := rlp.DecodeBytes(raw, &tx); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	if tx.Nonce() < c.pending {
+		return errors.New("nonce too low")
+	}
+	c.sent = append(c.sent, tx.Nonce())
+	if tx.Nonce() == c.pending {
+		c.pending++
+	}
+	return nil
+}
+
+func TestNonceManagerConcurrent(t *testing.T) {
+	ctx := context.Background()
+	m := NewNonceManager(&fakeNonceClient{pending: 5})
+	var addr common.Address
+	const count = 50
+	nonces := make(chan uint64, count)
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, err := m.Next(ctx, addr)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			nonces <- n
+		}()
+	}
+	wg.Wait()
+	close(nonces)
+	seen := make(map[uint64]bool)
+	for n := range nonces {
+		if n < 5 || n >= 5+count || seen[n] {
+			t.Errorf("unexpected nonce %d", n)
+		}
+		seen[n] = true
+	}
+	if got := len(m.InFlight(addr)); got != count {
+		t.Errorf("expected %d in-flight but got %d", count, got)
+	}
+}
+
+func TestNonceManagerRelease(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeNonceClient{}
+	m := NewNonceManager(client)
+	var addr common.Address
+	for i := uint64(0); i < 3; i++ {
+		if n, err := m.Next(ctx, addr); err != nil {
+			t.Fatal(err)
+		} else if n != i {
+			t.Fatalf("expected nonce %d but got %d", i, n)
+		}
+	}
+	m.Release(addr, 1)
+	if n, _ := m.Next(ctx, addr); n != 1 {
+		t.Errorf("expected released nonce 1 to be reused, but got %d", n)
+	}
+	m.Release(addr, 2)
+	if n, _ := m.Next(ctx, addr); n != 2 {
+		t.Errorf("expected released nonce 2 to be reused, but got %d", n)
+	}
+	client.pending = 2
+	if err := m.Resync(ctx, addr); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.InFlight(addr); len(got) != 1 || got[0] != 2 {
+		t.Errorf("expected only nonce 2 in-flight after resync, but got %v", got)
+	}
+}
+
+func TestNonceManagerSync(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeNonceClient{}
+	m := NewNonceManager(client)
+	var addr common.Address
+	if n, _ := m.Next(ctx, addr); n != 0 {
+		t.Fatalf("expected nonce 0 but got %d", n)
+	}
+	client.pending = 1
+	if err := m.Resync(ctx, addr); err != nil {
+		t.Fatal(err)
+	}
+	// The node drops the pending transaction, so its nonce is used again.
+	client.pending = 0
+	if err := m.Resync(ctx, addr); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := m.Next(ctx, addr); n != 0 {
+		t.Errorf("expected nonce 0 after the transaction was dropped, but got %d", n)
+	}
+
+	// Next counts locally until the resync interval passes.
+	client.pending = 3
+	if n, _ := m.Next(ctx, addr); n != 1 {
+		t.Errorf("expected local nonce 1 but got %d", n)
+	}
+	m.resyncInterval = 0
+	if n, _ := m.Next(ctx, addr); n != 3 {
+		t.Errorf("expected resynced nonce 3 but got %d", n)
+	}
+	if got := m.InFlight(addr); len(got) != 1 || got[0] != 3 {
+		t.Errorf("expected only nonce 3 in-flight after resync, but got %v", got)
+	}
+}
+
+func TestSendKeepsAmbiguousNonce(t *testing.T) {
+	ctx := context.Background()
+	acct, err := CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := NewAccountSigner(acct)
+	for _, test := range []struct {
+		name string
+		err  error
+		exp  uint64 // the nonce of the next transaction
+	}{
+		{"rejected", errors.New("insufficient funds for gas * price + value"), 0},
+		{"unsent", errors.New("429 Too Many Requests"), 0},
+		{"connection reset", errConnReset, 1},
+		{"timeout", context.DeadlineExceeded, 1},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			client := &fakeNonceClient{err: test.err}
+			opts := &TxOpts{ChainID: big.NewInt(1), GasLimit: 21000, Nonces: NewNonceManager(client)}
+			if _, err := Send(ctx, client, signer, opts, common.Address{}, big.NewInt(1)); err == nil {
+				t.Fatal("expected error")
+			}
+			client.err = nil
+			tx, err := Send(ctx, client, signer, opts, common.Address{}, big.NewInt(1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tx.Nonce != test.exp {
+				t.Errorf("expected nonce %d but got %d", test.exp, tx.Nonce)
+			}
+		})
+	}
+}
+
+func TestSendResyncsNonce(t *testing.T) {
+	ctx := context.Background()
+	acct, err := CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := NewAccountSigner(acct)
+	client := &fakeNonceClient{}
+	opts := &TxOpts{ChainID: big.NewInt(1), GasLimit: 21000, Nonces: NewNonceManager(client)}
+	if _, err := Send(ctx, client, signer, opts, common.Address{}, big.NewInt(1)); err != nil {
+		t.Fatal(err)
+	}
+	// Another process sends two transactions from the same account.
+	client.pending += 2
+	tx, err := Send(ctx, client, signer, opts, common.Address{}, big.NewInt(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Nonce != 3 {
+		t.Errorf("expected resynced nonce 3 but got %d", tx.Nonce)
+	}
+	if len(client.sent) != 2 {
+		t.Errorf("expected 2 sent transactions but got %v", client.sent)
+	}
+}
@@ -0,0 +1,103 @@
+package web3
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/core/state"
+	"github.com/gochain-io/gochain/v3/core/types"
+	"github.com/gochain-io/gochain/v3/crypto"
+	"github.com/gochain-io/gochain/v3/rlp"
+	"github.com/gochain-io/gochain/v3/trie"
+)
+
+// emptyCodeHash is the code hash of accounts without code.
+var emptyCodeHash = crypto.Keccak256Hash(nil)
+
+// VerifyProof checks the Merkle proofs in p against stateRoot, usually the StateRoot of
+// the block it was requested for. It returns an error unless the account fields and all
+// storage values in p are proven, including proofs that an account or slot is empty.
+func VerifyProof(stateRoot common.Hash, p *AccountProof) error {
+	value, _, err := trie.VerifyProof(stateRoot, crypto.Keccak256(p.Address.Bytes()), newProofDB(p.AccountProof))
+	if err != nil {
+		return fmt.Errorf("invalid account proof: %v", err)
+	}
+	if value == nil {
+		// Absent accounts have no storage, but nodes may report either form of empty hash.
+		if p.Nonce != 0 || p.Balance.Sign() != 0 ||
+			(p.CodeHash != emptyCodeHash && p.CodeHash != (common.Hash{})) ||
+			(p.StorageHash != types.EmptyRootHash && p.StorageHash != (common.Hash{})) {
+			return errors.New("account proof shows no account, but account fields are not empty")
+		}
+		for _, s := range p.StorageProof {
+			if s.Value.Sign() != 0 {
+				return fmt.Errorf("account proof shows no account, but slot %s is not empty", s.Key.Hex())
+			}
+		}
+		return nil
+	}
+	acct := state.Account{Nonce: p.Nonce, Balance: p.Balance, Root: p.StorageHash, CodeHash: p.CodeHash}
+	want, err := acct.MarshalRLP()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(value, want) {
+		return errors.New("account proof does not match account fields")
+	}
+	for _, s := range p.StorageProof {
+		if err := verifyStorageProof(p.StorageHash, s); err != nil {
+			return fmt.Errorf("invalid proof for slot %s: %v", s.Key.Hex(), err)
+		}
+	}
+	return nil
+}
+
+func verifyStorageProof(storageRoot common.Hash, s StorageProof) error {
+	var value []byte
+	if storageRoot != types.EmptyRootHash {
+		var err error
+		value, _, err = trie.VerifyProof(storageRoot, crypto.Keccak256(s.Key.Bytes()), newProofDB(s.Proof))
+		if err != nil {
+			return err
+		}
+	}
+	got := new(big.Int)
+	if value != nil {
+		// Slot values are stored as RLP strings, without leading zeros.
+		var b []byte
+		if err := rlp.DecodeBytes(value, &b); err != nil {
+			return fmt.Errorf("invalid slot value: %v", err)
+		}
+		got.SetBytes(b)
+	}
+	if got.Cmp(s.Value) != 0 {
+		return fmt.Errorf("proof shows value %s, not %s", got, s.Value)
+	}
+	return nil
+}
+
+// proofDB serves trie nodes by hash, for trie.VerifyProof.
+type proofDB map[common.Hash][]byte
+
+func newProofDB(nodes [][]byte) proofDB {
+	db := make(proofDB, len(nodes))
+	for _, n := range nodes {
+		db[crypto.Keccak256Hash(n)] = n
+	}
+	return db
+}
+
+func (db proofDB) Get(key []byte) ([]byte, error) {
+	if n, ok := db[common.BytesToHash(key)]; ok {
+		return n, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (db proofDB) Has(key []byte) (bool, error) {
+	_, ok := db[common.BytesToHash(key)]
+	return ok, nil
+}
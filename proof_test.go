@@ -0,0 +1,86 @@
+package web3
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/gochain-io/gochain/v3/common"
+)
+
+// storeContractBin deploys a contract without code, which stores 42 in slot 0.
+const storeContractBin = "0x602a60005500"
+
+func TestVerifyProof(t *testing.T) {
+	ctx := context.Background()
+	sim, err := NewSimulatedClient(SimulatedOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Close()
+	tx, err := DeployContract(ctx, sim, NewAccountSigner(sim.Accounts()[0]), nil, storeContractBin, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt, err := WaitForReceipt(ctx, sim, tx.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := receipt.ContractAddress
+	slots := []common.Hash{{}, common.BigToHash(big.NewInt(1))}
+
+	if v, err := sim.GetStorageAt(ctx, addr, slots[0], nil); err != nil {
+		t.Fatal(err)
+	} else if v.Big().Int64() != 42 {
+		t.Fatalf("expected 42 in slot 0 but got %s", v.Hex())
+	}
+	block, err := sim.GetBlockByNumber(ctx, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := sim.GetProof(ctx, addr, slots, block.Number)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyProof(block.StateRoot, p); err != nil {
+		t.Fatal(err)
+	}
+	if v := p.StorageProof[0].Value; v.Int64() != 42 {
+		t.Errorf("expected 42 in slot 0 proof but got %s", v)
+	}
+
+	// Proofs survive JSON encoding, as returned by nodes.
+	b, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded AccountProof
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyProof(block.StateRoot, &decoded); err != nil {
+		t.Errorf("decoded proof: %v", err)
+	}
+
+	decoded.StorageProof[0].Value = big.NewInt(43)
+	if err := VerifyProof(block.StateRoot, &decoded); err == nil {
+		t.Error("expected error for wrong slot value")
+	}
+	p.Balance = big.NewInt(1)
+	if err := VerifyProof(block.StateRoot, p); err == nil {
+		t.Error("expected error for wrong balance")
+	}
+
+	absent, err := sim.GetProof(ctx, common.HexToAddress("0x1234"), slots, block.Number)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyProof(block.StateRoot, absent); err != nil {
+		t.Errorf("absent account: %v", err)
+	}
+	absent.Nonce = 1
+	if err := VerifyProof(block.StateRoot, absent); err == nil {
+		t.Error("expected error for absent account with nonce")
+	}
+}
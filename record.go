@@ -0,0 +1,278 @@
+package web3
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/gochain-io/gochain/v3/rpc"
+)
+
+// fixtureEntry is a recorded JSON-RPC exchange. Batches are recorded as single entries,
+// with arrays for the request and response.
+type fixtureEntry struct {
+	// Comment optionally describes the entry, e.g. to mark fixtures written by hand.
+	Comment  string          `json:"comment,omitempty"`
+	Request  json.RawMessage `json:"request"`
+	Response json.RawMessage `json:"response"`
+}
+
+// rpcMessage holds the parts of a JSON-RPC message used for matching.
+type rpcMessage struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// Recorder is an http.RoundTripper which records the JSON-RPC traffic passing through
+// Transport, so that it can be saved as a fixture for a Replayer.
+type Recorder struct {
+	// Transport makes the actual requests. Default: http.DefaultTransport.
+	Transport http.RoundTripper
+
+	mu      sync.Mutex
+	entries []fixtureEntry
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	}
+	t := r.Transport
+	if t == nil {
+		t = http.DefaultTransport
+	}
+	resp, err := t.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+	// Only successful responses are recorded. Failures are passed on to the caller.
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		r.mu.Lock()
+		r.entries = append(r.entries, fixtureEntry{Request: reqBody, Response: respBody})
+		r.mu.Unlock()
+	}
+	return resp, nil
+}
+
+// Save writes the recorded traffic to the fixture file at path.
+func (r *Recorder) Save(path string) error {
+	r.mu.Lock()
+	b, err := json.MarshalIndent(r.entries, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(b, '\n'), 0644)
+}
+
+// Replayer is an http.RoundTripper which serves JSON-RPC responses from a fixture file
+// saved by a Recorder, without any network access. Requests are matched by method and
+// params, ignoring ids. When the same request was recorded more than once, the responses
+// are replayed in the recorded order, and the last one is repeated.
+type Replayer struct {
+	mu      sync.Mutex
+	entries map[string][]fixtureEntry
+}
+
+// LoadReplayer returns a Replayer for the fixture file at path.
+func LoadReplayer(path string) (*Replayer, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []fixtureEntry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture %s: %v", path, err)
+	}
+	r := &Replayer{entries: make(map[string][]fixtureEntry)}
+	for _, e := range entries {
+		_, key, err := parseRPCMessages(e.Request)
+		if err != nil {
+			return nil, fmt.Errorf("invalid request in fixture %s: %v", path, err)
+		}
+		r.entries[key] = append(r.entries[key], e)
+	}
+	return r, nil
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil {
+		return nil, errors.New("missing request body")
+	}
+	reqBody, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	msgs, key, err := parseRPCMessages(reqBody)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	queue := r.entries[key]
+	if len(queue) == 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("no recorded response for request %s", reqBody)
+	}
+	e := queue[0]
+	if len(queue) > 1 {
+		r.entries[key] = queue[1:]
+	}
+	r.mu.Unlock()
+
+	recorded, _, err := parseRPCMessages(e.Request)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := replaceIDs(e.Response, recorded, msgs)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/json"}},
+		Body:          ioutil.NopCloser(bytes.NewReader(respBody)),
+		ContentLength: int64(len(respBody)),
+		Request:       req,
+	}, nil
+}
+
+// parseRPCMessages parses a single or batch JSON-RPC request, and returns a key which
+// identifies it independently of ids and formatting.
+func parseRPCMessages(b []byte) ([]rpcMessage, string, error) {
+	var msgs []rpcMessage
+	b = bytes.TrimSpace(b)
+	batch := len(b) > 0 && b[0] == '['
+	if batch {
+		if err := json.Unmarshal(b, &msgs); err != nil {
+			return nil, "", err
+		}
+	} else {
+		var msg rpcMessage
+		if err := json.Unmarshal(b, &msg); err != nil {
+			return nil, "", err
+		}
+		msgs = []rpcMessage{msg}
+	}
+	type keyElem struct {
+		Method string      `json:"method"`
+		Params interface{} `json:"params"`
+	}
+	elems := make([]keyElem, len(msgs))
+	for i, msg := range msgs {
+		elems[i].Method = msg.Method
+		if len(msg.Params) > 0 {
+			// Round trip through interface{} to normalize formatting and key order.
+			if err := json.Unmarshal(msg.Params, &elems[i].Params); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+	var key []byte
+	var err error
+	if batch {
+		key, err = json.Marshal(elems)
+	} else {
+		key, err = json.Marshal(elems[0])
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return msgs, string(key), nil
+}
+
+// replaceIDs rewrites the ids in the recorded response from those of the recorded
+// request to those of the matching current request.
+func replaceIDs(response json.RawMessage, recorded, current []rpcMessage) ([]byte, error) {
+	ids := make(map[string]json.RawMessage, len(recorded))
+	for i := range recorded {
+		ids[string(recorded[i].ID)] = current[i].ID
+	}
+	replace := func(m map[string]json.RawMessage) {
+		if id, ok := ids[string(m["id"])]; ok {
+			m["id"] = id
+		}
+	}
+	if b := bytes.TrimSpace(response); len(b) > 0 && b[0] == '[' {
+		var ms []map[string]json.RawMessage
+		if err := json.Unmarshal(b, &ms); err != nil {
+			return nil, err
+		}
+		for _, m := range ms {
+			replace(m)
+		}
+		return json.Marshal(ms)
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(response, &m); err != nil {
+		return nil, err
+	}
+	replace(m)
+	return json.Marshal(m)
+}
+
+// DialRecord returns a new client backed by dialing the HTTP(S) url, which records all
+// requests and responses, and saves them to the fixture file at path when closed.
+func DialRecord(url, path string, opts ...ClientOption) (Client, error) {
+	rec := &Recorder{}
+	r, err := rpc.DialHTTPWithClient(url, &http.Client{Transport: rec})
+	if err != nil {
+		return nil, err
+	}
+	return &recordClient{Client: NewClient(r, opts...), rec: rec, path: path}, nil
+}
+
+type recordClient struct {
+	Client
+	rec  *Recorder
+	path string
+}
+
+func (c *recordClient) Close() {
+	c.Client.Close()
+	if err := c.rec.Save(c.path); err != nil {
+		log.Printf("Failed to save fixture %s: %v\n", c.path, err)
+	}
+}
+
+// DialReplay returns a new client which replays the responses from the fixture file at
+// path, as saved by DialRecord, without any network access.
+func DialReplay(path string, opts ...ClientOption) (Client, error) {
+	rep, err := LoadReplayer(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := rpc.DialHTTPWithClient("http://replay", &http.Client{Transport: rep})
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(r, opts...), nil
+}
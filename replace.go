@@ -0,0 +1,114 @@
+package web3
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/core/types"
+	"github.com/gochain-io/gochain/v3/params"
+)
+
+// ReplacementPriceBump is the minimum gas price increase, in percent, required by the
+// node to replace a pending transaction with another of the same nonce.
+const ReplacementPriceBump = 10
+
+// SpeedUpTransaction replaces the pending transaction hash with an identical one at a
+// higher gas price. When gasPrice is nil, the price is bumped just enough to meet the
+// replacement threshold, or to the current network gas price if that is higher.
+func SpeedUpTransaction(ctx context.Context, client Client, signer Signer, opts *TxOpts, hash common.Hash, gasPrice *big.Int) (*Transaction, error) {
+	tx, gasPrice, err := replaceable(ctx, client, signer, hash, gasPrice)
+	if err != nil {
+		return nil, err
+	}
+	return signAndSend(ctx, client, signer, replacementOpts(opts, tx.Nonce), func(nonce uint64) *types.Transaction {
+		if tx.To == nil {
+			return types.NewContractCreation(nonce, tx.Value, tx.GasLimit, gasPrice, tx.Input)
+		}
+		return types.NewTransaction(nonce, *tx.To, tx.Value, tx.GasLimit, gasPrice, tx.Input)
+	})
+}
+
+// CancelTransaction replaces the pending transaction hash with a zero value transfer from
+// the sender to itself, at a higher gas price. gasPrice is as for SpeedUpTransaction.
+func CancelTransaction(ctx context.Context, client Client, signer Signer, opts *TxOpts, hash common.Hash, gasPrice *big.Int) (*Transaction, error) {
+	tx, gasPrice, err := replaceable(ctx, client, signer, hash, gasPrice)
+	if err != nil {
+		return nil, err
+	}
+	return signAndSend(ctx, client, signer, replacementOpts(opts, tx.Nonce), func(nonce uint64) *types.Transaction {
+		return types.NewTransaction(nonce, tx.From, new(big.Int), params.TxGas, gasPrice, nil)
+	})
+}
+
+// replaceable returns the pending transaction hash, and the gas price to replace it with.
+func replaceable(ctx context.Context, client Client, signer Signer, hash common.Hash, gasPrice *big.Int) (*Transaction, *big.Int, error) {
+	tx, err := client.GetTransactionByHash(ctx, hash)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot get transaction: %v", err)
+	}
+	if tx.BlockNumber != nil {
+		return nil, nil, fmt.Errorf("transaction %s is already mined in block %s", hash.Hex(), tx.BlockNumber)
+	}
+	if tx.From != signer.Address() {
+		return nil, nil, fmt.Errorf("transaction %s was sent from %s, not %s", hash.Hex(), tx.From.Hex(), signer.Address().Hex())
+	}
+	min := ReplacementGasPrice(tx.GasPrice)
+	if gasPrice == nil {
+		gasPrice, err = client.GetGasPrice(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot get gas price: %v", err)
+		}
+		if gasPrice.Cmp(min) < 0 {
+			gasPrice = min
+		}
+	} else if gasPrice.Cmp(min) < 0 {
+		return nil, nil, fmt.Errorf("gas price %s is below the replacement threshold of %s", gasPrice, min)
+	}
+	return tx, gasPrice, nil
+}
+
+func replacementOpts(opts *TxOpts, nonce uint64) *TxOpts {
+	var o TxOpts
+	if opts != nil {
+		o = *opts
+	}
+	o.Nonce = &nonce
+	return &o
+}
+
+// ReplacementGasPrice returns the minimum gas price required to replace a pending
+// transaction with gas price old.
+func ReplacementGasPrice(old *big.Int) *big.Int {
+	p := new(big.Int).Mul(old, big.NewInt(100+ReplacementPriceBump))
+	p.Add(p, big.NewInt(99))
+	p.Div(p, big.NewInt(100))
+	if p.Cmp(old) <= 0 {
+		p.Add(old, common.Big1)
+	}
+	return p
+}
+
+// WaitForAnyReceipt polls for the receipts of hashes until one is available, or ctx is
+// cancelled. This is useful after replacing a transaction, when either the original or
+// the replacement may be mined.
+func WaitForAnyReceipt(ctx context.Context, client Client, hashes ...common.Hash) (*Receipt, error) {
+	for {
+		for _, hash := range hashes {
+			receipt, err := client.GetTransactionReceipt(ctx, hash)
+			if err == nil {
+				return receipt, nil
+			}
+			if err != NotFoundErr {
+				return nil, err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
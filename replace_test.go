@@ -0,0 +1,182 @@
+package web3
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/core/types"
+	"github.com/gochain-io/gochain/v3/params"
+)
+
+func TestReplacementGasPrice(t *testing.T) {
+	for _, test := range []struct {
+		old, exp int64
+	}{
+		{0, 1},
+		{1, 2},
+		{9, 10},
+		{10, 11},
+		{11, 13},
+		{100, 110},
+		{101, 112},
+		{1e9, 1.1e9},
+		{1e9 + 1, 1.1e9 + 2},
+	} {
+		if got := ReplacementGasPrice(big.NewInt(test.old)); got.Int64() != test.exp {
+			t.Errorf("%d: expected %d but got %s", test.old, test.exp, got)
+		}
+	}
+}
+
+// newReplaceMock returns a txMock which reports the transaction pending.
+func newReplaceMock(pending *Transaction) *txMock {
+	m := newTxMock(60, 0)
+	m.GetTransactionByHashFunc = func(ctx context.Context, hash common.Hash) (*Transaction, error) {
+		if hash != pending.Hash {
+			return nil, NotFoundErr
+		}
+		return pending, nil
+	}
+	return m
+}
+
+func TestReplaceTransaction(t *testing.T) {
+	ctx := context.Background()
+	acct, err := ParsePrivateKey(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := NewAccountSigner(acct)
+	to := common.HexToAddress("0x0000000000000000000000000000000000000002")
+	pending := &Transaction{
+		Nonce:    7,
+		GasPrice: big.NewInt(2e9),
+		GasLimit: 50000,
+		To:       &to,
+		Value:    big.NewInt(100),
+		Input:    []byte{1, 2, 3, 4},
+		From:     signer.Address(),
+		Hash:     common.HexToHash("0x01"),
+	}
+	check := func(t *testing.T, m *txMock, gasPrice int64) *types.Transaction {
+		t.Helper()
+		if len(m.sent) != 1 {
+			t.Fatalf("expected 1 transaction sent but got %d", len(m.sent))
+		}
+		sent := m.sent[0]
+		if sent.Nonce() != pending.Nonce {
+			t.Errorf("expected nonce %d but got %d", pending.Nonce, sent.Nonce())
+		}
+		if sent.GasPrice().Int64() != gasPrice {
+			t.Errorf("expected gas price %d but got %s", gasPrice, sent.GasPrice())
+		}
+		from, err := types.Sender(types.NewEIP155Signer(big.NewInt(60)), sent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if from != signer.Address() {
+			t.Errorf("expected sender %s but got %s", signer.Address().Hex(), from.Hex())
+		}
+		return sent
+	}
+
+	t.Run("speedup", func(t *testing.T) {
+		m := newReplaceMock(pending)
+		if _, err := SpeedUpTransaction(ctx, m, signer, nil, pending.Hash, nil); err != nil {
+			t.Fatal(err)
+		}
+		// The network price of 1 gwei is below the replacement threshold.
+		sent := check(t, m, 2.2e9)
+		if *sent.To() != to || sent.Value().Cmp(pending.Value) != 0 || sent.Gas() != pending.GasLimit || string(sent.Data()) != string(pending.Input) {
+			t.Errorf("expected an identical transaction but got %v", sent)
+		}
+	})
+	t.Run("speedup network price", func(t *testing.T) {
+		m := newReplaceMock(pending)
+		m.GetGasPriceFunc = func(ctx context.Context) (*big.Int, error) {
+			return big.NewInt(5e9), nil
+		}
+		if _, err := SpeedUpTransaction(ctx, m, signer, nil, pending.Hash, nil); err != nil {
+			t.Fatal(err)
+		}
+		check(t, m, 5e9)
+	})
+	t.Run("cancel", func(t *testing.T) {
+		m := newReplaceMock(pending)
+		if _, err := CancelTransaction(ctx, m, signer, nil, pending.Hash, big.NewInt(3e9)); err != nil {
+			t.Fatal(err)
+		}
+		sent := check(t, m, 3e9)
+		if *sent.To() != signer.Address() {
+			t.Errorf("expected a transfer to %s but got %s", signer.Address().Hex(), sent.To().Hex())
+		}
+		if sent.Value().Sign() != 0 {
+			t.Errorf("expected zero value but got %s", sent.Value())
+		}
+		if sent.Gas() != params.TxGas || len(sent.Data()) != 0 {
+			t.Errorf("expected a plain transfer but got gas %d and data %x", sent.Gas(), sent.Data())
+		}
+	})
+
+	for _, test := range []struct {
+		name     string
+		tx       Transaction
+		gasPrice *big.Int
+	}{
+		{"below threshold", *pending, big.NewInt(2.1e9)},
+		{"mined", Transaction{From: pending.From, GasPrice: pending.GasPrice, BlockNumber: big.NewInt(1)}, nil},
+		{"other sender", Transaction{From: to, GasPrice: pending.GasPrice}, nil},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			tx := test.tx
+			tx.Hash = pending.Hash
+			m := newReplaceMock(&tx)
+			if _, err := CancelTransaction(ctx, m, signer, nil, pending.Hash, test.gasPrice); err == nil {
+				t.Error("expected error")
+			}
+			if len(m.sent) != 0 {
+				t.Errorf("expected no transaction sent but got %d", len(m.sent))
+			}
+		})
+	}
+}
+
+func TestWaitForAnyReceipt(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	original, replacement := common.HexToHash("0x01"), common.HexToHash("0x02")
+	var polls int
+	var m MockClient
+	m.GetTransactionReceiptFunc = func(ctx context.Context, hash common.Hash) (*Receipt, error) {
+		if hash == original {
+			polls++
+		}
+		// The replacement is mined after the first poll.
+		if hash == replacement && polls > 1 {
+			return &Receipt{TxHash: hash}, nil
+		}
+		return nil, NotFoundErr
+	}
+	r, err := WaitForAnyReceipt(ctx, &m, original, replacement)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.TxHash != replacement {
+		t.Errorf("expected receipt of %s but got %s", replacement.Hex(), r.TxHash.Hex())
+	}
+	if polls != 2 {
+		t.Errorf("expected 2 polls but got %d", polls)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	m.GetTransactionReceiptFunc = func(ctx context.Context, hash common.Hash) (*Receipt, error) {
+		return nil, NotFoundErr
+	}
+	if _, err := WaitForAnyReceipt(ctx, &m, original); err != context.DeadlineExceeded {
+		t.Errorf("expected %v but got %v", context.DeadlineExceeded, err)
+	}
+}
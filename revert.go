@@ -0,0 +1,198 @@
+package web3
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/gochain-io/gochain/v3/accounts/abi"
+	"github.com/gochain-io/gochain/v3/common/hexutil"
+	"github.com/gochain-io/gochain/v3/core/types"
+)
+
+// builtinErrors declares the errors emitted by solidity itself: Error(string) for revert
+// and require, and Panic(uint256) for failed assertions, overflows, etc.
+var builtinErrors = mustParseABIErrors(`[
+	{"type":"error","name":"Error","inputs":[{"name":"message","type":"string"}]},
+	{"type":"error","name":"Panic","inputs":[{"name":"code","type":"uint256"}]}
+]`)
+
+// RevertError is returned for calls and transactions which were reverted.
+type RevertError struct {
+	Reason string // the decoded reason, or empty if unknown
+	Data   []byte // the raw revert data, if any
+}
+
+func (e *RevertError) Error() string {
+	switch {
+	case e.Reason != "":
+		return "execution reverted: " + e.Reason
+	case len(e.Data) > 0:
+		return "execution reverted: " + hexutil.Encode(e.Data)
+	}
+	return "execution reverted"
+}
+
+// ParseABIErrors returns the custom errors declared in the ABI JSON read from r, keyed by
+// name. These are not included in abi.ABI. Each error is represented as an abi.Method, so
+// MethodID returns the error selector.
+func ParseABIErrors(r io.Reader) (map[string]abi.Method, error) {
+	var fields []map[string]interface{}
+	if err := json.NewDecoder(r).Decode(&fields); err != nil {
+		return nil, err
+	}
+	var errs []map[string]interface{}
+	for _, f := range fields {
+		if f["type"] == "error" {
+			f["type"] = "function"
+			errs = append(errs, f)
+		}
+	}
+	if len(errs) == 0 {
+		return map[string]abi.Method{}, nil
+	}
+	b, err := json.Marshal(errs)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := parseABI(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	return parsed.Methods, nil
+}
+
+func mustParseABIErrors(s string) map[string]abi.Method {
+	errs, err := ParseABIErrors(strings.NewReader(s))
+	if err != nil {
+		panic(err)
+	}
+	return errs
+}
+
+// DecodeRevert decodes revert data as Error(string), Panic(uint256), or one of the custom
+// errors customErrs, which may be nil. It returns false if data matches none of them.
+func DecodeRevert(data []byte, customErrs map[string]abi.Method) (string, bool) {
+	if len(data) < 4 {
+		return "", false
+	}
+	if m, ok := findError(builtinErrors, data[:4]); ok {
+		values, err := unpackValues(m.Inputs, data[4:])
+		if err != nil {
+			return "", false
+		}
+		if m.Name == "Error" {
+			return values[0].(string), true
+		}
+		return fmt.Sprintf("panic: 0x%x", values[0].(*big.Int)), true
+	}
+	m, ok := findError(customErrs, data[:4])
+	if !ok {
+		return "", false
+	}
+	values, err := unpackValues(m.Inputs, data[4:])
+	if err != nil {
+		return "", false
+	}
+	args := make([]string, len(values))
+	for i, v := range values {
+		args[i] = fmt.Sprint(FormatValue(m.Inputs[i].Type, v))
+	}
+	return m.Name + "(" + strings.Join(args, ", ") + ")", true
+}
+
+func findError(errs map[string]abi.Method, id []byte) (abi.Method, bool) {
+	for _, m := range errs {
+		if bytes.Equal(MethodID(m), id) {
+			return m, true
+		}
+	}
+	return abi.Method{}, false
+}
+
+// isRevertData reports whether the result of a call is revert data rather than return
+// values, which older nodes return without an error. ABI encoded return values are always
+// a multiple of 32 bytes, while revert data is prefixed with a 4 byte selector.
+func isRevertData(data []byte) bool {
+	return len(data)%32 == 4
+}
+
+// revertErrorFrom converts err to a *RevertError if the node reported a revert, and
+// otherwise returns err unchanged.
+func revertErrorFrom(err error) error {
+	if !strings.HasPrefix(err.Error(), "execution reverted") {
+		return err
+	}
+	rerr := &RevertError{Data: errorData(err)}
+	if reason, ok := DecodeRevert(rerr.Data, nil); ok {
+		rerr.Reason = reason
+	} else if msg := strings.TrimPrefix(err.Error(), "execution reverted: "); msg != err.Error() {
+		rerr.Reason = msg
+	}
+	return rerr
+}
+
+// errorData returns the hex data field of the JSON-RPC error err, or nil if it has none.
+// The rpc package does not expose the field, but its error type marshals back to the
+// JSON-RPC error object.
+func errorData(err error) []byte {
+	b, jerr := json.Marshal(err)
+	if jerr != nil {
+		return nil
+	}
+	var e struct {
+		Data interface{} `json:"data"`
+	}
+	if json.Unmarshal(b, &e) != nil {
+		return nil
+	}
+	s, ok := e.Data.(string)
+	if !ok {
+		return nil
+	}
+	data, _ := hexutil.Decode(s)
+	return data
+}
+
+// RevertReason recovers the reason a mined transaction failed, by replaying it with
+// eth_call at the block of its receipt. The reason is decoded with customErrs, which may
+// be nil. Since the state may differ from when the transaction executed, the reason is
+// not guaranteed to be accurate, and is empty if it cannot be determined.
+func RevertReason(ctx context.Context, client Client, receipt *Receipt, customErrs map[string]abi.Method) (string, error) {
+	if receipt.Status != types.ReceiptStatusFailed {
+		return "", fmt.Errorf("transaction %s did not fail", receipt.TxHash.Hex())
+	}
+	tx, err := client.GetTransactionByHash(ctx, receipt.TxHash)
+	if err != nil {
+		return "", fmt.Errorf("cannot get transaction: %v", err)
+	}
+	msg := CallMsg{
+		From:        tx.From,
+		To:          tx.To,
+		Gas:         tx.GasLimit,
+		GasPrice:    tx.GasPrice,
+		Value:       tx.Value,
+		Data:        tx.Input,
+		BlockNumber: new(big.Int).SetUint64(receipt.BlockNumber),
+	}
+	res, err := client.Call(ctx, msg)
+	if rerr, ok := err.(*RevertError); ok {
+		if reason, ok := DecodeRevert(rerr.Data, customErrs); ok {
+			return reason, nil
+		}
+		return rerr.Reason, nil
+	} else if err != nil {
+		return "", fmt.Errorf("cannot replay transaction: %v", err)
+	}
+	if reason, ok := DecodeRevert(res, customErrs); ok {
+		return reason, nil
+	}
+	if receipt.GasUsed == tx.GasLimit {
+		return "out of gas", nil
+	}
+	return "", nil
+}
@@ -0,0 +1,233 @@
+package web3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gochain-io/gochain/v3/accounts/abi"
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/common/hexutil"
+	"github.com/gochain-io/gochain/v3/core/types"
+	"github.com/gochain-io/gochain/v3/rpc"
+)
+
+const revertTestABI = `[
+	{"type":"function","name":"f","inputs":[],"outputs":[]},
+	{"type":"error","name":"InsufficientBalance","inputs":[
+		{"name":"available","type":"uint256"},
+		{"name":"required","type":"uint256"}
+	]},
+	{"type":"error","name":"Unauthorized","inputs":[{"name":"caller","type":"address"}]}
+]`
+
+// revertData encodes the revert data of err with args.
+func revertData(t *testing.T, err abi.Method, args ...interface{}) []byte {
+	t.Helper()
+	packed, perr := err.Inputs.Pack(args...)
+	if perr != nil {
+		t.Fatal(perr)
+	}
+	return append(err.Id(), packed...)
+}
+
+func TestParseABIErrors(t *testing.T) {
+	errs, err := ParseABIErrors(strings.NewReader(revertTestABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors but got %d", len(errs))
+	}
+	if got := common.Bytes2Hex(errs["InsufficientBalance"].Id()); got != "cf479181" {
+		t.Errorf("expected selector cf479181 but got %s", got)
+	}
+	errs, err = ParseABIErrors(strings.NewReader(`[{"type":"function","name":"f","inputs":[]}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 0 {
+		t.Errorf("expected no errors but got %d", len(errs))
+	}
+}
+
+func TestDecodeRevert(t *testing.T) {
+	custom, err := ParseABIErrors(strings.NewReader(revertTestABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errorData := revertData(t, builtinErrors["Error"], "not enough")
+	for _, test := range []struct {
+		name   string
+		data   []byte
+		custom map[string]abi.Method
+		exp    string
+		ok     bool
+	}{
+		{"error", errorData, nil, "not enough", true},
+		{"empty error", revertData(t, builtinErrors["Error"], ""), nil, "", true},
+		{"panic", revertData(t, builtinErrors["Panic"], big.NewInt(0x11)), nil, "panic: 0x11", true},
+		{"custom", revertData(t, custom["InsufficientBalance"], big.NewInt(1), big.NewInt(2)), custom, "InsufficientBalance(1, 2)", true},
+		{"custom address", revertData(t, custom["Unauthorized"], common.HexToAddress("0x000000000000000000000000000000000000dEaD")), custom, "Unauthorized(0x000000000000000000000000000000000000dEaD)", true},
+		{"unknown custom", revertData(t, custom["InsufficientBalance"], big.NewInt(1), big.NewInt(2)), nil, "", false},
+		{"truncated error", errorData[:len(errorData)-32], nil, "", false},
+		{"truncated custom", revertData(t, custom["InsufficientBalance"], big.NewInt(1), big.NewInt(2))[:36], custom, "", false},
+		{"short selector", []byte{0x08, 0xc3, 0x79}, nil, "", false},
+		{"empty", nil, nil, "", false},
+		{"return values", common.LeftPadBytes([]byte{1}, 32), custom, "", false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := DecodeRevert(test.data, test.custom)
+			if ok != test.ok {
+				t.Fatalf("expected ok %t but got %t", test.ok, ok)
+			}
+			if got != test.exp {
+				t.Errorf("expected %q but got %q", test.exp, got)
+			}
+		})
+	}
+}
+
+func TestRevertReason(t *testing.T) {
+	ctx := context.Background()
+	custom, err := ParseABIErrors(strings.NewReader(revertTestABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := common.HexToAddress("0x0000000000000000000000000000000000000002")
+	tx := &Transaction{
+		From:     common.HexToAddress("0x0000000000000000000000000000000000000001"),
+		To:       &to,
+		GasLimit: 100000,
+		GasPrice: big.NewInt(1e9),
+		Value:    big.NewInt(0),
+		Input:    []byte{1, 2, 3, 4},
+		Hash:     common.HexToHash("0x01"),
+	}
+	failed := &Receipt{Status: types.ReceiptStatusFailed, TxHash: tx.Hash, BlockNumber: 10, GasUsed: 50000}
+	for _, test := range []struct {
+		name    string
+		receipt *Receipt
+		ret     []byte
+		err     error
+		exp     string
+	}{
+		{"revert error", failed, nil, &RevertError{Data: revertData(t, builtinErrors["Error"], "not owner")}, "not owner"},
+		{"custom revert error", failed, nil, &RevertError{Data: revertData(t, custom["InsufficientBalance"], big.NewInt(1), big.NewInt(2))}, "InsufficientBalance(1, 2)"},
+		{"node reason", failed, nil, &RevertError{Reason: "not owner"}, "not owner"},
+		{"revert data", failed, revertData(t, builtinErrors["Panic"], big.NewInt(1)), nil, "panic: 0x1"},
+		{"out of gas", &Receipt{Status: types.ReceiptStatusFailed, TxHash: tx.Hash, BlockNumber: 10, GasUsed: tx.GasLimit}, nil, nil, "out of gas"},
+		{"unknown", failed, nil, nil, ""},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var m MockClient
+			m.GetTransactionByHashFunc = func(ctx context.Context, hash common.Hash) (*Transaction, error) {
+				if hash != tx.Hash {
+					t.Errorf("expected transaction %s but got %s", tx.Hash.Hex(), hash.Hex())
+				}
+				return tx, nil
+			}
+			m.CallFunc = func(ctx context.Context, msg CallMsg) ([]byte, error) {
+				if msg.From != tx.From || *msg.To != to || msg.Gas != tx.GasLimit || string(msg.Data) != string(tx.Input) {
+					t.Errorf("unexpected call %+v", msg)
+				}
+				if msg.BlockNumber == nil || msg.BlockNumber.Uint64() != test.receipt.BlockNumber {
+					t.Errorf("expected call at block %d but got %v", test.receipt.BlockNumber, msg.BlockNumber)
+				}
+				return test.ret, test.err
+			}
+			got, err := RevertReason(ctx, &m, test.receipt, custom)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.exp {
+				t.Errorf("expected reason %q but got %q", test.exp, got)
+			}
+		})
+	}
+
+	var m MockClient
+	if _, err := RevertReason(ctx, &m, &Receipt{Status: types.ReceiptStatusSuccessful}, nil); err == nil {
+		t.Error("expected error for a successful transaction")
+	}
+	m.GetTransactionByHashFunc = func(ctx context.Context, hash common.Hash) (*Transaction, error) {
+		return tx, nil
+	}
+	m.CallFunc = func(ctx context.Context, msg CallMsg) ([]byte, error) {
+		return nil, errors.New("missing trie node")
+	}
+	if _, err := RevertReason(ctx, &m, failed, nil); err == nil || !strings.Contains(err.Error(), "missing trie node") {
+		t.Errorf("expected replay error but got %v", err)
+	}
+}
+
+func TestCallRevertError(t *testing.T) {
+	custom, err := ParseABIErrors(strings.NewReader(revertTestABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errorData := revertData(t, builtinErrors["Error"], "not owner")
+	customData := revertData(t, custom["Unauthorized"], common.HexToAddress("0x01"))
+	for _, test := range []struct {
+		name    string
+		message string
+		data    []byte
+		reason  string
+	}{
+		{"error data", "execution reverted", errorData, "not owner"},
+		{"custom data", "execution reverted", customData, ""},
+		{"message only", "execution reverted: not owner", nil, "not owner"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req struct {
+					ID json.RawMessage `json:"id"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				rerr := map[string]interface{}{"code": 3, "message": test.message}
+				if test.data != nil {
+					rerr["data"] = hexutil.Encode(test.data)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "error": rerr})
+			}))
+			defer ts.Close()
+			r, err := rpc.DialHTTP(ts.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := NewClient(r)
+			defer c.Close()
+
+			to := common.HexToAddress("0x02")
+			_, err = c.Call(context.Background(), CallMsg{To: &to})
+			rerr, ok := err.(*RevertError)
+			if !ok {
+				t.Fatalf("expected *RevertError but got %v", err)
+			}
+			if string(rerr.Data) != string(test.data) {
+				t.Errorf("expected data %x but got %x", test.data, rerr.Data)
+			}
+			if rerr.Reason != test.reason {
+				t.Errorf("expected reason %q but got %q", test.reason, rerr.Reason)
+			}
+			if test.data != nil {
+				exp, _ := DecodeRevert(test.data, custom)
+				if got, _ := DecodeRevert(rerr.Data, custom); got != exp {
+					t.Errorf("expected decoded reason %q but got %q", exp, got)
+				}
+			}
+			if !strings.HasPrefix(err.Error(), "execution reverted") {
+				t.Errorf("unexpected error message %q", err)
+			}
+		})
+	}
+}
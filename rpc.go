@@ -1,9 +1,12 @@
 package web3
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
+	"strings"
 	"time"
 
 	"github.com/gochain-io/gochain/v3/common"
@@ -270,6 +273,7 @@ type rpcReceipt struct {
 	ContractAddress   *common.Address `json:"contractAddress"`
 	GasUsed           *hexutil.Uint64 `json:"gasUsed"`
 	ParsedLogs        *[]Event        `json:"parsedLogs"`
+	RevertReason      *string         `json:"revertReason,omitempty"`
 	BlockHash         *common.Hash    `json:"blockHash"`
 	BlockNumber       *hexutil.Uint64 `json:"blockNumber"`
 	From              *common.Address `json:"from"`
@@ -322,11 +326,16 @@ func (rr *rpcReceipt) copyTo(r *Receipt) error {
 	if rr.To != nil {
 		r.To = rr.To
 	}
+	if rr.RevertReason != nil {
+		r.RevertReason = *rr.RevertReason
+	}
 	return nil
 }
 
 func (rr *rpcReceipt) copyFrom(r *Receipt) {
-	rr.PostState = (*hexutil.Bytes)(&r.PostState)
+	if r.PostState != nil {
+		rr.PostState = (*hexutil.Bytes)(&r.PostState)
+	}
 	rr.Status = (*hexutil.Uint64)(&r.Status)
 	rr.CumulativeGasUsed = (*hexutil.Uint64)(&r.CumulativeGasUsed)
 	rr.Bloom = &r.Bloom
@@ -336,8 +345,259 @@ func (rr *rpcReceipt) copyFrom(r *Receipt) {
 	rr.ContractAddress = &r.ContractAddress
 	rr.GasUsed = (*hexutil.Uint64)(&r.GasUsed)
 	rr.ParsedLogs = &r.ParsedLogs
+	if r.RevertReason != "" {
+		rr.RevertReason = &r.RevertReason
+	}
 	rr.BlockHash = &r.BlockHash
 	rr.BlockNumber = (*hexutil.Uint64)(&r.BlockNumber)
 	rr.From = &r.From
 	rr.To = r.To
 }
+
+type rpcAccountProof struct {
+	Address      *common.Address   `json:"address"`
+	AccountProof []hexutil.Bytes   `json:"accountProof"`
+	Balance      *hexutil.Big      `json:"balance"`
+	CodeHash     *common.Hash      `json:"codeHash"`
+	Nonce        *hexutil.Uint64   `json:"nonce"`
+	StorageHash  *common.Hash      `json:"storageHash"`
+	StorageProof []rpcStorageProof `json:"storageProof"`
+}
+
+type rpcStorageProof struct {
+	// Nodes echo the requested key, which may not be zero padded.
+	Key   string          `json:"key"`
+	Value *hexutil.Big    `json:"value"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+func (rp *rpcAccountProof) copyTo(p *AccountProof) error {
+	if rp.Address == nil {
+		return errors.New("missing 'address'")
+	}
+	p.Address = *rp.Address
+	p.AccountProof = make([][]byte, len(rp.AccountProof))
+	for i, n := range rp.AccountProof {
+		p.AccountProof[i] = n
+	}
+	if rp.Balance == nil {
+		return errors.New("missing 'balance'")
+	}
+	p.Balance = (*big.Int)(rp.Balance)
+	if rp.CodeHash == nil {
+		return errors.New("missing 'codeHash'")
+	}
+	p.CodeHash = *rp.CodeHash
+	if rp.Nonce == nil {
+		return errors.New("missing 'nonce'")
+	}
+	p.Nonce = uint64(*rp.Nonce)
+	if rp.StorageHash == nil {
+		return errors.New("missing 'storageHash'")
+	}
+	p.StorageHash = *rp.StorageHash
+	p.StorageProof = make([]StorageProof, len(rp.StorageProof))
+	for i, rs := range rp.StorageProof {
+		s := &p.StorageProof[i]
+		s.Key = common.HexToHash(rs.Key)
+		if rs.Value == nil {
+			return errors.New("missing storage proof 'value'")
+		}
+		s.Value = (*big.Int)(rs.Value)
+		s.Proof = make([][]byte, len(rs.Proof))
+		for j, n := range rs.Proof {
+			s.Proof[j] = n
+		}
+	}
+	return nil
+}
+
+func (rp *rpcAccountProof) copyFrom(p *AccountProof) {
+	rp.Address = &p.Address
+	rp.AccountProof = make([]hexutil.Bytes, len(p.AccountProof))
+	for i, n := range p.AccountProof {
+		rp.AccountProof[i] = n
+	}
+	rp.Balance = (*hexutil.Big)(p.Balance)
+	rp.CodeHash = &p.CodeHash
+	rp.Nonce = (*hexutil.Uint64)(&p.Nonce)
+	rp.StorageHash = &p.StorageHash
+	rp.StorageProof = make([]rpcStorageProof, len(p.StorageProof))
+	for i, s := range p.StorageProof {
+		rs := &rp.StorageProof[i]
+		rs.Key = s.Key.Hex()
+		rs.Value = (*hexutil.Big)(s.Value)
+		rs.Proof = make([]hexutil.Bytes, len(s.Proof))
+		for j, n := range s.Proof {
+			rs.Proof[j] = n
+		}
+	}
+}
+
+type rpcCallFrame struct {
+	Type    string          `json:"type"`
+	From    *common.Address `json:"from"`
+	To      *common.Address `json:"to,omitempty"`
+	Value   *hexutil.Big    `json:"value,omitempty"`
+	Gas     *hexutil.Uint64 `json:"gas,omitempty"`
+	GasUsed *hexutil.Uint64 `json:"gasUsed,omitempty"`
+	Input   hexutil.Bytes   `json:"input"`
+	Output  hexutil.Bytes   `json:"output,omitempty"`
+	Error   string          `json:"error,omitempty"`
+	Calls   []*CallFrame    `json:"calls,omitempty"`
+}
+
+func (rf *rpcCallFrame) copyTo(f *CallFrame) error {
+	if rf.Type == "" {
+		return errors.New("missing 'type'")
+	}
+	f.Type = rf.Type
+	if rf.From == nil {
+		return errors.New("missing 'from'")
+	}
+	f.From = *rf.From
+	if rf.To != nil {
+		f.To = *rf.To
+	}
+	f.Value = (*big.Int)(rf.Value)
+	if rf.Gas != nil {
+		f.Gas = uint64(*rf.Gas)
+	}
+	if rf.GasUsed != nil {
+		f.GasUsed = uint64(*rf.GasUsed)
+	}
+	f.Input = rf.Input
+	f.Output = rf.Output
+	f.Error = rf.Error
+	f.Calls = rf.Calls
+	return nil
+}
+
+func (rf *rpcCallFrame) copyFrom(f *CallFrame) {
+	rf.Type = f.Type
+	rf.From = &f.From
+	rf.To = &f.To
+	rf.Value = (*hexutil.Big)(f.Value)
+	rf.Gas = (*hexutil.Uint64)(&f.Gas)
+	rf.GasUsed = (*hexutil.Uint64)(&f.GasUsed)
+	rf.Input = f.Input
+	rf.Output = f.Output
+	rf.Error = f.Error
+	rf.Calls = f.Calls
+}
+
+type rpcExecutionTrace struct {
+	Gas         uint64         `json:"gas"`
+	Failed      bool           `json:"failed"`
+	ReturnValue string         `json:"returnValue"`
+	StructLogs  []rpcStructLog `json:"structLogs"`
+}
+
+type rpcStructLog struct {
+	Pc      uint64             `json:"pc"`
+	Op      string             `json:"op"`
+	Gas     uint64             `json:"gas"`
+	GasCost uint64             `json:"gasCost"`
+	Depth   int                `json:"depth"`
+	Error   json.RawMessage    `json:"error,omitempty"`
+	Stack   *[]string          `json:"stack,omitempty"`
+	Memory  *[]string          `json:"memory,omitempty"`
+	Storage *map[string]string `json:"storage,omitempty"`
+}
+
+// decodeTraceHex decodes a struct logger hex value, which has a 0x prefix or not
+// depending on the node.
+func decodeTraceHex(s string) ([]byte, error) {
+	s = strings.TrimPrefix(s, "0x")
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	return hex.DecodeString(s)
+}
+
+func (rt *rpcExecutionTrace) copyTo(t *ExecutionTrace) error {
+	t.Gas = rt.Gas
+	t.Failed = rt.Failed
+	var err error
+	t.ReturnValue, err = decodeTraceHex(rt.ReturnValue)
+	if err != nil {
+		return fmt.Errorf("invalid 'returnValue': %v", err)
+	}
+	t.StructLogs = make([]StructLog, len(rt.StructLogs))
+	for i, rl := range rt.StructLogs {
+		l := &t.StructLogs[i]
+		l.Pc, l.Op, l.Gas, l.GasCost, l.Depth = rl.Pc, rl.Op, rl.Gas, rl.GasCost, rl.Depth
+		if len(rl.Error) > 0 && string(rl.Error) != "null" {
+			// Older nodes marshal the error value itself, which is usually an empty object.
+			if err := json.Unmarshal(rl.Error, &l.Error); err != nil {
+				l.Error = "error"
+			}
+		}
+		if rl.Stack != nil {
+			l.Stack = make([]*big.Int, len(*rl.Stack))
+			for j, s := range *rl.Stack {
+				b, err := decodeTraceHex(s)
+				if err != nil {
+					return fmt.Errorf("invalid stack value %q: %v", s, err)
+				}
+				l.Stack[j] = new(big.Int).SetBytes(b)
+			}
+		}
+		if rl.Memory != nil {
+			l.Memory = []byte{}
+			for _, s := range *rl.Memory {
+				b, err := decodeTraceHex(s)
+				if err != nil {
+					return fmt.Errorf("invalid memory word %q: %v", s, err)
+				}
+				l.Memory = append(l.Memory, b...)
+			}
+		}
+		if rl.Storage != nil {
+			l.Storage = make(map[common.Hash]common.Hash, len(*rl.Storage))
+			for k, v := range *rl.Storage {
+				l.Storage[common.HexToHash(k)] = common.HexToHash(v)
+			}
+		}
+	}
+	return nil
+}
+
+func (rt *rpcExecutionTrace) copyFrom(t *ExecutionTrace) {
+	rt.Gas = t.Gas
+	rt.Failed = t.Failed
+	rt.ReturnValue = hex.EncodeToString(t.ReturnValue)
+	rt.StructLogs = make([]rpcStructLog, len(t.StructLogs))
+	for i, l := range t.StructLogs {
+		rl := &rt.StructLogs[i]
+		rl.Pc, rl.Op, rl.Gas, rl.GasCost, rl.Depth = l.Pc, l.Op, l.Gas, l.GasCost, l.Depth
+		if l.Error != "" {
+			rl.Error, _ = json.Marshal(l.Error)
+		}
+		if l.Stack != nil {
+			stack := make([]string, len(l.Stack))
+			for j, v := range l.Stack {
+				stack[j] = hex.EncodeToString(common.BigToHash(v).Bytes())
+			}
+			rl.Stack = &stack
+		}
+		if l.Memory != nil {
+			memory := []string{}
+			for j := 0; j < len(l.Memory); j += 32 {
+				end := j + 32
+				if end > len(l.Memory) {
+					end = len(l.Memory)
+				}
+				memory = append(memory, hex.EncodeToString(l.Memory[j:end]))
+			}
+			rl.Memory = &memory
+		}
+		if l.Storage != nil {
+			storage := make(map[string]string, len(l.Storage))
+			for k, v := range l.Storage {
+				storage[hex.EncodeToString(k.Bytes())] = hex.EncodeToString(v.Bytes())
+			}
+			rl.Storage = &storage
+		}
+	}
+}
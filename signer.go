@@ -0,0 +1,205 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strconv"
+
+	"github.com/gochain-io/gochain/v3/accounts/keystore"
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/common/hexutil"
+	"github.com/gochain-io/gochain/v3/core/types"
+	"github.com/gochain-io/gochain/v3/crypto"
+	"github.com/gochain-io/gochain/v3/rlp"
+	"github.com/gochain-io/gochain/v3/rpc"
+)
+
+// Signer signs transactions and messages on behalf of a single account.
+type Signer interface {
+	// Address returns the address of the signing account.
+	Address() common.Address
+	// SignTx returns a copy of tx with an EIP-155 signature for chainID.
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+	// SignMessage returns a 65 byte [R || S || V] signature of msg, after applying the
+	// standard Ethereum signed message prefix. V is 27 or 28.
+	SignMessage(ctx context.Context, msg []byte) ([]byte, error)
+	// SignTypedData returns a 65 byte [R || S || V] signature of the EIP-712 hash of td.
+	// V is 27 or 28.
+	SignTypedData(ctx context.Context, td *TypedData) ([]byte, error)
+}
+
+// NewAccountSigner returns a Signer backed by the in-memory key of acct.
+func NewAccountSigner(acct *Account) Signer {
+	return &accountSigner{acct: acct}
+}
+
+type accountSigner struct {
+	acct *Account
+}
+
+func (s *accountSigner) Address() common.Address {
+	return crypto.PubkeyToAddress(s.acct.key.PublicKey)
+}
+
+func (s *accountSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.NewEIP155Signer(chainID), s.acct.key)
+}
+
+func (s *accountSigner) SignMessage(ctx context.Context, msg []byte) ([]byte, error) {
+	return s.acct.SignMessage(msg)
+}
+
+func (s *accountSigner) SignTypedData(ctx context.Context, td *TypedData) ([]byte, error) {
+	return s.acct.SignTypedData(td)
+}
+
+// OpenKeystoreSigner returns a Signer for the encrypted keystore file at path.
+func OpenKeystoreSigner(path, password string) (Signer, error) {
+	keyJSON, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read keystore file: %v", err)
+	}
+	key, err := keystore.DecryptKey(keyJSON, password)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt keystore file: %v", err)
+	}
+	return NewAccountSigner(&Account{key: key.PrivateKey}), nil
+}
+
+// DialRemoteSigner returns a Signer for address which delegates signing to the external
+// signer at url, using the account_* JSON-RPC API of clef.
+func DialRemoteSigner(ctx context.Context, url string, address common.Address) (Signer, error) {
+	r, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return &remoteSigner{r: r, address: address}, nil
+}
+
+type remoteSigner struct {
+	r       *rpc.Client
+	address common.Address
+}
+
+func (s *remoteSigner) Address() common.Address {
+	return s.address
+}
+
+// remoteTxArgs is the transaction format expected by account_signTransaction.
+type remoteTxArgs struct {
+	From     common.Address  `json:"from"`
+	To       *common.Address `json:"to"`
+	Gas      hexutil.Uint64  `json:"gas"`
+	GasPrice hexutil.Big     `json:"gasPrice"`
+	Value    hexutil.Big     `json:"value"`
+	Nonce    hexutil.Uint64  `json:"nonce"`
+	Data     hexutil.Bytes   `json:"data"`
+}
+
+func (s *remoteSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	args := remoteTxArgs{
+		From:     s.address,
+		To:       tx.To(),
+		Gas:      hexutil.Uint64(tx.Gas()),
+		GasPrice: hexutil.Big(*tx.GasPrice()),
+		Value:    hexutil.Big(*tx.Value()),
+		Nonce:    hexutil.Uint64(tx.Nonce()),
+		Data:     tx.Data(),
+	}
+	var result struct {
+		Raw hexutil.Bytes `json:"raw"`
+	}
+	if err := s.r.CallContext(ctx, &result, "account_signTransaction", &args); err != nil {
+		return nil, err
+	}
+	signed := new(types.Transaction)
+	if err := rlp.DecodeBytes(result.Raw, signed); err != nil {
+		return nil, fmt.Errorf("invalid signed transaction from remote signer: %v", err)
+	}
+	// The remote signer signs with its own configured chain id, so verify it.
+	from, err := types.Sender(types.NewEIP155Signer(chainID), signed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature from remote signer: %v", err)
+	}
+	if from != s.address {
+		return nil, fmt.Errorf("remote signer signed as %s instead of %s", from.Hex(), s.address.Hex())
+	}
+	return signed, nil
+}
+
+func (s *remoteSigner) SignMessage(ctx context.Context, msg []byte) ([]byte, error) {
+	var sig hexutil.Bytes
+	err := s.r.CallContext(ctx, &sig, "account_signData", "text/plain", s.address, hexutil.Bytes(msg))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verify(textHash(msg), sig); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+func (s *remoteSigner) SignTypedData(ctx context.Context, td *TypedData) ([]byte, error) {
+	var sig hexutil.Bytes
+	if err := s.r.CallContext(ctx, &sig, "account_signTypedData", s.address, td); err != nil {
+		return nil, err
+	}
+	hash, err := td.Hash()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verify(hash, sig); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// verify returns an error unless sig is a signature of hash by the signer's address.
+func (s *remoteSigner) verify(hash, sig []byte) error {
+	if len(sig) != 65 {
+		return errors.New("invalid signature length from remote signer")
+	}
+	from, err := RecoverHashAddress(hash, sig)
+	if err != nil {
+		return fmt.Errorf("invalid signature from remote signer: %v", err)
+	}
+	if from != s.address {
+		return fmt.Errorf("remote signer signed as %s instead of %s", from.Hex(), s.address.Hex())
+	}
+	return nil
+}
+
+// RecoverAddress returns the address which signed msg with the standard Ethereum signed
+// message prefix, as by SignMessage. V may be 0 or 1, or 27 or 28.
+func RecoverAddress(msg, sig []byte) (common.Address, error) {
+	return RecoverHashAddress(textHash(msg), sig)
+}
+
+// RecoverHashAddress returns the address which signed the 32 byte hash, like ecrecover.
+// V may be 0 or 1, or 27 or 28.
+func RecoverHashAddress(hash, sig []byte) (common.Address, error) {
+	if len(sig) != 65 {
+		return common.Address{}, fmt.Errorf("invalid signature length %d: must be 65 bytes", len(sig))
+	}
+	sig = common.CopyBytes(sig)
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	if sig[64] > 1 {
+		return common.Address{}, fmt.Errorf("invalid signature recovery id %d", sig[64])
+	}
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// textHash returns the hash of msg after applying the Ethereum signed message prefix, as in personal_sign.
+func textHash(msg []byte) []byte {
+	prefix := "\x19Ethereum Signed Message:\n" + strconv.Itoa(len(msg))
+	return crypto.Keccak256([]byte(prefix), msg)
+}
@@ -0,0 +1,268 @@
+package web3
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gochain-io/gochain/v3/accounts/keystore"
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/common/hexutil"
+	"github.com/gochain-io/gochain/v3/core/types"
+	"github.com/gochain-io/gochain/v3/rlp"
+	"github.com/gochain-io/gochain/v3/rpc"
+)
+
+func TestSignMessage(t *testing.T) {
+	// The example of web3.eth.accounts.sign.
+	acct, err := ParsePrivateKey("0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := common.HexToAddress("0x2c7536E3605D9C16a7a3D7b1898e529396a65c23")
+	msg := []byte("Some data")
+	const exp = "0xb91467e570a6466aa9e9876cbcd013baba02900b8979d43fe208a4a4f339f5fd6007e74cd82e037b800186422fc2da167c747ef045e5d18a5f5d4300f8e1a0291c"
+
+	sig, err := acct.SignMessage(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hexutil.Encode(sig); got != exp {
+		t.Errorf("expected signature %s but got %s", exp, got)
+	}
+	if sig, err := NewAccountSigner(acct).SignMessage(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	} else if got := hexutil.Encode(sig); got != exp {
+		t.Errorf("expected signer signature %s but got %s", exp, got)
+	}
+
+	if got, err := RecoverAddress(msg, sig); err != nil {
+		t.Fatal(err)
+	} else if got != addr {
+		t.Errorf("expected address %s but got %s", addr.Hex(), got.Hex())
+	}
+	// V of 0 or 1 is accepted too.
+	sig[64] -= 27
+	if got, err := RecoverAddress(msg, sig); err != nil {
+		t.Fatal(err)
+	} else if got != addr {
+		t.Errorf("expected address %s but got %s", addr.Hex(), got.Hex())
+	}
+	if got, err := RecoverAddress([]byte("Other data"), sig); err == nil && got == addr {
+		t.Error("expected different address for another message")
+	}
+	sig[64] = 29
+	if _, err := RecoverAddress(msg, sig); err == nil {
+		t.Error("expected error for invalid V")
+	}
+	if _, err := RecoverAddress(msg, sig[:64]); err == nil {
+		t.Error("expected error for short signature")
+	}
+}
+
+// checkSignerTx signs a transaction with signer for chainID, and checks the sender.
+func checkSignerTx(t *testing.T, signer Signer, chainID *big.Int) {
+	t.Helper()
+	to := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	tx := types.NewTransaction(1, to, big.NewInt(2), 21000, big.NewInt(3), []byte{4})
+	signed, err := signer.SignTx(context.Background(), tx, chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signed.ChainId().Cmp(chainID) != 0 {
+		t.Errorf("expected chain id %s but got %s", chainID, signed.ChainId())
+	}
+	from, err := types.Sender(types.NewEIP155Signer(chainID), signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != signer.Address() {
+		t.Errorf("expected sender %s but got %s", signer.Address().Hex(), from.Hex())
+	}
+	if signed.Nonce() != 1 || *signed.To() != to || signed.Value().Int64() != 2 || signed.Gas() != 21000 ||
+		signed.GasPrice().Int64() != 3 || !bytes.Equal(signed.Data(), []byte{4}) {
+		t.Errorf("signed transaction differs from the original: %v", signed)
+	}
+}
+
+func TestAccountSigner(t *testing.T) {
+	acct, err := ParsePrivateKey(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := NewAccountSigner(acct)
+	if got := signer.Address().Hex(); got != acct.PublicKey() {
+		t.Errorf("expected address %s but got %s", acct.PublicKey(), got)
+	}
+	checkSignerTx(t, signer, big.NewInt(60))
+}
+
+func TestKeystoreSigner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "web3-signer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	acct, err := ParsePrivateKey(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := &keystore.Key{Address: common.HexToAddress(acct.PublicKey()), PrivateKey: acct.key}
+	keyJSON, err := keystore.EncryptKey(key, "foo", keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "key.json")
+	if err := ioutil.WriteFile(path, keyJSON, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := OpenKeystoreSigner(path, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer.Address() != key.Address {
+		t.Errorf("expected address %s but got %s", key.Address.Hex(), signer.Address().Hex())
+	}
+	checkSignerTx(t, signer, big.NewInt(60))
+
+	if _, err := OpenKeystoreSigner(path, "bar"); err == nil {
+		t.Error("expected error for the wrong password")
+	}
+	if _, err := OpenKeystoreSigner(filepath.Join(dir, "missing.json"), "foo"); err == nil {
+		t.Error("expected error for a missing file")
+	}
+}
+
+// SignerService implements the account_* signing API of clef with a single key, for any
+// requested address. It is exported because the rpc server only registers services of
+// exported types.
+type SignerService struct {
+	acct    *Account
+	chainID *big.Int
+}
+
+// SignTxArgs is the transaction sent to account_signTransaction.
+type SignTxArgs struct {
+	From     common.Address  `json:"from"`
+	To       *common.Address `json:"to"`
+	Gas      hexutil.Uint64  `json:"gas"`
+	GasPrice hexutil.Big     `json:"gasPrice"`
+	Value    hexutil.Big     `json:"value"`
+	Nonce    hexutil.Uint64  `json:"nonce"`
+	Data     hexutil.Bytes   `json:"data"`
+}
+
+func (s *SignerService) SignTransaction(args SignTxArgs) (map[string]hexutil.Bytes, error) {
+	tx := types.NewTransaction(uint64(args.Nonce), *args.To, args.Value.ToInt(), uint64(args.Gas), args.GasPrice.ToInt(), args.Data)
+	signed, err := types.SignTx(tx, types.NewEIP155Signer(s.chainID), s.acct.key)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := rlp.EncodeToBytes(signed)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]hexutil.Bytes{"raw": raw}, nil
+}
+
+func (s *SignerService) SignData(contentType string, address common.Address, data hexutil.Bytes) (hexutil.Bytes, error) {
+	return s.acct.SignMessage(data)
+}
+
+func (s *SignerService) SignTypedData(address common.Address, data json.RawMessage) (hexutil.Bytes, error) {
+	td, err := ParseTypedData(data)
+	if err != nil {
+		return nil, err
+	}
+	return s.acct.SignTypedData(td)
+}
+
+func TestRemoteSigner(t *testing.T) {
+	ctx := context.Background()
+	acct, err := ParsePrivateKey(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := CreateAccount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := common.HexToAddress(acct.PublicKey())
+	td, err := ParseTypedData([]byte(mailTypedData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var servers []*httptest.Server
+	defer func() {
+		for _, ts := range servers {
+			ts.Close()
+		}
+	}()
+	dial := func(t *testing.T, svc *SignerService) Signer {
+		server := rpc.NewServer()
+		if err := server.RegisterName("account", svc); err != nil {
+			t.Fatal(err)
+		}
+		ts := httptest.NewServer(server)
+		servers = append(servers, ts)
+		signer, err := DialRemoteSigner(ctx, ts.URL, addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if signer.Address() != addr {
+			t.Errorf("expected address %s but got %s", addr.Hex(), signer.Address().Hex())
+		}
+		return signer
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		signer := dial(t, &SignerService{acct: acct, chainID: big.NewInt(60)})
+		checkSignerTx(t, signer, big.NewInt(60))
+		msg := []byte("Some data")
+		sig, err := signer.SignMessage(ctx, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := RecoverAddress(msg, sig); err != nil || got != addr {
+			t.Errorf("expected message signer %s but got %s (%v)", addr.Hex(), got.Hex(), err)
+		}
+		sig, err = signer.SignTypedData(ctx, td)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := RecoverTypedDataAddress(td, sig); err != nil || got != addr {
+			t.Errorf("expected typed data signer %s but got %s (%v)", addr.Hex(), got.Hex(), err)
+		}
+	})
+
+	t.Run("other address", func(t *testing.T) {
+		signer := dial(t, &SignerService{acct: other, chainID: big.NewInt(60)})
+		exp := "remote signer signed as " + other.PublicKey() + " instead of " + addr.Hex()
+		tx := types.NewTransaction(0, addr, big.NewInt(1), 21000, big.NewInt(1), nil)
+		if _, err := signer.SignTx(ctx, tx, big.NewInt(60)); err == nil || err.Error() != exp {
+			t.Errorf("expected transaction error %q but got %v", exp, err)
+		}
+		if _, err := signer.SignMessage(ctx, []byte("Some data")); err == nil || err.Error() != exp {
+			t.Errorf("expected message error %q but got %v", exp, err)
+		}
+		if _, err := signer.SignTypedData(ctx, td); err == nil || err.Error() != exp {
+			t.Errorf("expected typed data error %q but got %v", exp, err)
+		}
+	})
+
+	t.Run("other chain id", func(t *testing.T) {
+		signer := dial(t, &SignerService{acct: acct, chainID: big.NewInt(1)})
+		tx := types.NewTransaction(0, addr, big.NewInt(1), 21000, big.NewInt(1), nil)
+		if _, err := signer.SignTx(ctx, tx, big.NewInt(60)); err == nil || !strings.HasPrefix(err.Error(), "invalid signature from remote signer") {
+			t.Errorf("expected invalid signature error but got %v", err)
+		}
+	})
+}
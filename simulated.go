@@ -0,0 +1,978 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/consensus/clique"
+	"github.com/gochain-io/gochain/v3/core"
+	"github.com/gochain-io/gochain/v3/core/rawdb"
+	"github.com/gochain-io/gochain/v3/core/state"
+	"github.com/gochain-io/gochain/v3/core/types"
+	"github.com/gochain-io/gochain/v3/core/vm"
+	"github.com/gochain-io/gochain/v3/crypto"
+	"github.com/gochain-io/gochain/v3/ethdb"
+	"github.com/gochain-io/gochain/v3/params"
+	"github.com/gochain-io/gochain/v3/rlp"
+)
+
+// SimulatedOptions configures a simulated chain. Zero values select the defaults.
+type SimulatedOptions struct {
+	// Accounts is the number of test accounts to generate and prefund. Default: 10.
+	Accounts int
+	// Balance is the initial balance of each generated account. Default: 1000000 GO.
+	Balance *big.Int
+	// Alloc prefunds additional accounts with the given balances.
+	Alloc map[common.Address]*big.Int
+	// ChainID is the chain id, and network id. Default: 1337.
+	ChainID *big.Int
+	// GasLimit is the gas limit of each block. Default: 10000000.
+	GasLimit uint64
+	// ManualMining holds transactions as pending until Mine is called, rather than mining
+	// a block for each transaction as soon as it is sent.
+	ManualMining bool
+}
+
+func (o *SimulatedOptions) setDefaults() {
+	if o.Accounts == 0 {
+		o.Accounts = 10
+	}
+	if o.Balance == nil {
+		o.Balance = new(big.Int).Mul(big.NewInt(1000000), big.NewInt(params.Ether))
+	}
+	if o.ChainID == nil {
+		o.ChainID = big.NewInt(1337)
+	}
+	if o.GasLimit == 0 {
+		o.GasLimit = 10000000
+	}
+}
+
+var errGasEstimationFailed = errors.New("gas required exceeds allowance or always failing transaction")
+
+// SimulatedClient is a Client backed by an in-memory chain, for tests. Blocks are mined
+// by the process itself, either for each transaction or on demand with Mine. The chain
+// can be rewound with Snapshot and Revert, and the time of later blocks moved forward
+// with AdjustTime.
+type SimulatedClient struct {
+	opts     SimulatedOptions
+	config   *params.ChainConfig
+	db       *ethdb.MemDatabase
+	chain    *core.BlockChain
+	accounts []*Account
+
+	mu            sync.Mutex
+	pending       []*types.Transaction
+	pendingHeader *types.Header
+	pendingState  *state.StateDB
+	pendingGas    *core.GasPool
+	timeOffset    time.Duration
+	snapshots     []simSnapshot
+
+	subsMu   sync.Mutex
+	headSubs map[*simSubscription]struct{}
+	logSubs  map[*simSubscription]FilterQuery
+	txSubs   map[*simSubscription]struct{}
+}
+
+type simSnapshot struct {
+	head       uint64
+	pending    []*types.Transaction
+	timeOffset time.Duration
+}
+
+// NewSimulatedClient returns a Client for a new simulated chain, with prefunded accounts
+// (see Accounts).
+func NewSimulatedClient(opts SimulatedOptions) (*SimulatedClient, error) {
+	opts.setDefaults()
+	config := *params.AllCliqueProtocolChanges
+	config.ChainId = opts.ChainID
+	s := &SimulatedClient{
+		opts:     opts,
+		config:   &config,
+		db:       ethdb.NewMemDatabase(),
+		headSubs: make(map[*simSubscription]struct{}),
+		logSubs:  make(map[*simSubscription]FilterQuery),
+		txSubs:   make(map[*simSubscription]struct{}),
+	}
+	alloc := make(core.GenesisAlloc)
+	for i := 0; i < opts.Accounts; i++ {
+		// Keys are derived from the index, so that addresses are the same in every run.
+		key, err := crypto.ToECDSA(crypto.Keccak256([]byte(fmt.Sprintf("simulated account %d", i))))
+		if err != nil {
+			return nil, err
+		}
+		acct := &Account{key: key}
+		s.accounts = append(s.accounts, acct)
+		alloc[crypto.PubkeyToAddress(key.PublicKey)] = core.GenesisAccount{Balance: opts.Balance}
+	}
+	for addr, bal := range opts.Alloc {
+		alloc[addr] = core.GenesisAccount{Balance: bal}
+	}
+	genesis := core.Genesis{
+		Config:     s.config,
+		Timestamp:  uint64(time.Now().Unix()),
+		GasLimit:   opts.GasLimit,
+		Difficulty: big.NewInt(1),
+		Alloc:      alloc,
+		Signer:     make([]byte, 65),
+	}
+	if _, err := genesis.Commit(s.db); err != nil {
+		return nil, fmt.Errorf("cannot commit genesis block: %v", err)
+	}
+	// The full faker skips header verification, so that block times can be in the future.
+	chain, err := core.NewBlockChain(context.Background(), s.db, &core.CacheConfig{Disabled: true}, s.config, clique.NewFullFaker(), vm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("cannot create blockchain: %v", err)
+	}
+	s.chain = chain
+	if err := s.resetPending(context.Background()); err != nil {
+		chain.Stop()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Accounts returns the generated prefunded accounts. They are the same for every
+// simulated chain.
+func (s *SimulatedClient) Accounts() []*Account {
+	return s.accounts
+}
+
+// Mine mines the pending transactions into a new block, and returns it.
+func (s *SimulatedClient) Mine(ctx context.Context) (*Block, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := s.mine(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.convertBlock(b, false), nil
+}
+
+// AdjustTime moves the clock used for the timestamps of new blocks forward by d. Block
+// timestamps always increase, so moving backwards only slows them down.
+func (s *SimulatedClient) AdjustTime(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.timeOffset += d
+}
+
+// Snapshot records the state of the chain, including pending transactions and the
+// clock, and returns an id for Revert.
+func (s *SimulatedClient) Snapshot() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshots = append(s.snapshots, simSnapshot{
+		head:       s.chain.CurrentBlock().NumberU64(),
+		pending:    append([]*types.Transaction(nil), s.pending...),
+		timeOffset: s.timeOffset,
+	})
+	return len(s.snapshots) - 1
+}
+
+// Revert rewinds the chain to the snapshot id, discarding any later blocks. The snapshot,
+// and any taken after it, can't be used again.
+func (s *SimulatedClient) Revert(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id < 0 || id >= len(s.snapshots) {
+		return fmt.Errorf("unknown snapshot %d", id)
+	}
+	snap := s.snapshots[id]
+	s.snapshots = s.snapshots[:id]
+	if err := s.chain.SetHead(snap.head); err != nil {
+		return fmt.Errorf("cannot rewind chain: %v", err)
+	}
+	s.pending = snap.pending
+	s.timeOffset = snap.timeOffset
+	return s.resetPending(ctx)
+}
+
+// nextHeader returns the header for a new block on top of parent.
+func (s *SimulatedClient) nextHeader(ctx context.Context, parent *types.Block) (*types.Header, error) {
+	t := time.Now().Add(s.timeOffset).Unix()
+	if t <= parent.Time().Int64() {
+		t = parent.Time().Int64() + 1
+	}
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		Signers:    parent.Signers(),
+		Voters:     parent.Voters(),
+		GasLimit:   s.opts.GasLimit,
+		Time:       big.NewInt(t),
+		Difficulty: big.NewInt(1),
+	}
+	if err := s.chain.Engine().Prepare(ctx, s.chain, header); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// apply executes tx on statedb as the next transaction of the block with header.
+func (s *SimulatedClient) apply(ctx context.Context, header *types.Header, statedb *state.StateDB, gp *core.GasPool, tx *types.Transaction, index int) (*types.Receipt, error) {
+	statedb.Prepare(tx.Hash(), common.Hash{}, index)
+	snap := statedb.Snapshot()
+	evm := vm.NewEVM(core.NewEVMContextLite(header, s.chain, &header.Coinbase), statedb, s.config, vm.Config{})
+	signer := types.MakeSigner(s.config, header.Number)
+	receipt, _, err := core.ApplyTransaction(ctx, evm, s.config, gp, statedb, header, tx, &header.GasUsed, signer)
+	if err != nil {
+		statedb.RevertToSnapshot(snap)
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// resetPending rebuilds the pending state on top of the head block, dropping pending
+// transactions which are no longer valid.
+func (s *SimulatedClient) resetPending(ctx context.Context) error {
+	head := s.chain.CurrentBlock()
+	header, err := s.nextHeader(ctx, head)
+	if err != nil {
+		return err
+	}
+	statedb, err := s.chain.StateAt(head.Root())
+	if err != nil {
+		return err
+	}
+	s.pendingHeader, s.pendingState = header, statedb
+	s.pendingGas = new(core.GasPool).AddGas(header.GasLimit)
+	txs := s.pending
+	s.pending = nil
+	for _, tx := range txs {
+		if _, err := s.apply(ctx, header, statedb, s.pendingGas, tx, len(s.pending)); err == nil {
+			s.pending = append(s.pending, tx)
+		}
+	}
+	return nil
+}
+
+// mine inserts a new block with the pending transactions. Transactions are executed
+// again, since the block time may differ from when they were sent.
+func (s *SimulatedClient) mine(ctx context.Context) (*types.Block, error) {
+	parent := s.chain.CurrentBlock()
+	header, err := s.nextHeader(ctx, parent)
+	if err != nil {
+		return nil, err
+	}
+	statedb, err := s.chain.StateAt(parent.Root())
+	if err != nil {
+		return nil, err
+	}
+	gp := new(core.GasPool).AddGas(header.GasLimit)
+	var txs []*types.Transaction
+	var receipts []*types.Receipt
+	for _, tx := range s.pending {
+		receipt, err := s.apply(ctx, header, statedb, gp, tx, len(txs))
+		if err != nil {
+			continue
+		}
+		txs = append(txs, tx)
+		receipts = append(receipts, receipt)
+	}
+	engine := s.chain.Engine()
+	block := engine.Finalize(ctx, s.chain, header, statedb, txs, receipts, true)
+	block, _, err = engine.Seal(ctx, s.chain, block, nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.chain.InsertChain(ctx, types.Blocks{block}); err != nil {
+		return nil, fmt.Errorf("cannot insert block: %v", err)
+	}
+	s.pending = nil
+	if err := s.resetPending(ctx); err != nil {
+		return nil, err
+	}
+	s.publishBlock(block)
+	return block, nil
+}
+
+// stateAt returns the header and state of block number (nil for latest).
+func (s *SimulatedClient) stateAt(number *big.Int) (*types.Header, *state.StateDB, error) {
+	block := s.chain.CurrentBlock()
+	if number != nil {
+		if !number.IsUint64() {
+			return nil, nil, NotFoundErr
+		}
+		block = s.chain.GetBlockByNumber(number.Uint64())
+		if block == nil {
+			return nil, nil, NotFoundErr
+		}
+	}
+	statedb, err := s.chain.StateAt(block.Root())
+	if err != nil {
+		return nil, nil, err
+	}
+	return block.Header(), statedb, nil
+}
+
+// call executes msg on statedb, returning the result and whether execution failed.
+func (s *SimulatedClient) call(header *types.Header, statedb *state.StateDB, msg CallMsg) ([]byte, bool, error) {
+	ret, _, failed, err := s.applyMessage(header, statedb, callMessage(header, msg), vm.Config{})
+	return ret, failed, err
+}
+
+// callMessage returns the message for executing msg as a call in the block with header.
+func callMessage(header *types.Header, msg CallMsg) *types.Message {
+	gas := msg.Gas
+	if gas == 0 {
+		gas = header.GasLimit
+	}
+	gasPrice := msg.GasPrice
+	if gasPrice == nil {
+		gasPrice = new(big.Int)
+	}
+	value := msg.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	return types.NewMessage(msg.From, msg.To, 0, value, gas, gasPrice, msg.Data, false)
+}
+
+// applyMessage executes m on statedb with cfg, returning the result, the gas used and
+// whether execution failed.
+func (s *SimulatedClient) applyMessage(header *types.Header, statedb *state.StateDB, m core.Message, cfg vm.Config) ([]byte, uint64, bool, error) {
+	evm := vm.NewEVM(core.NewEVMContext(m, header, s.chain, nil), statedb, s.config, cfg)
+	return core.ApplyMessage(evm, m, new(core.GasPool).AddGas(math.MaxUint64))
+}
+
+// revertError returns a *RevertError for the result of a failed call.
+func revertError(ret []byte) error {
+	reason, _ := DecodeRevert(ret, nil)
+	return &RevertError{Reason: reason, Data: ret}
+}
+
+// canonicalBlock returns the block with hash at number, if it is still part of the chain.
+func (s *SimulatedClient) canonicalBlock(hash common.Hash, number uint64) *types.Block {
+	block := s.chain.GetBlockByNumber(number)
+	if block == nil || block.Hash() != hash {
+		return nil
+	}
+	return block
+}
+
+// lookupTx returns the mined block and index of the transaction hash.
+func (s *SimulatedClient) lookupTx(hash common.Hash) (*types.Block, uint64, bool) {
+	blockHash, number, index := rawdb.ReadTxLookupEntry(s.db.GlobalTable(), hash)
+	if blockHash == (common.Hash{}) {
+		return nil, 0, false
+	}
+	block := s.canonicalBlock(blockHash, number)
+	if block == nil || index >= uint64(len(block.Transactions())) {
+		return nil, 0, false
+	}
+	return block, index, true
+}
+
+func (s *SimulatedClient) convertBlock(b *types.Block, includeTxs bool) *Block {
+	h := b.Header()
+	bloom := h.Bloom
+	block := &Block{
+		ParentHash:      h.ParentHash,
+		Sha3Uncles:      h.UncleHash,
+		Miner:           h.Coinbase,
+		Signers:         h.Signers,
+		Voters:          h.Voters,
+		Signer:          h.Signer,
+		StateRoot:       h.Root,
+		TxsRoot:         h.TxHash,
+		ReceiptsRoot:    h.ReceiptHash,
+		LogsBloom:       &bloom,
+		Difficulty:      h.Difficulty,
+		TotalDifficulty: s.chain.GetTd(b.Hash(), b.NumberU64()),
+		Number:          h.Number,
+		GasLimit:        h.GasLimit,
+		GasUsed:         h.GasUsed,
+		Timestamp:       time.Unix(h.Time.Int64(), 0).UTC(),
+		ExtraData:       h.Extra,
+		MixHash:         h.MixDigest,
+		Nonce:           h.Nonce,
+		Hash:            b.Hash(),
+		Uncles:          []common.Hash{},
+	}
+	if includeTxs {
+		block.TxDetails = make([]*Transaction, len(b.Transactions()))
+		for i := range block.TxDetails {
+			block.TxDetails[i] = s.convertBlockTx(b, i)
+		}
+	} else {
+		block.TxHashes = make([]common.Hash, len(b.Transactions()))
+		for i, tx := range b.Transactions() {
+			block.TxHashes[i] = tx.Hash()
+		}
+	}
+	return block
+}
+
+func (s *SimulatedClient) convertBlockTx(b *types.Block, index int) *Transaction {
+	tx := b.Transactions()[index]
+	from, _ := types.Sender(types.MakeSigner(s.config, b.Number()), tx)
+	t := convertTx(tx, from)
+	t.BlockNumber = b.Number()
+	t.BlockHash = b.Hash()
+	t.TransactionIndex = uint64(index)
+	return t
+}
+
+// blockReceipts returns the receipts of the transactions in b, including the positional
+// fields of their logs.
+func (s *SimulatedClient) blockReceipts(b *types.Block) []*Receipt {
+	raw := s.chain.GetReceiptsByHash(b.Hash())
+	signer := types.MakeSigner(s.config, b.Number())
+	receipts := make([]*Receipt, len(raw))
+	var logIndex uint
+	for i, r := range raw {
+		tx := b.Transactions()[i]
+		from, _ := types.Sender(signer, tx)
+		receipt := &Receipt{
+			PostState:         r.PostState,
+			Status:            r.Status,
+			CumulativeGasUsed: r.CumulativeGasUsed,
+			Bloom:             r.Bloom,
+			Logs:              make([]*types.Log, len(r.Logs)),
+			TxHash:            tx.Hash(),
+			TxIndex:           uint64(i),
+			ContractAddress:   r.ContractAddress,
+			GasUsed:           r.GasUsed,
+			BlockHash:         b.Hash(),
+			BlockNumber:       b.NumberU64(),
+			From:              from,
+			To:                tx.To(),
+		}
+		for j, l := range r.Logs {
+			cp := *l
+			cp.BlockNumber = b.NumberU64()
+			cp.BlockHash = b.Hash()
+			cp.TxHash = tx.Hash()
+			cp.TxIndex = uint(i)
+			cp.Index = logIndex
+			logIndex++
+			receipt.Logs[j] = &cp
+		}
+		receipts[i] = receipt
+	}
+	return receipts
+}
+
+// matchLog reports whether l matches the addresses and topics of q.
+func matchLog(q FilterQuery, l *types.Log) bool {
+	if len(q.Addresses) > 0 {
+		var found bool
+		for _, a := range q.Addresses {
+			if a == l.Address {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(q.Topics) > len(l.Topics) {
+		return false
+	}
+	for i, alternatives := range q.Topics {
+		if len(alternatives) == 0 {
+			continue
+		}
+		var found bool
+		for _, t := range alternatives {
+			if t == l.Topics[i] {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *SimulatedClient) GetBalance(ctx context.Context, address string, blockNumber *big.Int) (*big.Int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, statedb, err := s.stateAt(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return statedb.GetBalance(common.HexToAddress(address)), nil
+}
+
+func (s *SimulatedClient) GetCode(ctx context.Context, address string, blockNumber *big.Int) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, statedb, err := s.stateAt(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return statedb.GetCode(common.HexToAddress(address)), nil
+}
+
+func (s *SimulatedClient) GetStorageAt(ctx context.Context, address common.Address, slot common.Hash, blockNumber *big.Int) (common.Hash, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, statedb, err := s.stateAt(blockNumber)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return statedb.GetState(address, slot), nil
+}
+
+func (s *SimulatedClient) GetProof(ctx context.Context, address common.Address, slots []common.Hash, blockNumber *big.Int) (*AccountProof, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, statedb, err := s.stateAt(blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	accountProof, err := statedb.GetProof(address)
+	if err != nil {
+		return nil, err
+	}
+	p := &AccountProof{
+		Address:      address,
+		AccountProof: accountProof,
+		Balance:      statedb.GetBalance(address),
+		CodeHash:     emptyCodeHash,
+		Nonce:        statedb.GetNonce(address),
+		StorageHash:  types.EmptyRootHash,
+		StorageProof: make([]StorageProof, len(slots)),
+	}
+	storage := statedb.StorageTrie(address)
+	if storage != nil {
+		p.CodeHash = statedb.GetCodeHash(address)
+		p.StorageHash = storage.Hash()
+	}
+	for i, slot := range slots {
+		p.StorageProof[i] = StorageProof{Key: slot, Value: statedb.GetState(address, slot).Big()}
+		if storage != nil {
+			if p.StorageProof[i].Proof, err = statedb.GetStorageProof(address, slot); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return p, nil
+}
+
+func (s *SimulatedClient) GetBlockByNumber(ctx context.Context, number *big.Int, includeTxs bool) (*Block, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	block := s.chain.CurrentBlock()
+	if number != nil {
+		if !number.IsUint64() {
+			return nil, NotFoundErr
+		}
+		block = s.chain.GetBlockByNumber(number.Uint64())
+	}
+	if block == nil {
+		return nil, NotFoundErr
+	}
+	return s.convertBlock(block, includeTxs), nil
+}
+
+func (s *SimulatedClient) GetBlockByHash(ctx context.Context, hash string, includeTxs bool) (*Block, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	block := s.chain.GetBlockByHash(common.HexToHash(hash))
+	if block == nil {
+		return nil, NotFoundErr
+	}
+	return s.convertBlock(block, includeTxs), nil
+}
+
+func (s *SimulatedClient) GetTransactionByHash(ctx context.Context, hash common.Hash) (*Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, tx := range s.pending {
+		if tx.Hash() == hash {
+			from, _ := types.Sender(types.MakeSigner(s.config, s.pendingHeader.Number), tx)
+			return convertTx(tx, from), nil
+		}
+	}
+	block, index, ok := s.lookupTx(hash)
+	if !ok {
+		return nil, NotFoundErr
+	}
+	return s.convertBlockTx(block, int(index)), nil
+}
+
+func (s *SimulatedClient) GetSnapshot(ctx context.Context) (*Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	head := s.chain.CurrentBlock()
+	return &Snapshot{
+		Number:  head.NumberU64(),
+		Hash:    head.Hash(),
+		Signers: map[common.Address]uint64{},
+		Voters:  map[common.Address]struct{}{},
+		Tally:   map[common.Address]Tally{},
+	}, nil
+}
+
+func (s *SimulatedClient) GetID(ctx context.Context) (*ID, error) {
+	return &ID{
+		NetworkID:   new(big.Int).Set(s.config.ChainId),
+		ChainID:     new(big.Int).Set(s.config.ChainId),
+		GenesisHash: s.chain.Genesis().Hash(),
+	}, nil
+}
+
+func (s *SimulatedClient) GetTransactionReceipt(ctx context.Context, hash common.Hash) (*Receipt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	block, index, ok := s.lookupTx(hash)
+	if !ok {
+		return nil, NotFoundErr
+	}
+	receipts := s.blockReceipts(block)
+	if index >= uint64(len(receipts)) {
+		return nil, NotFoundErr
+	}
+	return receipts[index], nil
+}
+
+func (s *SimulatedClient) GetChainID(ctx context.Context) (*big.Int, error) {
+	return new(big.Int).Set(s.config.ChainId), nil
+}
+
+func (s *SimulatedClient) GetNetworkID(ctx context.Context) (*big.Int, error) {
+	return new(big.Int).Set(s.config.ChainId), nil
+}
+
+// GetGasPrice always returns 1 wei, since the simulated chain has no other transactions
+// to compete with.
+func (s *SimulatedClient) GetGasPrice(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(1), nil
+}
+
+func (s *SimulatedClient) GetPendingTransactionCount(ctx context.Context, account common.Address) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pendingState.GetNonce(account), nil
+}
+
+// SendRawTransaction executes tx on the pending state, and fails if it is invalid, e.g.
+// with "nonce too low". Unless ManualMining is set, a block is mined for it immediately.
+func (s *SimulatedClient) SendRawTransaction(ctx context.Context, raw []byte) error {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(raw, tx); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.pending {
+		if p.Hash() == tx.Hash() {
+			return fmt.Errorf("known transaction: %x", tx.Hash())
+		}
+	}
+	if _, _, ok := s.lookupTx(tx.Hash()); ok {
+		return fmt.Errorf("known transaction: %x", tx.Hash())
+	}
+	if _, err := s.apply(ctx, s.pendingHeader, s.pendingState, s.pendingGas, tx, len(s.pending)); err != nil {
+		return err
+	}
+	s.pending = append(s.pending, tx)
+	s.publishTx(tx.Hash())
+	if !s.opts.ManualMining {
+		if _, err := s.mine(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SimulatedClient) Call(ctx context.Context, msg CallMsg) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	header, statedb, err := s.stateAt(msg.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	ret, failed, err := s.call(header, statedb, msg)
+	if err != nil {
+		return nil, err
+	}
+	if failed {
+		return nil, revertError(ret)
+	}
+	return ret, nil
+}
+
+// EstimateGas searches for the lowest gas limit at which msg succeeds on the pending state.
+func (s *SimulatedClient) EstimateGas(ctx context.Context, msg CallMsg) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	executable := func(gas uint64) ([]byte, bool) {
+		msg.Gas = gas
+		snap := s.pendingState.Snapshot()
+		defer s.pendingState.RevertToSnapshot(snap)
+		ret, failed, err := s.call(s.pendingHeader, s.pendingState, msg)
+		return ret, err == nil && !failed
+	}
+	lo, hi := params.TxGas-1, s.pendingHeader.GasLimit
+	if msg.Gas >= params.TxGas {
+		hi = msg.Gas
+	}
+	if ret, ok := executable(hi); !ok {
+		if len(ret) > 0 {
+			return 0, revertError(ret)
+		}
+		return 0, errGasEstimationFailed
+	}
+	for lo+1 < hi {
+		mid := (lo + hi) / 2
+		if _, ok := executable(mid); ok {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return hi, nil
+}
+
+func (s *SimulatedClient) GetLogs(ctx context.Context, q FilterQuery) ([]*types.Log, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	to := s.chain.CurrentBlock().NumberU64()
+	from := to
+	if q.FromBlock != nil {
+		from = q.FromBlock.Uint64()
+	}
+	if q.ToBlock != nil && q.ToBlock.Uint64() < to {
+		to = q.ToBlock.Uint64()
+	}
+	logs := []*types.Log{}
+	for n := from; n <= to; n++ {
+		block := s.chain.GetBlockByNumber(n)
+		if block == nil {
+			break
+		}
+		for _, r := range s.blockReceipts(block) {
+			for _, l := range r.Logs {
+				if matchLog(q, l) {
+					logs = append(logs, l)
+				}
+			}
+		}
+	}
+	return logs, nil
+}
+
+func (s *SimulatedClient) SubscribeNewHeads(ctx context.Context, ch chan<- *Block) (Subscription, error) {
+	sub := newSimSubscription(func(v interface{}, quit <-chan struct{}) {
+		select {
+		case ch <- v.(*Block):
+		case <-quit:
+		}
+	})
+	s.subscribe(sub, func() { s.headSubs[sub] = struct{}{} })
+	return sub, nil
+}
+
+// SubscribeLogs delivers the logs of newly mined blocks which match q. The block range of
+// q is ignored.
+func (s *SimulatedClient) SubscribeLogs(ctx context.Context, q FilterQuery, ch chan<- *types.Log) (Subscription, error) {
+	sub := newSimSubscription(func(v interface{}, quit <-chan struct{}) {
+		select {
+		case ch <- v.(*types.Log):
+		case <-quit:
+		}
+	})
+	s.subscribe(sub, func() { s.logSubs[sub] = q })
+	return sub, nil
+}
+
+func (s *SimulatedClient) SubscribePendingTransactions(ctx context.Context, ch chan<- common.Hash) (Subscription, error) {
+	sub := newSimSubscription(func(v interface{}, quit <-chan struct{}) {
+		select {
+		case ch <- v.(common.Hash):
+		case <-quit:
+		}
+	})
+	s.subscribe(sub, func() { s.txSubs[sub] = struct{}{} })
+	return sub, nil
+}
+
+func (s *SimulatedClient) GetReceipts(ctx context.Context, hashes []common.Hash) ([]*Receipt, error) {
+	receipts := make([]*Receipt, len(hashes))
+	errs := make([]error, len(hashes))
+	for i, hash := range hashes {
+		receipts[i], errs[i] = s.GetTransactionReceipt(ctx, hash)
+	}
+	return receipts, simBatchError(errs)
+}
+
+func (s *SimulatedClient) GetBlocksByNumber(ctx context.Context, start, end *big.Int, includeTxs bool) ([]*Block, error) {
+	count, err := blockRange(start, end)
+	if err != nil {
+		return nil, err
+	}
+	blocks := make([]*Block, count)
+	errs := make([]error, count)
+	for i := range blocks {
+		number := new(big.Int).Add(start, new(big.Int).SetUint64(uint64(i)))
+		blocks[i], errs[i] = s.GetBlockByNumber(ctx, number, includeTxs)
+	}
+	return blocks, simBatchError(errs)
+}
+
+func (s *SimulatedClient) GetBalances(ctx context.Context, addresses []common.Address, blockNumber *big.Int) ([]*big.Int, error) {
+	balances := make([]*big.Int, len(addresses))
+	errs := make([]error, len(addresses))
+	for i, address := range addresses {
+		balances[i], errs[i] = s.GetBalance(ctx, address.Hex(), blockNumber)
+	}
+	return balances, simBatchError(errs)
+}
+
+// simBatchError returns a *BatchError if any of errs are non-nil.
+func simBatchError(errs []error) error {
+	for _, err := range errs {
+		if err != nil {
+			return &BatchError{Errors: errs}
+		}
+	}
+	return nil
+}
+
+// Close stops the chain and cancels all subscriptions.
+func (s *SimulatedClient) Close() {
+	s.subsMu.Lock()
+	var subs []*simSubscription
+	for sub := range s.headSubs {
+		subs = append(subs, sub)
+	}
+	for sub := range s.logSubs {
+		subs = append(subs, sub)
+	}
+	for sub := range s.txSubs {
+		subs = append(subs, sub)
+	}
+	s.subsMu.Unlock()
+	for _, sub := range subs {
+		sub.Unsubscribe()
+	}
+	s.chain.Stop()
+}
+
+// subscribe registers sub with add, and arranges for it to be removed on Unsubscribe.
+func (s *SimulatedClient) subscribe(sub *simSubscription, add func()) {
+	sub.onUnsubscribe = func() {
+		s.subsMu.Lock()
+		defer s.subsMu.Unlock()
+		delete(s.headSubs, sub)
+		delete(s.logSubs, sub)
+		delete(s.txSubs, sub)
+	}
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+	add()
+}
+
+func (s *SimulatedClient) publishBlock(b *types.Block) {
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+	if len(s.headSubs) > 0 {
+		block := s.convertBlock(b, false)
+		for sub := range s.headSubs {
+			sub.push(block)
+		}
+	}
+	if len(s.logSubs) > 0 {
+		for _, r := range s.blockReceipts(b) {
+			for _, l := range r.Logs {
+				for sub, q := range s.logSubs {
+					if matchLog(q, l) {
+						sub.push(l)
+					}
+				}
+			}
+		}
+	}
+}
+
+func (s *SimulatedClient) publishTx(hash common.Hash) {
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+	for sub := range s.txSubs {
+		sub.push(hash)
+	}
+}
+
+// simSubscription delivers values in order from a queue, so that slow subscribers
+// don't block the chain.
+type simSubscription struct {
+	send          func(v interface{}, quit <-chan struct{})
+	onUnsubscribe func()
+
+	mu     sync.Mutex
+	queue  []interface{}
+	notify chan struct{}
+	quit   chan struct{}
+	err    chan error
+	once   sync.Once
+}
+
+func newSimSubscription(send func(v interface{}, quit <-chan struct{})) *simSubscription {
+	sub := &simSubscription{
+		send:   send,
+		notify: make(chan struct{}, 1),
+		quit:   make(chan struct{}),
+		err:    make(chan error),
+	}
+	go sub.loop()
+	return sub
+}
+
+func (s *simSubscription) push(v interface{}) {
+	s.mu.Lock()
+	s.queue = append(s.queue, v)
+	s.mu.Unlock()
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (s *simSubscription) loop() {
+	for {
+		select {
+		case <-s.quit:
+			return
+		case <-s.notify:
+		}
+		for {
+			s.mu.Lock()
+			if len(s.queue) == 0 {
+				s.mu.Unlock()
+				break
+			}
+			v := s.queue[0]
+			s.queue = s.queue[1:]
+			s.mu.Unlock()
+			s.send(v, s.quit)
+		}
+	}
+}
+
+func (s *simSubscription) Err() <-chan error {
+	return s.err
+}
+
+func (s *simSubscription) Unsubscribe() {
+	s.once.Do(func() {
+		if s.onUnsubscribe != nil {
+			s.onUnsubscribe()
+		}
+		close(s.quit)
+		close(s.err)
+	})
+}
@@ -0,0 +1,140 @@
+package web3
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gochain-io/gochain/v3/accounts/abi"
+	"github.com/gochain-io/gochain/v3/common"
+)
+
+// simContractBin deploys a contract which returns 42 for any call.
+const (
+	simContractBin = "0x600a600c600039600a6000f3" + "602a60005260206000f3"
+	simContractABI = `[{"constant":true,"inputs":[],"name":"get","outputs":[{"name":"","type":"uint256"}],"type":"function"}]`
+)
+
+func TestSimulatedClient_DeployContract(t *testing.T) {
+	ctx := context.Background()
+	sim, err := NewSimulatedClient(SimulatedOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Close()
+	signer := NewAccountSigner(sim.Accounts()[0])
+
+	tx, err := DeployContract(ctx, sim, signer, nil, simContractBin, simContractABI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt, err := WaitForReceipt(ctx, sim, tx.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.Status != 1 {
+		t.Fatalf("contract creation failed: %+v", receipt)
+	}
+	myabi, err := abi.JSON(strings.NewReader(simContractABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := CallConstantFunction(ctx, sim, myabi, receipt.ContractAddress.Hex(), "get")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := res.(*big.Int); !ok || v.Int64() != 42 {
+		t.Errorf("expected 42 but got %v", res)
+	}
+}
+
+func TestSimulatedClient_ManualMining(t *testing.T) {
+	ctx := context.Background()
+	sim, err := NewSimulatedClient(SimulatedOptions{ManualMining: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Close()
+	signer := NewAccountSigner(sim.Accounts()[0])
+	to := common.HexToAddress(sim.Accounts()[1].PublicKey())
+
+	tx, err := Send(ctx, sim, signer, nil, to, big.NewInt(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sim.GetTransactionReceipt(ctx, tx.Hash); err != NotFoundErr {
+		t.Fatalf("expected pending transaction but got: %v", err)
+	}
+	if n, err := sim.GetPendingTransactionCount(ctx, signer.Address()); err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Errorf("expected pending nonce 1 but got %d", n)
+	}
+	block, err := sim.Mine(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(block.TxHashes) != 1 || block.TxHashes[0] != tx.Hash {
+		t.Errorf("expected block with tx %s but got %v", tx.Hash.Hex(), block.TxHashes)
+	}
+	if _, err := sim.GetTransactionReceipt(ctx, tx.Hash); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSimulatedClient_Revert(t *testing.T) {
+	ctx := context.Background()
+	sim, err := NewSimulatedClient(SimulatedOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Close()
+	signer := NewAccountSigner(sim.Accounts()[0])
+	to := common.HexToAddress("0x1234")
+
+	snap := sim.Snapshot()
+	tx, err := Send(ctx, sim, signer, nil, to, big.NewInt(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bal, err := sim.GetBalance(ctx, to.Hex(), nil); err != nil {
+		t.Fatal(err)
+	} else if bal.Int64() != 1000 {
+		t.Fatalf("expected balance 1000 but got %s", bal)
+	}
+	if err := sim.Revert(ctx, snap); err != nil {
+		t.Fatal(err)
+	}
+	if bal, err := sim.GetBalance(ctx, to.Hex(), nil); err != nil {
+		t.Fatal(err)
+	} else if bal.Sign() != 0 {
+		t.Errorf("expected balance 0 after revert but got %s", bal)
+	}
+	if _, err := sim.GetTransactionReceipt(ctx, tx.Hash); err != NotFoundErr {
+		t.Errorf("expected reverted receipt to be gone but got: %v", err)
+	}
+	// The same nonce is usable again.
+	if _, err := Send(ctx, sim, signer, nil, to, big.NewInt(1000)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSimulatedClient_AdjustTime(t *testing.T) {
+	ctx := context.Background()
+	sim, err := NewSimulatedClient(SimulatedOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Close()
+
+	sim.AdjustTime(24 * time.Hour)
+	block, err := sim.Mine(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if min := time.Now().Add(23 * time.Hour); block.Timestamp.Before(min) {
+		t.Errorf("expected block time after %s but got %s", min, block.Timestamp)
+	}
+}
@@ -0,0 +1,297 @@
+package web3
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/core"
+	"github.com/gochain-io/gochain/v3/core/state"
+	"github.com/gochain-io/gochain/v3/core/types"
+	"github.com/gochain-io/gochain/v3/core/vm"
+)
+
+// TraceTransaction supports StructLogger and CallTracer.
+func (s *SimulatedClient) TraceTransaction(ctx context.Context, hash common.Hash, cfg *TraceConfig) (*Trace, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	block, index, ok := s.lookupTx(hash)
+	if !ok {
+		return nil, NotFoundErr
+	}
+	parent := s.chain.GetBlockByHash(block.ParentHash())
+	if parent == nil {
+		return nil, fmt.Errorf("parent of block %s not found", block.Hash().Hex())
+	}
+	statedb, err := s.chain.StateAt(parent.Root())
+	if err != nil {
+		return nil, err
+	}
+	// Replay the earlier transactions of the block, to reach the state the
+	// transaction was executed on.
+	header := block.Header()
+	header.GasUsed = 0
+	gp := new(core.GasPool).AddGas(header.GasLimit)
+	for i, tx := range block.Transactions()[:index] {
+		if _, err := s.apply(ctx, header, statedb, gp, tx, i); err != nil {
+			return nil, fmt.Errorf("cannot replay transaction %s: %v", tx.Hash().Hex(), err)
+		}
+	}
+	tx := block.Transactions()[index]
+	m, err := tx.AsMessage(types.MakeSigner(s.config, header.Number))
+	if err != nil {
+		return nil, err
+	}
+	statedb.Prepare(tx.Hash(), block.Hash(), int(index))
+	return s.trace(header, statedb, m, cfg)
+}
+
+// TraceCall supports StructLogger and CallTracer.
+func (s *SimulatedClient) TraceCall(ctx context.Context, msg CallMsg, cfg *TraceConfig) (*Trace, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	header, statedb, err := s.stateAt(msg.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return s.trace(header, statedb, callMessage(header, msg), cfg)
+}
+
+// trace executes m on statedb with the tracer selected by cfg.
+func (s *SimulatedClient) trace(header *types.Header, statedb *state.StateDB, m core.Message, cfg *TraceConfig) (*Trace, error) {
+	var opts TraceConfig
+	if cfg != nil {
+		opts = *cfg
+	}
+	switch opts.Tracer {
+	case StructLogger:
+		logger := vm.NewStructLogger(&vm.LogConfig{
+			DisableMemory:  opts.DisableMemory,
+			DisableStack:   opts.DisableStack,
+			DisableStorage: opts.DisableStorage,
+		})
+		ret, gas, failed, err := s.applyMessage(header, statedb, m, vm.Config{Debug: true, Tracer: logger})
+		if err != nil {
+			return nil, err
+		}
+		t := &ExecutionTrace{Gas: gas, Failed: failed, ReturnValue: ret}
+		for _, l := range logger.StructLogs() {
+			sl := StructLog{Pc: l.Pc, Op: l.Op.String(), Gas: l.Gas, GasCost: l.GasCost, Depth: l.Depth, Memory: l.Memory}
+			if l.Err != nil {
+				sl.Error = l.Err.Error()
+			}
+			if l.Stack != nil {
+				sl.Stack = make([]*big.Int, len(l.Stack))
+				for i, v := range l.Stack {
+					sl.Stack[i] = new(big.Int).Set(v)
+				}
+			}
+			if l.Storage != nil {
+				sl.Storage = make(map[common.Hash]common.Hash, len(l.Storage))
+				for k, v := range l.Storage {
+					sl.Storage[k] = v
+				}
+			}
+			t.StructLogs = append(t.StructLogs, sl)
+		}
+		return &Trace{Execution: t}, nil
+
+	case CallTracer:
+		tracer := &callTracer{}
+		_, gas, _, err := s.applyMessage(header, statedb, m, vm.Config{Debug: true, Tracer: tracer})
+		if err != nil {
+			return nil, err
+		}
+		if len(tracer.stack) == 0 {
+			return nil, fmt.Errorf("no calls traced")
+		}
+		f := tracer.stack[0]
+		f.Gas, f.GasUsed = m.Gas(), gas
+		return &Trace{Call: &f.CallFrame}, nil
+	}
+	return nil, fmt.Errorf("tracer %q is not supported by the simulated chain", opts.Tracer)
+}
+
+// callTracer is a vm.Tracer which records the tree of calls like a node's callTracer.
+// Calls are recognized by their opcodes, and completed when execution returns to the
+// depth of the caller.
+type callTracer struct {
+	stack     []*callTracerFrame // stack[0] is the transaction
+	descended bool               // whether the last opcode was a call
+}
+
+type callTracerFrame struct {
+	CallFrame
+	gasIn, gasCost uint64 // gas before the call opcode, and its cost
+	gasSet         bool   // whether the call executed code, and set Gas
+	outOff, outLen int64  // memory area for the return data
+}
+
+func (t *callTracer) top() *callTracerFrame {
+	return t.stack[len(t.stack)-1]
+}
+
+func (t *callTracer) push(f *callTracerFrame) {
+	t.stack = append(t.stack, f)
+}
+
+func (t *callTracer) pop() *callTracerFrame {
+	f := t.top()
+	t.stack = t.stack[:len(t.stack)-1]
+	return f
+}
+
+func (t *callTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	typ := vm.CALL.String()
+	if create {
+		typ = vm.CREATE.String()
+	}
+	t.push(&callTracerFrame{CallFrame: CallFrame{
+		Type:  typ,
+		From:  from,
+		To:    to,
+		Value: new(big.Int).Set(value),
+		Gas:   gas,
+		Input: common.CopyBytes(input),
+	}})
+	return nil
+}
+
+func (t *callTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if err != nil {
+		return t.CaptureFault(env, pc, op, gas, cost, memory, stack, contract, depth, err)
+	}
+	switch op {
+	case vm.CREATE, vm.CREATE2:
+		t.push(&callTracerFrame{
+			CallFrame: CallFrame{
+				Type:  op.String(),
+				From:  contract.Address(),
+				Value: new(big.Int).Set(stack.Back(0)),
+				Input: memory.Get(stack.Back(1).Int64(), stack.Back(2).Int64()),
+			},
+			gasIn:   gas,
+			gasCost: cost,
+		})
+		t.descended = true
+		return nil
+
+	case vm.SELFDESTRUCT:
+		parent := t.top()
+		parent.Calls = append(parent.Calls, &CallFrame{
+			Type:  op.String(),
+			From:  contract.Address(),
+			To:    common.BigToAddress(stack.Back(0)),
+			Value: env.StateDB.GetBalance(contract.Address()),
+		})
+		return nil
+
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		to := common.BigToAddress(stack.Back(1))
+		if _, ok := vm.PrecompiledContractsByzantium[to]; ok {
+			return nil
+		}
+		// DELEGATECALL and STATICCALL have no value argument.
+		off := 1
+		if op == vm.DELEGATECALL || op == vm.STATICCALL {
+			off = 0
+		}
+		f := &callTracerFrame{
+			CallFrame: CallFrame{
+				Type:  op.String(),
+				From:  contract.Address(),
+				To:    to,
+				Input: memory.Get(stack.Back(2+off).Int64(), stack.Back(3+off).Int64()),
+			},
+			gasIn:   gas,
+			gasCost: cost,
+			outOff:  stack.Back(4 + off).Int64(),
+			outLen:  stack.Back(5 + off).Int64(),
+		}
+		switch op {
+		case vm.CALL, vm.CALLCODE:
+			f.Value = new(big.Int).Set(stack.Back(2))
+		case vm.DELEGATECALL:
+			if v := t.top().Value; v != nil {
+				f.Value = new(big.Int).Set(v)
+			}
+		}
+		t.push(f)
+		t.descended = true
+		return nil
+	}
+
+	if t.descended {
+		// Calls to accounts without code never reach a deeper depth.
+		if depth >= len(t.stack) {
+			t.top().Gas = gas
+			t.top().gasSet = true
+		}
+		t.descended = false
+	}
+	if op == vm.REVERT {
+		f := t.top()
+		f.Error = "execution reverted"
+		f.Output = memory.Get(stack.Back(0).Int64(), stack.Back(1).Int64())
+		return nil
+	}
+	if depth == len(t.stack)-1 {
+		// Returned to the caller. The call opcode left its success flag on the stack.
+		f := t.pop()
+		ret := stack.Back(0)
+		if f.Type == vm.CREATE.String() || f.Type == vm.CREATE2.String() {
+			f.GasUsed = f.gasIn - f.gasCost - gas
+			if ret.Sign() != 0 {
+				f.To = common.BigToAddress(ret)
+				f.Output = env.StateDB.GetCode(f.To)
+			} else if f.Error == "" {
+				f.Error = "internal failure"
+			}
+		} else {
+			if f.gasSet {
+				f.GasUsed = f.gasIn - f.gasCost + f.Gas - gas
+			}
+			if ret.Sign() != 0 {
+				f.Output = memory.Get(f.outOff, f.outLen)
+			} else if f.Error == "" {
+				f.Error = "internal failure"
+			}
+		}
+		parent := t.top()
+		parent.Calls = append(parent.Calls, &f.CallFrame)
+	}
+	return nil
+}
+
+func (t *callTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if len(t.stack) == 0 || t.top().Error != "" {
+		// Reverted calls are completed when returning to the caller.
+		return nil
+	}
+	f := t.pop()
+	f.Error = err.Error()
+	if f.gasSet {
+		f.GasUsed = f.Gas
+	}
+	if len(t.stack) > 0 {
+		parent := t.top()
+		parent.Calls = append(parent.Calls, &f.CallFrame)
+		return nil
+	}
+	t.push(f)
+	return nil
+}
+
+func (t *callTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	if len(t.stack) == 0 {
+		return nil
+	}
+	f := t.stack[0]
+	if err != nil && f.Error == "" {
+		f.Error = err.Error()
+	}
+	f.Output = common.CopyBytes(output)
+	return nil
+}
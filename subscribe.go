@@ -0,0 +1,268 @@
+package web3
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/common/hexutil"
+	"github.com/gochain-io/gochain/v3/core/types"
+	"github.com/gochain-io/gochain/v3/rpc"
+)
+
+const (
+	minResubscribeBackoff = 500 * time.Millisecond
+	maxResubscribeBackoff = 30 * time.Second
+)
+
+// Subscription represents a stream of notifications. Subscriptions created by the
+// client automatically resubscribe after a dropped connection, and backfill the blocks
+// and logs since the last one delivered.
+type Subscription interface {
+	// Err returns a channel which receives a fatal subscription error, e.g. when
+	// resubscribing fails with an error which is not a connection failure.
+	// The channel is closed by Unsubscribe.
+	Err() <-chan error
+	// Unsubscribe cancels the subscription. It is safe to call more than once.
+	Unsubscribe()
+}
+
+func (c *client) SubscribeNewHeads(ctx context.Context, ch chan<- *Block) (Subscription, error) {
+	// last is the number of the last block delivered to ch, or the head when subscribing.
+	last, err := c.blockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deliver := func(ctx context.Context, head *big.Int) error {
+		from := head
+		if last.Cmp(head) < 0 {
+			// Backfill any blocks between the last delivered and this head.
+			from = new(big.Int).Add(last, common.Big1)
+		}
+		for n := from; n.Cmp(head) <= 0; n = new(big.Int).Add(n, common.Big1) {
+			b, err := c.GetBlockByNumber(ctx, n, false)
+			if err != nil {
+				return err
+			}
+			select {
+			case ch <- b:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			last = n
+		}
+		return nil
+	}
+	handle := func(ctx context.Context, msg json.RawMessage) error {
+		var head struct {
+			Number *hexutil.Big `json:"number"`
+		}
+		if err := json.Unmarshal(msg, &head); err != nil || head.Number == nil {
+			return nil
+		}
+		if err := deliver(ctx, head.Number.ToInt()); err != nil && ctx.Err() != nil {
+			return err
+		}
+		// A block which failed to fetch is backfilled after the next head.
+		return nil
+	}
+	backfill := func(ctx context.Context) error {
+		head, err := c.blockNumber(ctx)
+		if err != nil {
+			return err
+		}
+		return deliver(ctx, head)
+	}
+	return c.subscribe(ctx, []interface{}{"newHeads"}, handle, backfill)
+}
+
+func (c *client) SubscribeLogs(ctx context.Context, q FilterQuery, ch chan<- *types.Log) (Subscription, error) {
+	// Logs after this position are backfilled following a reconnect.
+	start, err := c.blockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lastBlock, lastIndex := start.Uint64(), uint(0)
+	delivered := false
+	deliver := func(ctx context.Context, l *types.Log) error {
+		select {
+		case ch <- l:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if !l.Removed {
+			lastBlock, lastIndex, delivered = l.BlockNumber, l.Index, true
+		}
+		return nil
+	}
+	handle := func(ctx context.Context, msg json.RawMessage) error {
+		l := new(types.Log)
+		if err := json.Unmarshal(msg, l); err != nil {
+			return nil
+		}
+		return deliver(ctx, l)
+	}
+	backfill := func(ctx context.Context) error {
+		fq := q
+		fq.FromBlock = new(big.Int).SetUint64(lastBlock)
+		fq.ToBlock = nil
+		logs, err := c.GetLogs(ctx, fq)
+		if err != nil {
+			return err
+		}
+		for _, l := range logs {
+			if delivered && l.BlockNumber == lastBlock && l.Index <= lastIndex {
+				continue
+			}
+			if err := deliver(ctx, l); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	arg := map[string]interface{}{"topics": q.Topics}
+	if len(q.Addresses) > 0 {
+		arg["address"] = q.Addresses
+	}
+	return c.subscribe(ctx, []interface{}{"logs", arg}, handle, backfill)
+}
+
+func (c *client) SubscribePendingTransactions(ctx context.Context, ch chan<- common.Hash) (Subscription, error) {
+	handle := func(ctx context.Context, msg json.RawMessage) error {
+		var hash common.Hash
+		if err := json.Unmarshal(msg, &hash); err != nil {
+			return nil
+		}
+		select {
+		case ch <- hash:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		return nil
+	}
+	// Pending transactions missed while disconnected cannot be recovered.
+	backfill := func(context.Context) error { return nil }
+	return c.subscribe(ctx, []interface{}{"newPendingTransactions"}, handle, backfill)
+}
+
+func (c *client) blockNumber(ctx context.Context) (*big.Int, error) {
+	var result hexutil.Big
+	err := c.r.CallContext(ctx, &result, "eth_blockNumber")
+	return (*big.Int)(&result), err
+}
+
+// subscribe creates an eth subscription for args. Each notification is passed to handle,
+// and backfill is called after every successful resubscription, and retried with it until
+// it succeeds.
+func (c *client) subscribe(ctx context.Context, args []interface{}, handle func(context.Context, json.RawMessage) error, backfill func(context.Context) error) (Subscription, error) {
+	msgs := make(chan json.RawMessage)
+	sub, err := c.r.EthSubscribe(ctx, msgs, args...)
+	if err != nil {
+		return nil, err
+	}
+	s := &resubscription{
+		unsub: make(chan struct{}),
+		done:  make(chan struct{}),
+		err:   make(chan error, 1),
+	}
+	go s.loop(c.r, sub, msgs, args, handle, backfill)
+	return s, nil
+}
+
+// resubscription is a Subscription which transparently resubscribes when the
+// underlying rpc subscription fails.
+type resubscription struct {
+	unsubOnce sync.Once
+	unsub     chan struct{}
+	done      chan struct{}
+	err       chan error
+}
+
+func (s *resubscription) Err() <-chan error {
+	return s.err
+}
+
+func (s *resubscription) Unsubscribe() {
+	s.unsubOnce.Do(func() {
+		close(s.unsub)
+		<-s.done
+	})
+}
+
+func (s *resubscription) loop(r *rpc.Client, sub *rpc.ClientSubscription, msgs chan json.RawMessage, args []interface{},
+	handle func(context.Context, json.RawMessage) error, backfill func(context.Context) error) {
+	defer close(s.done)
+	defer close(s.err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.unsub:
+			cancel()
+		case <-s.done:
+		}
+	}()
+	for {
+		select {
+		case msg := <-msgs:
+			if err := handle(ctx, msg); err != nil {
+				sub.Unsubscribe()
+				return
+			}
+		case <-sub.Err():
+			// The connection dropped, so keep trying to resubscribe.
+			var err error
+			sub, err = resubscribe(ctx, r, msgs, args, backfill)
+			if err != nil {
+				if ctx.Err() == nil {
+					s.err <- err
+				}
+				return
+			}
+		case <-ctx.Done():
+			sub.Unsubscribe()
+			return
+		}
+	}
+}
+
+// resubscribe creates a subscription for args and then calls backfill, retrying both
+// with exponential backoff until they succeed, ctx is done, or either fails with an error
+// which is not transient.
+func resubscribe(ctx context.Context, r *rpc.Client, msgs chan json.RawMessage, args []interface{}, backfill func(context.Context) error) (*rpc.ClientSubscription, error) {
+	var sub *rpc.ClientSubscription
+	backoff := minResubscribeBackoff
+	for {
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			if sub != nil {
+				sub.Unsubscribe()
+			}
+			return nil, ctx.Err()
+		}
+		var err error
+		if sub == nil {
+			sub, err = r.EthSubscribe(ctx, msgs, args...)
+		}
+		if err == nil {
+			if err = backfill(ctx); err == nil {
+				return sub, nil
+			}
+		}
+		// Blocks may not be found yet on a node which is behind.
+		retry := isTransient(err) || err == NotFoundErr
+		if ctx.Err() != nil || err == rpc.ErrClientQuit || !retry {
+			if sub != nil {
+				sub.Unsubscribe()
+			}
+			return nil, err
+		}
+		if backoff *= 2; backoff > maxResubscribeBackoff {
+			backoff = maxResubscribeBackoff
+		}
+	}
+}
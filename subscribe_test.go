@@ -0,0 +1,405 @@
+package web3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/common/hexutil"
+	"github.com/gochain-io/gochain/v3/core/types"
+	"github.com/gochain-io/gochain/v3/rpc"
+)
+
+// ChainService is a minimal eth service with a head block and logs, which notifies the
+// newHeads and logs subscriptions of new blocks. It is exported because the rpc server
+// only registers services of exported types.
+type ChainService struct {
+	mu         sync.Mutex
+	head       int64
+	logs       []*types.Log
+	subs       []chainSub
+	subscribed chan time.Time // receives the time of each new subscription
+	logsErr    error          // returned by GetLogs when set
+}
+
+type chainSub struct {
+	notifier *rpc.Notifier
+	id       rpc.ID
+	logs     bool
+}
+
+func newChainService() *ChainService {
+	return &ChainService{subscribed: make(chan time.Time, 16)}
+}
+
+// addBlock advances the head by one block containing logs. Subscribers are only
+// notified when notify is set, otherwise the block is skipped as if the node missed it.
+func (s *ChainService) addBlock(notify bool, logs ...*types.Log) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.head++
+	for _, l := range logs {
+		l.BlockNumber = uint64(s.head)
+		l.BlockHash = common.BigToHash(big.NewInt(s.head))
+	}
+	s.logs = append(s.logs, logs...)
+	if !notify {
+		return
+	}
+	for _, sub := range s.subs {
+		// Subscriptions of dropped connections fail, and are ignored.
+		if !sub.logs {
+			sub.notifier.Notify(sub.id, map[string]interface{}{"number": hexutil.EncodeBig(big.NewInt(s.head))})
+			continue
+		}
+		for _, l := range logs {
+			sub.notifier.Notify(sub.id, l)
+		}
+	}
+}
+
+func (s *ChainService) subscribe(ctx context.Context, logs bool) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	s.mu.Lock()
+	s.subs = append(s.subs, chainSub{notifier: notifier, id: sub.ID, logs: logs})
+	s.mu.Unlock()
+	s.subscribed <- time.Now()
+	return sub, nil
+}
+
+func (s *ChainService) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	return s.subscribe(ctx, false)
+}
+
+func (s *ChainService) Logs(ctx context.Context, crit map[string]interface{}) (*rpc.Subscription, error) {
+	return s.subscribe(ctx, true)
+}
+
+func (s *ChainService) BlockNumber() hexutil.Uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return hexutil.Uint64(s.head)
+}
+
+func (s *ChainService) GetBlockByNumber(ctx context.Context, number hexutil.Big, full bool) (*Block, error) {
+	return &Block{
+		Sha3Uncles:   types.EmptyUncleHash,
+		TxsRoot:      types.EmptyRootHash,
+		LogsBloom:    new(types.Bloom),
+		Difficulty:   big.NewInt(1),
+		Number:       number.ToInt(),
+		Timestamp:    time.Unix(0, 0),
+		Hash:         common.BigToHash(number.ToInt()),
+		TxHashes:     []common.Hash{},
+		ReceiptsRoot: types.EmptyRootHash,
+	}, nil
+}
+
+func (s *ChainService) GetLogs(ctx context.Context, crit struct {
+	FromBlock *hexutil.Big `json:"fromBlock"`
+}) ([]*types.Log, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.logsErr != nil {
+		return nil, s.logsErr
+	}
+	logs := []*types.Log{}
+	for _, l := range s.logs {
+		if crit.FromBlock == nil || l.BlockNumber >= crit.FromBlock.ToInt().Uint64() {
+			logs = append(logs, l)
+		}
+	}
+	return logs, nil
+}
+
+// dropListener is a net.Listener whose accepted connections can be dropped.
+type dropListener struct {
+	net.Listener
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func (l *dropListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err == nil {
+		l.mu.Lock()
+		l.conns = append(l.conns, c)
+		l.mu.Unlock()
+	}
+	return c, err
+}
+
+// drop closes all accepted connections.
+func (l *dropListener) drop() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, c := range l.conns {
+		c.Close()
+	}
+	l.conns = nil
+}
+
+func testLog(index uint) *types.Log {
+	return &types.Log{
+		Address: common.HexToAddress("0x0000000000000000000000000000000000000001"),
+		Topics:  []common.Hash{{1}},
+		Data:    []byte{},
+		TxHash:  common.BigToHash(big.NewInt(int64(index))),
+		Index:   index,
+	}
+}
+
+func TestSubscribeNewHeads(t *testing.T) {
+	svc := newChainService()
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", svc); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+	c := NewClient(rpc.DialInProc(server))
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	blocks := make(chan *Block)
+	sub, err := c.SubscribeNewHeads(ctx, blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Unsubscribe()
+	// Give the server a moment to activate the subscription.
+	time.Sleep(100 * time.Millisecond)
+
+	// Heads 3 and 4 are skipped by the node and must be backfilled.
+	go func() {
+		for _, notify := range []bool{true, true, false, false, true} {
+			svc.addBlock(notify)
+		}
+	}()
+	for want := int64(1); want <= 5; want++ {
+		select {
+		case b := <-blocks:
+			if b.Number.Int64() != want {
+				t.Fatalf("expected block %d but got %d", want, b.Number)
+			}
+		case err := <-sub.Err():
+			t.Fatalf("subscription failed: %v", err)
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for block %d", want)
+		}
+	}
+}
+
+// newDropClient returns a client of svc over a websocket connection which can be dropped
+// with the returned listener.
+func newDropClient(t *testing.T, ctx context.Context, svc *ChainService) (Client, *dropListener, func()) {
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", svc); err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewUnstartedServer(server.WebsocketHandler([]string{"*"}))
+	l := &dropListener{Listener: ts.Listener}
+	ts.Listener = l
+	ts.Start()
+	r, err := rpc.DialWebsocket(ctx, "ws://"+l.Addr().String(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(r)
+	return c, l, func() {
+		c.Close()
+		ts.Close()
+		server.Stop()
+	}
+}
+
+func TestSubscribeReconnect(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	svc := newChainService()
+	c, l, stop := newDropClient(t, ctx, svc)
+	defer stop()
+
+	blocks := make(chan *Block)
+	headSub, err := c.SubscribeNewHeads(ctx, blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer headSub.Unsubscribe()
+	logs := make(chan *types.Log)
+	logSub, err := c.SubscribeLogs(ctx, FilterQuery{}, logs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logSub.Unsubscribe()
+	for i := 0; i < 2; i++ {
+		<-svc.subscribed
+	}
+	// Give the server a moment to activate the subscriptions.
+	time.Sleep(100 * time.Millisecond)
+
+	expectBlock := func(n uint64) {
+		t.Helper()
+		select {
+		case b := <-blocks:
+			if b.Number.Uint64() != n {
+				t.Fatalf("expected block %d but got %d", n, b.Number)
+			}
+		case err := <-headSub.Err():
+			t.Fatalf("heads subscription failed: %v", err)
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for block %d", n)
+		}
+	}
+	expectLog := func(block uint64, index uint) {
+		t.Helper()
+		select {
+		case l := <-logs:
+			if l.BlockNumber != block || l.Index != index {
+				t.Fatalf("expected log %d in block %d but got log %d in block %d", index, block, l.Index, l.BlockNumber)
+			}
+		case err := <-logSub.Err():
+			t.Fatalf("logs subscription failed: %v", err)
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for log %d in block %d", index, block)
+		}
+	}
+	svc.addBlock(true, testLog(0))
+	expectBlock(1)
+	expectLog(1, 0)
+
+	// Blocks 2 and 3 are added while disconnected, and must be backfilled without
+	// repeating block 1 or its log.
+	dropped := time.Now()
+	l.drop()
+	svc.addBlock(true, testLog(1))
+	svc.addBlock(true, testLog(2))
+	for i := 0; i < 2; i++ {
+		select {
+		case at := <-svc.subscribed:
+			if d := at.Sub(dropped); d < minResubscribeBackoff {
+				t.Errorf("resubscribed after %s, before the minimum backoff %s", d, minResubscribeBackoff)
+			}
+		case <-ctx.Done():
+			t.Fatal("timed out waiting to resubscribe")
+		}
+	}
+	expectBlock(2)
+	expectBlock(3)
+	expectLog(2, 1)
+	expectLog(3, 2)
+
+	svc.addBlock(true, testLog(3))
+	expectBlock(4)
+	expectLog(4, 3)
+
+	select {
+	case b := <-blocks:
+		t.Errorf("unexpected block %d", b.Number)
+	case l := <-logs:
+		t.Errorf("unexpected log %d in block %d", l.Index, l.BlockNumber)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestSubscribeResubscribeErrors(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	svc := newChainService()
+	c, l, stop := newDropClient(t, ctx, svc)
+	defer stop()
+
+	blocks := make(chan *Block)
+	headSub, err := c.SubscribeNewHeads(ctx, blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer headSub.Unsubscribe()
+	logSub, err := c.SubscribeLogs(ctx, FilterQuery{}, make(chan *types.Log))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logSub.Unsubscribe()
+	for i := 0; i < 2; i++ {
+		<-svc.subscribed
+	}
+
+	// The connection drops before any block is delivered, and the logs can no longer be
+	// queried.
+	svc.mu.Lock()
+	svc.logsErr = errors.New("invalid filter")
+	svc.mu.Unlock()
+	l.drop()
+	svc.addBlock(false)
+	svc.addBlock(false)
+	for want := uint64(1); want <= 2; want++ {
+		select {
+		case b := <-blocks:
+			if b.Number.Uint64() != want {
+				t.Fatalf("expected block %d but got %d", want, b.Number)
+			}
+		case err := <-headSub.Err():
+			t.Fatalf("heads subscription failed: %v", err)
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for block %d", want)
+		}
+	}
+	select {
+	case err := <-logSub.Err():
+		if err == nil || err.Error() != "invalid filter" {
+			t.Errorf("expected invalid filter but got %v", err)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for the logs subscription to fail")
+	}
+}
+
+var errConnReset = &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}
+
+func TestResubscribeRetriesBackfill(t *testing.T) {
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", newChainService()); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+	r := rpc.DialInProc(server)
+	defer r.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	msgs := make(chan json.RawMessage)
+
+	// A connection failure is retried on the same subscription.
+	var calls int
+	sub, err := resubscribe(ctx, r, msgs, []interface{}{"newHeads"}, func(context.Context) error {
+		if calls++; calls == 1 {
+			return errConnReset
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub.Unsubscribe()
+	if calls != 2 {
+		t.Errorf("expected 2 backfills but got %d", calls)
+	}
+
+	invalid := errors.New("invalid filter")
+	sub, err = resubscribe(ctx, r, msgs, []interface{}{"newHeads"}, func(context.Context) error {
+		return invalid
+	})
+	if err != invalid || sub != nil {
+		t.Errorf("expected %v but got %v", invalid, err)
+	}
+}
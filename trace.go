@@ -0,0 +1,168 @@
+package web3
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/gochain-io/gochain/v3/common"
+)
+
+// Tracers for TraceConfig.
+const (
+	// StructLogger logs the state of the EVM at each opcode. It is the default.
+	StructLogger = ""
+	// CallTracer records the tree of calls made, including internal calls.
+	CallTracer = "callTracer"
+)
+
+// TraceConfig configures TraceTransaction and TraceCall. A nil *TraceConfig uses the
+// struct logger with the node's defaults.
+type TraceConfig struct {
+	// Tracer is StructLogger, CallTracer, or the name or code of another tracer supported
+	// by the node.
+	Tracer string
+	// Struct logger options.
+	DisableStack   bool
+	DisableStorage bool
+	DisableMemory  bool
+	// Timeout limits the time the node spends tracing. When 0, the node's default is used.
+	Timeout time.Duration
+}
+
+func (cfg *TraceConfig) tracer() string {
+	if cfg == nil {
+		return StructLogger
+	}
+	return cfg.Tracer
+}
+
+// Trace is the result of TraceTransaction or TraceCall. Only the field for the configured
+// tracer is set.
+type Trace struct {
+	Call      *CallFrame      // CallTracer
+	Execution *ExecutionTrace // StructLogger
+	Raw       json.RawMessage // other tracers
+}
+
+// CallFrame is a call traced by CallTracer, with the calls it made.
+type CallFrame struct {
+	Type    string // CALL, CALLCODE, DELEGATECALL, STATICCALL, CREATE, CREATE2 or SELFDESTRUCT
+	From    common.Address
+	To      common.Address // the created contract for CREATE and CREATE2
+	Value   *big.Int       // nil for STATICCALL
+	Gas     uint64
+	GasUsed uint64
+	Input   []byte
+	Output  []byte // the revert data, if the call was reverted
+	Error   string // empty if the call succeeded
+	Calls   []*CallFrame
+}
+
+// ExecutionTrace is the result of StructLogger.
+type ExecutionTrace struct {
+	Gas         uint64
+	Failed      bool
+	ReturnValue []byte
+	StructLogs  []StructLog
+}
+
+// StructLog is the state of the EVM before executing an opcode.
+type StructLog struct {
+	Pc      uint64
+	Op      string
+	Gas     uint64
+	GasCost uint64
+	Depth   int
+	Error   string
+	Stack   []*big.Int                  // nil when disabled
+	Memory  []byte                      // nil when disabled
+	Storage map[common.Hash]common.Hash // nil when disabled
+}
+
+func (f *CallFrame) UnmarshalJSON(data []byte) error {
+	var r rpcCallFrame
+	err := json.Unmarshal(data, &r)
+	if err != nil {
+		return err
+	}
+	return r.copyTo(f)
+}
+
+func (f *CallFrame) MarshalJSON() ([]byte, error) {
+	var r rpcCallFrame
+	r.copyFrom(f)
+	return json.Marshal(&r)
+}
+
+func (t *ExecutionTrace) UnmarshalJSON(data []byte) error {
+	var r rpcExecutionTrace
+	err := json.Unmarshal(data, &r)
+	if err != nil {
+		return err
+	}
+	return r.copyTo(t)
+}
+
+func (t *ExecutionTrace) MarshalJSON() ([]byte, error) {
+	var r rpcExecutionTrace
+	r.copyFrom(t)
+	return json.Marshal(&r)
+}
+
+func (t *Trace) MarshalJSON() ([]byte, error) {
+	switch {
+	case t.Call != nil:
+		return json.Marshal(t.Call)
+	case t.Execution != nil:
+		return json.Marshal(t.Execution)
+	}
+	return t.Raw, nil
+}
+
+// toTraceConfigArg returns the debug_trace* config argument for cfg.
+func toTraceConfigArg(cfg *TraceConfig) interface{} {
+	arg := map[string]interface{}{}
+	if cfg == nil {
+		return arg
+	}
+	if cfg.Tracer != "" {
+		arg["tracer"] = cfg.Tracer
+	}
+	if cfg.DisableStack {
+		arg["disableStack"] = true
+	}
+	if cfg.DisableStorage {
+		arg["disableStorage"] = true
+	}
+	// Newer nodes exclude memory by default, with the opposite flag.
+	arg["disableMemory"] = cfg.DisableMemory
+	arg["enableMemory"] = !cfg.DisableMemory
+	if cfg.Timeout != 0 {
+		arg["timeout"] = cfg.Timeout.String()
+	}
+	return arg
+}
+
+// decodeTrace decodes the raw result of a debug_trace* call made with cfg.
+func decodeTrace(raw json.RawMessage, cfg *TraceConfig) (*Trace, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, NotFoundErr
+	}
+	switch cfg.tracer() {
+	case CallTracer:
+		var f CallFrame
+		if err := json.Unmarshal(raw, &f); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal call trace: %v", err)
+		}
+		return &Trace{Call: &f}, nil
+	case StructLogger:
+		var t ExecutionTrace
+		if err := json.Unmarshal(raw, &t); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal execution trace: %v", err)
+		}
+		return &Trace{Execution: &t}, nil
+	}
+	return &Trace{Raw: raw}, nil
+}
@@ -0,0 +1,153 @@
+package web3
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/core/types"
+)
+
+const (
+	// traceCallerBin deploys a contract which calls the address in its input, and
+	// returns the first word returned.
+	traceCallerBin = "0x6014600c60003960146000f3" + "602060006000600060006000355af160206000f3"
+	// traceRevertBin deploys a contract which reverts with the data 0xaa.
+	traceRevertBin = "0x600a600c600039600a6000f3" + "60aa6000526001601ffd"
+)
+
+func deployTraceContract(t *testing.T, sim *SimulatedClient, bin string) common.Address {
+	ctx := context.Background()
+	tx, err := DeployContract(ctx, sim, NewAccountSigner(sim.Accounts()[0]), nil, bin, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := WaitForReceipt(ctx, sim, tx.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r.ContractAddress
+}
+
+func TestSimulatedClient_TraceCall(t *testing.T) {
+	ctx := context.Background()
+	sim, err := NewSimulatedClient(SimulatedOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Close()
+	caller := deployTraceContract(t, sim, traceCallerBin)
+	callee := deployTraceContract(t, sim, simContractBin)
+	reverter := deployTraceContract(t, sim, traceRevertBin)
+
+	for _, test := range []struct {
+		name   string
+		to     common.Address
+		output []byte
+		err    string
+	}{
+		{name: "success", to: callee, output: common.LeftPadBytes([]byte{42}, 32)},
+		{name: "revert", to: reverter, output: []byte{0xaa}, err: "execution reverted"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			msg := CallMsg{To: &caller, Data: common.LeftPadBytes(test.to.Bytes(), 32)}
+			trace, err := sim.TraceCall(ctx, msg, &TraceConfig{Tracer: CallTracer})
+			if err != nil {
+				t.Fatal(err)
+			}
+			top := trace.Call
+			if top.Type != "CALL" || top.To != caller || top.Error != "" || top.GasUsed == 0 {
+				t.Errorf("unexpected top level call: %+v", top)
+			}
+			if len(top.Calls) != 1 {
+				t.Fatalf("expected 1 internal call but got %d", len(top.Calls))
+			}
+			call := top.Calls[0]
+			if call.Type != "CALL" || call.From != caller || call.To != test.to {
+				t.Errorf("unexpected internal call: %+v", call)
+			}
+			if call.Error != test.err {
+				t.Errorf("expected error %q but got %q", test.err, call.Error)
+			}
+			if !bytes.Equal(call.Output, test.output) {
+				t.Errorf("expected output %x but got %x", test.output, call.Output)
+			}
+
+			// Traces survive JSON encoding, as returned by nodes.
+			b, err := json.Marshal(trace)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := decodeTrace(b, &TraceConfig{Tracer: CallTracer})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c := decoded.Call.Calls[0]; c.To != test.to || c.Error != test.err || !bytes.Equal(c.Output, test.output) {
+				t.Errorf("unexpected decoded internal call: %+v", c)
+			}
+		})
+	}
+}
+
+func TestSimulatedClient_TraceTransaction(t *testing.T) {
+	ctx := context.Background()
+	sim, err := NewSimulatedClient(SimulatedOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sim.Close()
+	caller := deployTraceContract(t, sim, traceCallerBin)
+	callee := deployTraceContract(t, sim, simContractBin)
+
+	tx, err := signAndSend(ctx, sim, NewAccountSigner(sim.Accounts()[0]), nil, func(nonce uint64) *types.Transaction {
+		return types.NewTransaction(nonce, caller, new(big.Int), 100000, big.NewInt(1), common.LeftPadBytes(callee.Bytes(), 32))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt, err := WaitForReceipt(ctx, sim, tx.Hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	trace, err := sim.TraceTransaction(ctx, tx.Hash, &TraceConfig{Tracer: CallTracer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trace.Call.GasUsed != receipt.GasUsed {
+		t.Errorf("expected gas used %d but got %d", receipt.GasUsed, trace.Call.GasUsed)
+	}
+	if len(trace.Call.Calls) != 1 || trace.Call.Calls[0].To != callee {
+		t.Errorf("expected call to %s but got %+v", callee.Hex(), trace.Call.Calls)
+	}
+
+	trace, err = sim.TraceTransaction(ctx, tx.Hash, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec := trace.Execution
+	if exec.Failed || exec.Gas != receipt.GasUsed || len(exec.StructLogs) == 0 {
+		t.Errorf("unexpected execution trace: gas %d failed %t logs %d", exec.Gas, exec.Failed, len(exec.StructLogs))
+	}
+	var depth2 bool
+	for _, l := range exec.StructLogs {
+		depth2 = depth2 || l.Depth == 2
+	}
+	if !depth2 {
+		t.Error("expected opcodes of the internal call")
+	}
+	b, err := json.Marshal(trace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := decodeTrace(b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := decoded.Execution; len(got.StructLogs) != len(exec.StructLogs) || !bytes.Equal(got.ReturnValue, exec.ReturnValue) {
+		t.Errorf("unexpected decoded execution trace: %+v", got)
+	}
+}
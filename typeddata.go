@@ -0,0 +1,333 @@
+package web3
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	gomath "math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/common/hexutil"
+	"github.com/gochain-io/gochain/v3/common/math"
+	"github.com/gochain-io/gochain/v3/crypto"
+)
+
+// TypedData is an EIP-712 typed structured data document, in the JSON format of
+// eth_signTypedData_v4.
+type TypedData struct {
+	Types       map[string][]TypedDataField `json:"types"`
+	PrimaryType string                      `json:"primaryType"`
+	Domain      map[string]interface{}      `json:"domain"`
+	Message     map[string]interface{}      `json:"message"`
+}
+
+// TypedDataField is a member of a struct type.
+type TypedDataField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// domainType is the name of the type of TypedData.Domain.
+const domainType = "EIP712Domain"
+
+// domainFields are the fields of the domain type, in order, when the document omits it.
+var domainFields = []TypedDataField{
+	{Name: "name", Type: "string"},
+	{Name: "version", Type: "string"},
+	{Name: "chainId", Type: "uint256"},
+	{Name: "verifyingContract", Type: "address"},
+	{Name: "salt", Type: "bytes32"},
+}
+
+// ParseTypedData parses a JSON typed data document. Numbers keep their full precision.
+func ParseTypedData(data []byte) (*TypedData, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var td TypedData
+	if err := dec.Decode(&td); err != nil {
+		return nil, fmt.Errorf("invalid typed data: %v", err)
+	}
+	if td.PrimaryType == "" {
+		return nil, fmt.Errorf("invalid typed data: missing primaryType")
+	}
+	if td.fields(td.PrimaryType) == nil {
+		return nil, fmt.Errorf("invalid typed data: primaryType %q is not defined", td.PrimaryType)
+	}
+	return &td, nil
+}
+
+// fields returns the fields of the struct type typ, or nil if it is not defined. The
+// domain type is derived from the domain's fields when it is not defined.
+func (td *TypedData) fields(typ string) []TypedDataField {
+	if fields, ok := td.Types[typ]; ok {
+		return fields
+	}
+	if typ != domainType {
+		return nil
+	}
+	var fields []TypedDataField
+	for _, f := range domainFields {
+		if _, ok := td.Domain[f.Name]; ok {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// Hash returns the EIP-712 hash to sign: keccak256(0x1901 || domainSeparator || hashStruct(message)).
+func (td *TypedData) Hash() ([]byte, error) {
+	domain, err := td.DomainSeparator()
+	if err != nil {
+		return nil, err
+	}
+	if td.PrimaryType == domainType {
+		return crypto.Keccak256([]byte{0x19, 0x01}, domain), nil
+	}
+	msg, err := td.HashStruct(td.PrimaryType, td.Message)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Keccak256([]byte{0x19, 0x01}, domain, msg), nil
+}
+
+// DomainSeparator returns the hash of the domain.
+func (td *TypedData) DomainSeparator() ([]byte, error) {
+	return td.HashStruct(domainType, td.Domain)
+}
+
+// HashStruct returns the hash of data as the struct type typ.
+func (td *TypedData) HashStruct(typ string, data map[string]interface{}) ([]byte, error) {
+	fields := td.fields(typ)
+	if fields == nil {
+		return nil, fmt.Errorf("type %q is not defined", typ)
+	}
+	enc := td.TypeHash(typ)
+	for _, f := range fields {
+		v, ok := data[f.Name]
+		if !ok {
+			return nil, fmt.Errorf("missing value for %s.%s", typ, f.Name)
+		}
+		b, err := td.encodeValue(f.Type, v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s.%s: %v", typ, f.Name, err)
+		}
+		enc = append(enc, b...)
+	}
+	return crypto.Keccak256(enc), nil
+}
+
+// TypeHash returns the hash of the encoding of the struct type typ.
+func (td *TypedData) TypeHash(typ string) []byte {
+	return crypto.Keccak256([]byte(td.EncodeType(typ)))
+}
+
+// EncodeType returns the encoding of the struct type typ, followed by the struct types it
+// references, sorted by name, e.g: Mail(Person from,Person to,string contents)Person(string name,address wallet)
+func (td *TypedData) EncodeType(typ string) string {
+	deps := map[string]bool{}
+	td.dependencies(typ, deps)
+	delete(deps, typ)
+	names := make([]string, 0, len(deps))
+	for name := range deps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, name := range append([]string{typ}, names...) {
+		sb.WriteString(name)
+		sb.WriteString("(")
+		for i, f := range td.fields(name) {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString(f.Type + " " + f.Name)
+		}
+		sb.WriteString(")")
+	}
+	return sb.String()
+}
+
+// dependencies adds typ and the struct types it references to deps.
+func (td *TypedData) dependencies(typ string, deps map[string]bool) {
+	if i := strings.Index(typ, "["); i >= 0 {
+		typ = typ[:i]
+	}
+	if deps[typ] {
+		return
+	}
+	fields := td.fields(typ)
+	if fields == nil {
+		return
+	}
+	deps[typ] = true
+	for _, f := range fields {
+		td.dependencies(f.Type, deps)
+	}
+}
+
+// encodeValue returns the 32 byte encoding of v as typ.
+func (td *TypedData) encodeValue(typ string, v interface{}) ([]byte, error) {
+	if strings.HasSuffix(typ, "]") {
+		i := strings.LastIndex(typ, "[")
+		elemType, length := typ[:i], typ[i+1:len(typ)-1]
+		arr, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected array but got %T", v)
+		}
+		if length != "" {
+			if n, err := strconv.Atoi(length); err != nil || n != len(arr) {
+				return nil, fmt.Errorf("expected %s elements but got %d", length, len(arr))
+			}
+		}
+		var enc []byte
+		for i, e := range arr {
+			b, err := td.encodeValue(elemType, e)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %v", i, err)
+			}
+			enc = append(enc, b...)
+		}
+		return crypto.Keccak256(enc), nil
+	}
+	if td.fields(typ) != nil {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected struct but got %T", v)
+		}
+		return td.HashStruct(typ, m)
+	}
+
+	switch {
+	case typ == "string":
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string but got %T", v)
+		}
+		return crypto.Keccak256([]byte(s)), nil
+
+	case typ == "bytes":
+		b, err := typedBytes(v)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.Keccak256(b), nil
+
+	case typ == "bool":
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected bool but got %T", v)
+		}
+		if b {
+			return common.LeftPadBytes([]byte{1}, 32), nil
+		}
+		return make([]byte, 32), nil
+
+	case typ == "address":
+		s, ok := v.(string)
+		if !ok || !common.IsHexAddress(s) {
+			return nil, fmt.Errorf("invalid address %v", v)
+		}
+		return common.LeftPadBytes(common.HexToAddress(s).Bytes(), 32), nil
+
+	case strings.HasPrefix(typ, "bytes"):
+		n, err := strconv.Atoi(typ[len("bytes"):])
+		if err != nil || n < 1 || n > 32 {
+			return nil, fmt.Errorf("unsupported type %q", typ)
+		}
+		b, err := typedBytes(v)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) > n {
+			return nil, fmt.Errorf("expected at most %d bytes but got %d", n, len(b))
+		}
+		return common.RightPadBytes(b, 32), nil
+
+	case strings.HasPrefix(typ, "uint"), strings.HasPrefix(typ, "int"):
+		signed := strings.HasPrefix(typ, "int")
+		size := strings.TrimPrefix(strings.TrimPrefix(typ, "u"), "int")
+		bits := 256
+		if size != "" {
+			var err error
+			if bits, err = strconv.Atoi(size); err != nil || bits < 8 || bits > 256 || bits%8 != 0 {
+				return nil, fmt.Errorf("unsupported type %q", typ)
+			}
+		}
+		i, err := typedInt(v)
+		if err != nil {
+			return nil, err
+		}
+		min, max := new(big.Int), new(big.Int).Lsh(big.NewInt(1), uint(bits))
+		if signed {
+			max.Rsh(max, 1)
+			min.Neg(max)
+		}
+		if i.Cmp(min) < 0 || i.Cmp(max) >= 0 {
+			return nil, fmt.Errorf("%s out of range for %s", i, typ)
+		}
+		return math.PaddedBigBytes(math.U256(i), 32), nil
+	}
+	return nil, fmt.Errorf("unsupported type %q", typ)
+}
+
+// typedBytes returns v, a hex string, as bytes.
+func typedBytes(v interface{}) ([]byte, error) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected hex string but got %T", v)
+	}
+	return hexutil.Decode(s)
+}
+
+// typedInt returns v, a JSON number or a decimal or hex string, as an integer.
+func typedInt(v interface{}) (*big.Int, error) {
+	var s string
+	switch v := v.(type) {
+	case json.Number:
+		s = v.String()
+	case string:
+		s = v
+	case float64:
+		if v != gomath.Trunc(v) {
+			return nil, fmt.Errorf("expected integer but got %v", v)
+		}
+		i, _ := big.NewFloat(v).Int(nil)
+		return i, nil
+	default:
+		return nil, fmt.Errorf("expected integer but got %T", v)
+	}
+	i, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer %q", s)
+	}
+	return i, nil
+}
+
+// SignTypedData returns a 65 byte [R || S || V] signature of the EIP-712 hash of td, as
+// in eth_signTypedData_v4. V is 27 or 28.
+func (a *Account) SignTypedData(td *TypedData) ([]byte, error) {
+	hash, err := td.Hash()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := a.SignHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+// RecoverTypedDataAddress returns the address which signed the EIP-712 hash of td.
+func RecoverTypedDataAddress(td *TypedData, sig []byte) (common.Address, error) {
+	hash, err := td.Hash()
+	if err != nil {
+		return common.Address{}, err
+	}
+	return RecoverHashAddress(hash, sig)
+}
@@ -0,0 +1,145 @@
+package web3
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/common/hexutil"
+	"github.com/gochain-io/gochain/v3/crypto"
+)
+
+// mailTypedData is the example of the EIP-712 specification.
+const mailTypedData = `{
+  "types": {
+    "EIP712Domain": [
+      {"name": "name", "type": "string"},
+      {"name": "version", "type": "string"},
+      {"name": "chainId", "type": "uint256"},
+      {"name": "verifyingContract", "type": "address"}
+    ],
+    "Person": [
+      {"name": "name", "type": "string"},
+      {"name": "wallet", "type": "address"}
+    ],
+    "Mail": [
+      {"name": "from", "type": "Person"},
+      {"name": "to", "type": "Person"},
+      {"name": "contents", "type": "string"}
+    ]
+  },
+  "primaryType": "Mail",
+  "domain": {
+    "name": "Ether Mail",
+    "version": "1",
+    "chainId": 1,
+    "verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+  },
+  "message": {
+    "from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+    "to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+    "contents": "Hello, Bob!"
+  }
+}`
+
+func TestTypedData(t *testing.T) {
+	td, err := ParseTypedData([]byte(mailTypedData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := td.EncodeType("Mail"), "Mail(Person from,Person to,string contents)Person(string name,address wallet)"; got != exp {
+		t.Errorf("expected type encoding %q but got %q", exp, got)
+	}
+	for _, test := range []struct {
+		name string
+		hash func() ([]byte, error)
+		exp  string
+	}{
+		{"type hash", func() ([]byte, error) { return td.TypeHash("Mail"), nil }, "0xa0cedeb2dc280ba39b857546d74f5549c3a1d7bdc2dd96bf881f76108e23dac2"},
+		{"struct hash", func() ([]byte, error) { return td.HashStruct("Mail", td.Message) }, "0xc52c0ee5d84264471806290a3f2c4cecfc5490626bf912d01f240d7a274b371e"},
+		{"domain separator", td.DomainSeparator, "0xf2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f"},
+		{"hash", td.Hash, "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2"},
+	} {
+		hash, err := test.hash()
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if got := hexutil.Encode(hash); got != test.exp {
+			t.Errorf("expected %s %s but got %s", test.name, test.exp, got)
+		}
+	}
+
+	// The domain type is derived from the domain when omitted.
+	delete(td.Types, domainType)
+	if sep, err := td.DomainSeparator(); err != nil {
+		t.Fatal(err)
+	} else if got := hexutil.Encode(sep); got != "0xf2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f" {
+		t.Errorf("unexpected derived domain separator %s", got)
+	}
+
+	acct := &Account{key: crypto.ToECDSAUnsafe(crypto.Keccak256([]byte("cow")))}
+	sig, err := NewAccountSigner(acct).SignTypedData(context.Background(), td)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const exp = "0x4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b915621c"
+	if got := hexutil.Encode(sig); got != exp {
+		t.Errorf("expected signature %s but got %s", exp, got)
+	}
+	if addr, err := RecoverTypedDataAddress(td, sig); err != nil {
+		t.Fatal(err)
+	} else if addr != common.HexToAddress("0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826") {
+		t.Errorf("unexpected signer %s", addr.Hex())
+	}
+
+	td.Message["contents"] = 1
+	if _, err := td.Hash(); err == nil {
+		t.Error("expected error for invalid string")
+	}
+	delete(td.Message, "contents")
+	if _, err := td.Hash(); err == nil {
+		t.Error("expected error for missing field")
+	}
+}
+
+func TestTypedData_encodeValue(t *testing.T) {
+	td := &TypedData{Types: map[string][]TypedDataField{
+		"Item": {{Name: "id", Type: "uint8"}},
+	}}
+	for _, test := range []struct {
+		typ   string
+		value interface{}
+		err   bool
+	}{
+		{typ: "uint8", value: "255"},
+		{typ: "uint8", value: "256", err: true},
+		{typ: "uint8", value: "-1", err: true},
+		{typ: "int8", value: "-128"},
+		{typ: "int8", value: "128", err: true},
+		{typ: "uint256", value: "0xff"},
+		{typ: "uint256", value: 1.5, err: true},
+		{typ: "bytes4", value: "0x01020304"},
+		{typ: "bytes4", value: "0x0102030405", err: true},
+		{typ: "bytes", value: "0x0102030405"},
+		{typ: "bool", value: true},
+		{typ: "address", value: "0x01", err: true},
+		{typ: "string[]", value: []interface{}{"a", "b"}},
+		{typ: "string[3]", value: []interface{}{"a", "b"}, err: true},
+		{typ: "Item[]", value: []interface{}{map[string]interface{}{"id": "1"}}},
+		{typ: "Item", value: map[string]interface{}{}, err: true},
+		{typ: "fixed128x18", value: "1", err: true},
+	} {
+		b, err := td.encodeValue(test.typ, test.value)
+		if test.err {
+			if err == nil {
+				t.Errorf("%s %v: expected error", test.typ, test.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %v: %v", test.typ, test.value, err)
+		} else if len(b) != 32 {
+			t.Errorf("%s %v: expected 32 bytes but got %d", test.typ, test.value, len(b))
+		}
+	}
+}
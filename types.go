@@ -16,6 +16,28 @@ type CallMsg struct {
 	GasPrice *big.Int        // wei <-> gas exchange ratio
 	Value    *big.Int        // amount of wei sent along with the call
 	Data     []byte          // input data, usually an ABI-encoded contract method invocation
+
+	BlockNumber *big.Int // the block to execute Call against, nil means latest
+}
+
+// FilterQuery contains options for log filtering.
+type FilterQuery struct {
+	FromBlock *big.Int         // beginning of the queried range, nil means the node default (latest block)
+	ToBlock   *big.Int         // end of the range, nil means latest block
+	Addresses []common.Address // restricts matches to events created by specific contracts
+
+	// The topic list restricts matches to particular event topics. Each event has a list
+	// of topics. Topics matches a prefix of that list. An empty element slice matches any
+	// topic. Non-empty elements represent an alternative that matches any of the
+	// contained topics.
+	//
+	// Examples:
+	// {} or nil          matches any topic list
+	// {{A}}              matches topic A in first position
+	// {{}, {B}}          matches any topic in first position AND B in second position
+	// {{A}, {B}}         matches topic A in first position AND B in second position
+	// {{A, B}, {C, D}}   matches topic (A OR B) in first position AND (C OR D) in second position
+	Topics [][]common.Hash
 }
 
 type Snapshot struct {
@@ -56,6 +78,7 @@ type Receipt struct {
 	ContractAddress   common.Address
 	GasUsed           uint64
 	ParsedLogs        []Event
+	RevertReason      string // only set for failed transactions, see RevertReason
 	BlockHash         common.Hash
 	BlockNumber       uint64
 	From              common.Address
@@ -174,3 +197,37 @@ func (t *Transaction) MarshalJSON() ([]byte, error) {
 	r.copyFrom(t)
 	return json.Marshal(&r)
 }
+
+// AccountProof is the result of GetProof: an account's state, and Merkle proofs of it
+// and of some of its storage slots. See VerifyProof.
+type AccountProof struct {
+	Address      common.Address
+	AccountProof [][]byte // trie nodes from the state root to the account
+	Balance      *big.Int
+	CodeHash     common.Hash
+	Nonce        uint64
+	StorageHash  common.Hash // root of the account's storage trie
+	StorageProof []StorageProof
+}
+
+// StorageProof is a Merkle proof of the value of a storage slot.
+type StorageProof struct {
+	Key   common.Hash
+	Value *big.Int
+	Proof [][]byte // trie nodes from the storage root to the slot
+}
+
+func (p *AccountProof) UnmarshalJSON(data []byte) error {
+	var r rpcAccountProof
+	err := json.Unmarshal(data, &r)
+	if err != nil {
+		return err
+	}
+	return r.copyTo(p)
+}
+
+func (p *AccountProof) MarshalJSON() ([]byte, error) {
+	var r rpcAccountProof
+	r.copyFrom(p)
+	return json.Marshal(&r)
+}
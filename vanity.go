@@ -0,0 +1,203 @@
+package web3
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/crypto"
+)
+
+// VanityOptions configures SearchVanity. At least one of Prefix, Suffix and Regexp must be
+// set, and all which are set must match.
+type VanityOptions struct {
+	// Prefix and Suffix are hex digits, optionally prefixed with 0x.
+	Prefix string
+	Suffix string
+	// Regexp is matched against the 40 hex digits of the address, without 0x.
+	Regexp *regexp.Regexp
+	// CaseSensitive matches against the EIP-55 checksummed address. Otherwise the
+	// address and patterns are lower case.
+	CaseSensitive bool
+	// Contract matches the address of the first contract deployed by the account with
+	// CREATE, instead of the account's address.
+	Contract bool
+	// Workers is the number of parallel searches. When 0, runtime.NumCPU() is used.
+	Workers int
+	// Progress is called every ProgressInterval (default 1s) while searching.
+	Progress         func(VanityProgress)
+	ProgressInterval time.Duration
+}
+
+// VanityProgress reports the progress of SearchVanity.
+type VanityProgress struct {
+	Attempts uint64
+	Elapsed  time.Duration
+	Rate     float64 // attempts per second
+	// Difficulty is the expected number of attempts to find a match, or 0 if unknown, e.g.
+	// for regular expressions.
+	Difficulty float64
+	// ETA is the expected time to find a match at the current rate, or 0 if unknown. Since
+	// each attempt is independent, it does not decrease with the attempts made. It is
+	// capped at the maximum time.Duration.
+	ETA time.Duration
+}
+
+// VanityResult is an account found by SearchVanity.
+type VanityResult struct {
+	Account  *Account
+	Address  common.Address
+	Contract common.Address // the address of the first contract, if VanityOptions.Contract
+	Attempts uint64
+}
+
+// VanityDifficulty returns the expected number of attempts to find a match for the
+// prefix and suffix of opts, or 0 if there are none. Regexp is not accounted for.
+func VanityDifficulty(opts VanityOptions) float64 {
+	digits := strings.TrimPrefix(opts.Prefix, "0x") + strings.TrimPrefix(opts.Suffix, "0x")
+	if digits == "" {
+		return 0
+	}
+	d := math.Pow(16, float64(len(digits)))
+	if opts.CaseSensitive {
+		// Checksummed letters are upper case about half of the time.
+		for _, c := range digits {
+			if strings.ContainsRune("abcdefABCDEF", c) {
+				d *= 2
+			}
+		}
+	}
+	return d
+}
+
+// vanityMatcher matches addresses against normalized VanityOptions patterns.
+type vanityMatcher struct {
+	prefix, suffix string
+	re             *regexp.Regexp
+	caseSensitive  bool
+}
+
+func newVanityMatcher(opts VanityOptions) (*vanityMatcher, error) {
+	m := &vanityMatcher{
+		prefix:        strings.TrimPrefix(opts.Prefix, "0x"),
+		suffix:        strings.TrimPrefix(opts.Suffix, "0x"),
+		re:            opts.Regexp,
+		caseSensitive: opts.CaseSensitive,
+	}
+	if m.prefix == "" && m.suffix == "" && m.re == nil {
+		return nil, errors.New("missing prefix, suffix or regexp")
+	}
+	if len(m.prefix)+len(m.suffix) > 2*common.AddressLength {
+		return nil, fmt.Errorf("prefix and suffix are longer than %d hex digits", 2*common.AddressLength)
+	}
+	for _, s := range []string{m.prefix, m.suffix} {
+		if _, err := hex.DecodeString(s + strings.Repeat("0", len(s)%2)); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: must be hex digits", s)
+		}
+	}
+	if !m.caseSensitive {
+		m.prefix, m.suffix = strings.ToLower(m.prefix), strings.ToLower(m.suffix)
+	}
+	return m, nil
+}
+
+func (m *vanityMatcher) match(addr common.Address) bool {
+	var s string
+	if m.caseSensitive {
+		s = addr.Hex()[2:]
+	} else {
+		s = hex.EncodeToString(addr[:])
+	}
+	return strings.HasPrefix(s, m.prefix) && strings.HasSuffix(s, m.suffix) && (m.re == nil || m.re.MatchString(s))
+}
+
+// SearchVanity generates random accounts in parallel until one matches opts, or ctx is
+// done.
+func SearchVanity(ctx context.Context, opts VanityOptions) (*VanityResult, error) {
+	m, err := newVanityMatcher(opts)
+	if err != nil {
+		return nil, err
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+
+	var attempts uint64
+	found := make(chan *VanityResult, 1)
+	errs := make(chan error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				acct, err := CreateAccount()
+				if err != nil {
+					errs <- err
+					return
+				}
+				n := atomic.AddUint64(&attempts, 1)
+				r := VanityResult{Account: acct, Address: crypto.PubkeyToAddress(acct.key.PublicKey), Attempts: n}
+				addr := r.Address
+				if opts.Contract {
+					r.Contract = crypto.CreateAddress(r.Address, 0)
+					addr = r.Contract
+				}
+				if m.match(addr) {
+					select {
+					case found <- &r:
+					default:
+					}
+					return
+				}
+			}
+		}()
+	}
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	interval := opts.ProgressInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	difficulty := VanityDifficulty(opts)
+	start := time.Now()
+	for {
+		select {
+		case r := <-found:
+			return r, nil
+		case err := <-errs:
+			return nil, err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+			if opts.Progress == nil {
+				continue
+			}
+			p := VanityProgress{Attempts: atomic.LoadUint64(&attempts), Elapsed: time.Since(start), Difficulty: difficulty}
+			p.Rate = float64(p.Attempts) / p.Elapsed.Seconds()
+			if difficulty > 0 && p.Rate > 0 {
+				p.ETA = time.Duration(math.MaxInt64)
+				if secs := difficulty / p.Rate; secs < p.ETA.Seconds() {
+					p.ETA = time.Duration(secs * float64(time.Second))
+				}
+			}
+			opts.Progress(p)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package web3
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gochain-io/gochain/v3/crypto"
+)
+
+func TestSearchVanity(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		name string
+		opts VanityOptions
+		ok   func(addr string) bool
+	}{
+		{
+			name: "prefix",
+			opts: VanityOptions{Prefix: "0xA"},
+			ok:   func(addr string) bool { return strings.HasPrefix(strings.ToLower(addr), "0xa") },
+		},
+		{
+			name: "suffix",
+			opts: VanityOptions{Suffix: "0", Workers: 2},
+			ok:   func(addr string) bool { return strings.HasSuffix(addr, "0") },
+		},
+		{
+			name: "suffix with 0x",
+			opts: VanityOptions{Suffix: "0xa"},
+			ok:   func(addr string) bool { return strings.HasSuffix(strings.ToLower(addr), "a") },
+		},
+		{
+			name: "regexp",
+			opts: VanityOptions{Regexp: regexp.MustCompile("^[0-9]{2}")},
+			ok:   func(addr string) bool { return regexp.MustCompile("^0x[0-9]{2}").MatchString(addr) },
+		},
+		{
+			name: "case sensitive",
+			opts: VanityOptions{Prefix: "B", CaseSensitive: true},
+			ok:   func(addr string) bool { return strings.HasPrefix(addr, "0xB") },
+		},
+		{
+			name: "contract",
+			opts: VanityOptions{Prefix: "f", Contract: true},
+			ok:   func(addr string) bool { return strings.HasPrefix(strings.ToLower(addr), "0xf") },
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			r, err := SearchVanity(ctx, test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Address.Hex() != r.Account.PublicKey() {
+				t.Errorf("expected address %s of the account but got %s", r.Account.PublicKey(), r.Address.Hex())
+			}
+			addr := r.Address
+			if test.opts.Contract {
+				addr = crypto.CreateAddress(r.Address, 0)
+				if r.Contract != addr {
+					t.Errorf("expected contract address %s but got %s", addr.Hex(), r.Contract.Hex())
+				}
+			}
+			if !test.ok(addr.Hex()) {
+				t.Errorf("address %s does not match", addr.Hex())
+			}
+			if r.Attempts == 0 {
+				t.Error("expected attempts")
+			}
+		})
+	}
+
+	for _, opts := range []VanityOptions{
+		{},
+		{Prefix: "0xgo"},
+		{Suffix: "0xgo"},
+		{Suffix: strings.Repeat("0", 41)},
+	} {
+		if _, err := SearchVanity(ctx, opts); err == nil {
+			t.Errorf("%+v: expected error", opts)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := SearchVanity(ctx, VanityOptions{Prefix: strings.Repeat("0", 40)}); err != context.Canceled {
+		t.Errorf("expected context.Canceled but got %v", err)
+	}
+}
+
+func TestVanityDifficulty(t *testing.T) {
+	for _, test := range []struct {
+		opts VanityOptions
+		exp  float64
+	}{
+		{VanityOptions{}, 0},
+		{VanityOptions{Regexp: regexp.MustCompile("a")}, 0},
+		{VanityOptions{Prefix: "0x12", Suffix: "3"}, 4096},
+		{VanityOptions{Prefix: "0xAb"}, 256},
+		{VanityOptions{Suffix: "0xabc"}, 4096},
+		{VanityOptions{Prefix: "0xAb", CaseSensitive: true}, 1024},
+	} {
+		if got := VanityDifficulty(test.opts); got != test.exp {
+			t.Errorf("%+v: expected %v but got %v", test.opts, test.exp, got)
+		}
+	}
+}
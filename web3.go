@@ -1,12 +1,11 @@
 package web3
 
 import (
+	"bytes"
 	"context"
-	"crypto/ecdsa"
 	"errors"
 	"fmt"
 	"math/big"
-	"reflect"
 	"strconv"
 	"strings"
 	"time"
@@ -15,7 +14,7 @@ import (
 	"github.com/gochain-io/gochain/v3/common"
 	"github.com/gochain-io/gochain/v3/common/hexutil"
 	"github.com/gochain-io/gochain/v3/core/types"
-	"github.com/gochain-io/gochain/v3/crypto"
+	"github.com/gochain-io/gochain/v3/params"
 	"github.com/gochain-io/gochain/v3/rlp"
 )
 
@@ -28,31 +27,129 @@ const (
 
 var Networks = map[string]Network{
 	"testnet": {
-		URL:  testnetURL,
-		Unit: "GO",
+		URL:     testnetURL,
+		Unit:    "GO",
+		ChainID: big.NewInt(31337),
 	},
 	"gochain": {
-		URL:  mainnetURL,
-		Unit: "GO",
+		URL:     mainnetURL,
+		Unit:    "GO",
+		ChainID: big.NewInt(60),
 	},
 	"localhost": {
 		URL:  "http://localhost:8545",
 		Unit: "GO",
 	},
 	"ethereum": {
-		URL:  "https://main-rpc.linkpool.io",
-		Unit: "ETH",
+		URL:     "https://main-rpc.linkpool.io",
+		Unit:    "ETH",
+		ChainID: big.NewInt(1),
 	},
 	"ropsten": {
-		URL:  "https://ropsten-rpc.linkpool.io",
-		Unit: "ETH",
+		URL:     "https://ropsten-rpc.linkpool.io",
+		Unit:    "ETH",
+		ChainID: big.NewInt(3),
 	},
 }
 
 type Network struct {
-	URL  string
-	Unit string
-	//TODO net_id, chain_id
+	URL     string
+	Unit    string
+	ChainID *big.Int // nil if unknown
+	//TODO net_id
+}
+
+// DefaultGasMultiplier is the safety margin applied to gas estimates, unless overridden by TxOpts.
+const DefaultGasMultiplier = 1.25
+
+// TxOpts holds optional settings for the transaction builders. A nil *TxOpts is
+// equivalent to the zero value.
+type TxOpts struct {
+	// ChainID is used for EIP-155 replay protected signing. When nil, the chain id
+	// reported by the node is used.
+	ChainID *big.Int
+	// GasLimit for the transaction. When 0, the gas is estimated by the node.
+	GasLimit uint64
+	// GasMultiplier is applied to gas estimates. When 0, DefaultGasMultiplier is used.
+	GasMultiplier float64
+	// Nonces hands out nonces to concurrent senders. When nil, the pending transaction
+	// count reported by the node is used.
+	Nonces *NonceManager
+	// Nonce overrides the nonce, e.g. to replace a pending transaction. When set, Nonces
+	// is not used.
+	Nonce *uint64
+}
+
+func (o *TxOpts) chainID(ctx context.Context, client Client) (*big.Int, error) {
+	if o != nil && o.ChainID != nil {
+		return o.ChainID, nil
+	}
+	id, err := client.GetChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get chain id: %v", err)
+	}
+	return id, nil
+}
+
+func (o *TxOpts) gasLimit(ctx context.Context, client Client, msg CallMsg) (uint64, error) {
+	if o != nil && o.GasLimit != 0 {
+		return o.GasLimit, nil
+	}
+	gas, err := client.EstimateGas(ctx, msg)
+	if err != nil {
+		return 0, fmt.Errorf("cannot estimate gas: %v", err)
+	}
+	if gas == params.TxGas {
+		// Plain transfers always cost exactly the intrinsic gas.
+		return gas, nil
+	}
+	mul := DefaultGasMultiplier
+	if o != nil && o.GasMultiplier != 0 {
+		mul = o.GasMultiplier
+	}
+	return uint64(float64(gas) * mul), nil
+}
+
+func (o *TxOpts) nonces() *NonceManager {
+	if o == nil || o.Nonce != nil {
+		return nil
+	}
+	return o.Nonces
+}
+
+func (o *TxOpts) nonce(ctx context.Context, client Client, from common.Address) (uint64, error) {
+	if o != nil && o.Nonce != nil {
+		return *o.Nonce, nil
+	}
+	var nonce uint64
+	var err error
+	if m := o.nonces(); m != nil {
+		nonce, err = m.Next(ctx, from)
+	} else {
+		nonce, err = client.GetPendingTransactionCount(ctx, from)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("cannot get nonce: %v", err)
+	}
+	return nonce, nil
+}
+
+func (o *TxOpts) releaseNonce(from common.Address, nonce uint64) {
+	if m := o.nonces(); m != nil {
+		m.Release(from, nonce)
+	}
+}
+
+// CheckChainID returns an error if the chain id reported by the node does not match expected.
+func CheckChainID(ctx context.Context, client Client, expected *big.Int) error {
+	actual, err := client.GetChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot get chain id: %v", err)
+	}
+	if actual.Cmp(expected) != 0 {
+		return fmt.Errorf("chain id mismatch: expected %s but the network reported %s", expected, actual)
+	}
+	return nil
 }
 
 var (
@@ -70,40 +167,27 @@ func WeiAsGwei(w *big.Int) string {
 	return new(big.Rat).SetFrac(w, weiPerGwei).FloatString(9)
 }
 
-func convertOutputParameter(t abi.Argument) interface{} {
-	switch t.Type.T {
-	case abi.BoolTy:
-		return new(bool)
-	case abi.UintTy, abi.IntTy:
-		return new(big.Int)
-	case abi.StringTy:
-		return new(string)
-	case abi.AddressTy:
-		return new(common.Address)
-	case abi.BytesTy, abi.FixedBytesTy:
-		return new([]byte)
-	default:
-		return new(string)
-	}
-}
-
 // CallConstantFunction executes a contract function call without submitting a transaction.
 func CallConstantFunction(ctx context.Context, client Client, myabi abi.ABI, address, functionName string, parameters ...interface{}) (interface{}, error) {
 	if address == "" {
 		return nil, errors.New("no contract address specified")
 	}
-	var out []interface{}
-	for _, t := range myabi.Methods[functionName].Outputs {
-		out = append(out, convertOutputParameter(t))
+	method, ok := myabi.Methods[functionName]
+	if !ok {
+		return nil, fmt.Errorf("no such function %q", functionName)
 	}
-	if len(myabi.Methods[functionName].Inputs) != len(parameters) {
-		return nil, errors.New("Wrong number of arguments expected:" + strconv.Itoa(len(myabi.Methods[functionName].Inputs)) + " given:" + strconv.Itoa(len(parameters)))
+	if len(method.Inputs) != len(parameters) {
+		return nil, errors.New("Wrong number of arguments expected:" + strconv.Itoa(len(method.Inputs)) + " given:" + strconv.Itoa(len(parameters)))
 	}
-
-	input, err := myabi.Pack(functionName, convertParameters(myabi.Methods[functionName], parameters)...)
+	args, err := convertParameters(method.Inputs, parameters)
 	if err != nil {
 		return nil, err
 	}
+	packed, err := packArguments(method.Inputs, args)
+	if err != nil {
+		return nil, err
+	}
+	input := append(MethodID(method), packed...)
 
 	toAddress := common.HexToAddress(address)
 
@@ -112,171 +196,168 @@ func CallConstantFunction(ctx context.Context, client Client, myabi abi.ABI, add
 		return nil, err
 
 	}
-	if len(myabi.Methods[functionName].Outputs) > 1 {
-		err = myabi.Unpack(&out, functionName, res)
+	if isRevertData(res) {
+		reason, _ := DecodeRevert(res, nil)
+		return nil, &RevertError{Reason: reason, Data: res}
+	}
+	switch len(method.Outputs) {
+	case 0:
+		return nil, nil
+	case 1:
+		out, err := unpackValues(method.Outputs, res)
 		if err != nil {
 			return nil, err
 		}
-		for i, o := range out {
-			out[i] = reflect.ValueOf(o).Elem().Interface()
-		}
-		return out, nil
-	}
-	err = myabi.Unpack(&out[0], functionName, res)
-	if err != nil {
-		return nil, err
+		return out[0], nil
+	default:
+		return unpackValues(method.Outputs, res)
 	}
-	return out[0], nil
 }
 
 // CallTransactFunction submits a transaction to execute a smart contract function call.
-func CallTransactFunction(ctx context.Context, client Client, myabi abi.ABI, address, privateKeyHex, functionName string, amount int, parameters ...interface{}) (*Transaction, error) {
+func CallTransactFunction(ctx context.Context, client Client, myabi abi.ABI, address string, signer Signer, opts *TxOpts, functionName string, amount int, parameters ...interface{}) (*Transaction, error) {
 	if address == "" {
 		return nil, errors.New("no contract address specified")
 	}
 
-	if len(myabi.Methods[functionName].Inputs) != len(parameters) {
-		return nil, errors.New("Wrong number of arguments expected:" + strconv.Itoa(len(myabi.Methods[functionName].Inputs)) + " given:" + strconv.Itoa(len(parameters)))
+	method, ok := myabi.Methods[functionName]
+	if !ok {
+		return nil, fmt.Errorf("no such function %q", functionName)
 	}
-
-	input, err := myabi.Pack(functionName, convertParameters(myabi.Methods[functionName], parameters)...)
+	if len(method.Inputs) != len(parameters) {
+		return nil, errors.New("Wrong number of arguments expected:" + strconv.Itoa(len(method.Inputs)) + " given:" + strconv.Itoa(len(parameters)))
+	}
+	args, err := convertParameters(method.Inputs, parameters)
 	if err != nil {
 		return nil, err
 	}
-	if len(privateKeyHex) > 2 && privateKeyHex[:2] == "0x" {
-		privateKeyHex = privateKeyHex[2:]
-	}
-	privateKey, err := crypto.HexToECDSA(privateKeyHex)
+	packed, err := packArguments(method.Inputs, args)
 	if err != nil {
-		return nil, fmt.Errorf("invalid private key: %v", err)
+		return nil, err
 	}
+	input := append(MethodID(method), packed...)
 	gasPrice, err := client.GetGasPrice(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("cannot get gas price: %v", err)
 	}
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, errors.New("error casting public key to ECDSA")
-	}
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
-	nonce, err := client.GetPendingTransactionCount(ctx, fromAddress)
-	if err != nil {
-		return nil, fmt.Errorf("cannot get nonce: %v", err)
-	}
 	toAddress := common.HexToAddress(address)
-	tx := types.NewTransaction(nonce, toAddress, big.NewInt(int64(amount)), 20000000, gasPrice, input)
-	signedTx, err := types.SignTx(tx, types.HomesteadSigner{}, privateKey)
-	if err != nil {
-		return nil, fmt.Errorf("cannot sign transaction: %v", err)
-	}
-	raw, err := rlp.EncodeToBytes(signedTx)
+	value := big.NewInt(int64(amount))
+	gasLimit, err := opts.gasLimit(ctx, client, CallMsg{From: signer.Address(), To: &toAddress, Value: value, Data: input})
 	if err != nil {
 		return nil, err
 	}
-	err = client.SendRawTransaction(ctx, raw)
-	if err != nil {
-		return nil, fmt.Errorf("cannot send transaction: %v", err)
-	}
-	return convertTx(signedTx, fromAddress), nil
+	return signAndSend(ctx, client, signer, opts, func(nonce uint64) *types.Transaction {
+		return types.NewTransaction(nonce, toAddress, value, gasLimit, gasPrice, input)
+	})
 }
 
 // DeployContract submits a contract creation transaction.
 // abiJSON is only required when including params for the constructor.
-func DeployContract(ctx context.Context, client Client, privateKeyHex string, binHex, abiJSON string, params ...interface{}) (*Transaction, error) {
-	if len(privateKeyHex) > 2 && privateKeyHex[:2] == "0x" {
-		privateKeyHex = privateKeyHex[2:]
-	}
-	privateKey, err := crypto.HexToECDSA(privateKeyHex)
-	if err != nil {
-		return nil, fmt.Errorf("invalid private key: %v", err)
-	}
-
+func DeployContract(ctx context.Context, client Client, signer Signer, opts *TxOpts, binHex, abiJSON string, params ...interface{}) (*Transaction, error) {
 	gasPrice, err := client.GetGasPrice(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("cannot get gas price: %v", err)
 	}
-
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, errors.New("error casting public key to ECDSA")
-	}
-
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
-	nonce, err := client.GetPendingTransactionCount(ctx, fromAddress)
-	if err != nil {
-		return nil, fmt.Errorf("cannot get nonce: %v", err)
-	}
 	binData, err := hexutil.Decode(binHex)
 	if err != nil {
 		return nil, fmt.Errorf("cannot decode contract data: %v", err)
 	}
 	if len(params) > 0 {
-		abiData, err := abi.JSON(strings.NewReader(abiJSON))
+		abiData, err := parseABI(strings.NewReader(abiJSON))
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse ABI: %v", err)
 		}
-		input, err := abiData.Pack("", convertParameters(abiData.Constructor, params)...)
+		args, err := convertParameters(abiData.Constructor.Inputs, params)
+		if err != nil {
+			return nil, err
+		}
+		input, err := packArguments(abiData.Constructor.Inputs, args)
 		if err != nil {
 			return nil, fmt.Errorf("cannot pack parameters: %v", err)
 		}
 		binData = append(binData, input...)
 	}
-	//TODO try to use web3.Transaction only; can't sign currently
-	tx := types.NewContractCreation(nonce, big.NewInt(0), 2000000, gasPrice, binData)
-	signedTx, err := types.SignTx(tx, types.HomesteadSigner{}, privateKey)
-	if err != nil {
-		return nil, fmt.Errorf("cannot sign transaction: %v", err)
-	}
-	raw, err := rlp.EncodeToBytes(signedTx)
+	gasLimit, err := opts.gasLimit(ctx, client, CallMsg{From: signer.Address(), Data: binData})
 	if err != nil {
 		return nil, err
 	}
-	err = client.SendRawTransaction(ctx, raw)
-	if err != nil {
-		return nil, fmt.Errorf("cannot send transaction: %v", err)
-	}
-
-	return convertTx(signedTx, fromAddress), nil
+	//TODO try to use web3.Transaction only; can't sign currently
+	return signAndSend(ctx, client, signer, opts, func(nonce uint64) *types.Transaction {
+		return types.NewContractCreation(nonce, big.NewInt(0), gasLimit, gasPrice, binData)
+	})
 }
 
-func Send(ctx context.Context, client Client, privateKeyHex string, address common.Address, amount *big.Int) (*Transaction, error) {
-	if len(privateKeyHex) > 2 && privateKeyHex[:2] == "0x" {
-		privateKeyHex = privateKeyHex[2:]
-	}
-	privateKey, err := crypto.HexToECDSA(privateKeyHex)
-	if err != nil {
-		return nil, fmt.Errorf("invalid private key: %v", err)
-	}
+// Send submits a transaction transferring amount wei to address.
+func Send(ctx context.Context, client Client, signer Signer, opts *TxOpts, address common.Address, amount *big.Int) (*Transaction, error) {
 	gasPrice, err := client.GetGasPrice(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("cannot get gas price: %v", err)
 	}
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, errors.New("error casting public key to ECDSA")
-	}
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
-	nonce, err := client.GetPendingTransactionCount(ctx, fromAddress)
-	if err != nil {
-		return nil, fmt.Errorf("cannot get nonce: %v", err)
-	}
-	tx := types.NewTransaction(nonce, address, amount, 21000, gasPrice, nil)
-	signedTx, err := types.SignTx(tx, types.HomesteadSigner{}, privateKey)
+	gasLimit, err := opts.gasLimit(ctx, client, CallMsg{From: signer.Address(), To: &address, Value: amount})
 	if err != nil {
-		return nil, fmt.Errorf("cannot sign transaction: %v", err)
+		return nil, err
 	}
-	raw, err := rlp.EncodeToBytes(signedTx)
+	return signAndSend(ctx, client, signer, opts, func(nonce uint64) *types.Transaction {
+		return types.NewTransaction(nonce, address, amount, gasLimit, gasPrice, nil)
+	})
+}
+
+// maxNonceRetries limits how many times a transaction is retried with a fresh nonce after
+// being rejected with "nonce too low".
+const maxNonceRetries = 3
+
+// signAndSend builds the transaction with the next nonce, signs it with signer for the
+// chain id from opts, and sends it. When opts has a NonceManager, the transaction is
+// retried with a resynced nonce if the node rejects the nonce as used, and the nonce is
+// only released if the node clearly did not accept the transaction.
+func signAndSend(ctx context.Context, client Client, signer Signer, opts *TxOpts, newTx func(nonce uint64) *types.Transaction) (*Transaction, error) {
+	chainID, err := opts.chainID(ctx, client)
 	if err != nil {
 		return nil, err
 	}
-	err = client.SendRawTransaction(ctx, raw)
-	if err != nil {
+	from := signer.Address()
+	for attempt := 0; ; attempt++ {
+		nonce, err := opts.nonce(ctx, client, from)
+		if err != nil {
+			return nil, err
+		}
+		signedTx, err := signer.SignTx(ctx, newTx(nonce), chainID)
+		if err != nil {
+			opts.releaseNonce(from, nonce)
+			return nil, fmt.Errorf("cannot sign transaction: %v", err)
+		}
+		raw, err := rlp.EncodeToBytes(signedTx)
+		if err != nil {
+			opts.releaseNonce(from, nonce)
+			return nil, err
+		}
+		err = client.SendRawTransaction(ctx, raw)
+		if err == nil {
+			return convertTx(signedTx, from), nil
+		}
+		m := opts.nonces()
+		switch {
+		case m != nil && isKnownTransaction(err):
+			// The node already has this exact transaction, so it was sent.
+			if err := m.Resync(ctx, from); err != nil {
+				return nil, fmt.Errorf("cannot resync nonce: %v", err)
+			}
+			return convertTx(signedTx, from), nil
+		case m != nil && isNonceTooLow(err) && attempt < maxNonceRetries:
+			if err := m.Resync(ctx, from); err != nil {
+				return nil, fmt.Errorf("cannot resync nonce: %v", err)
+			}
+			continue
+		}
+		if isRejected(ctx, err) {
+			opts.releaseNonce(from, nonce)
+		} else if m != nil {
+			// The transaction may have been sent, so its nonce stays in-flight until a
+			// resync shows whether it was used.
+			m.Resync(ctx, from)
+		}
 		return nil, fmt.Errorf("cannot send transaction: %v", err)
 	}
-	return convertTx(signedTx, fromAddress), nil
 }
 
 func convertTx(tx *types.Transaction, from common.Address) *Transaction {
@@ -293,29 +374,6 @@ func convertTx(tx *types.Transaction, from common.Address) *Transaction {
 	return rtx
 }
 
-func convertParameters(method abi.Method, inputParams []interface{}) []interface{} {
-	var convertedParams []interface{}
-	for i, input := range method.Inputs {
-		switch input.Type.T {
-		case abi.BoolTy:
-			val, _ := strconv.ParseBool(inputParams[i].(string))
-			convertedParams = append(convertedParams, val)
-		case abi.UintTy:
-			val := new(big.Int)
-			fmt.Sscan(inputParams[i].(string), val)
-			convertedParams = append(convertedParams, val)
-		case abi.AddressTy:
-			val := common.HexToAddress(inputParams[i].(string))
-			convertedParams = append(convertedParams, val)
-		default:
-			val := inputParams[i].(string)
-			convertedParams = append(convertedParams, val)
-		}
-
-	}
-	return convertedParams
-}
-
 // WaitForReceipt polls for a transaction receipt until it is available, or ctx is cancelled.
 func WaitForReceipt(ctx context.Context, client Client, hash common.Hash) (*Receipt, error) {
 	for {
@@ -334,9 +392,10 @@ func WaitForReceipt(ctx context.Context, client Client, hash common.Hash) (*Rece
 	}
 }
 
+// FindEventById returns the event in abi with the given id (topic[0]), or nil if there is none.
 func FindEventById(abi abi.ABI, id common.Hash) *abi.Event {
 	for _, event := range abi.Events {
-		if event.Id() == id {
+		if EventID(event) == id {
 			return &event
 		}
 	}
@@ -352,42 +411,107 @@ func getInputs(args abi.Arguments, indexed bool) []abi.Argument {
 	return out
 }
 
-// func ParseReceipt(myabi abi.ABI, receipt *Receipt) (map[string]map[string]interface{}, error) {
+// FindMethodById returns the method in abi with the given 4 byte id, or nil if there is none.
+func FindMethodById(abi abi.ABI, id []byte) *abi.Method {
+	for _, method := range abi.Methods {
+		if bytes.Equal(MethodID(method), id) {
+			return &method
+		}
+	}
+	return nil
+}
+
+// ParseInput decodes the input data of a call to a method declared in myabi. It returns
+// a nil method if input does not call any method of myabi.
+func ParseInput(myabi abi.ABI, input []byte) (*abi.Method, []interface{}, error) {
+	if len(input) < 4 {
+		return nil, nil, nil
+	}
+	method := FindMethodById(myabi, input[:4])
+	if method == nil {
+		return nil, nil, nil
+	}
+	values, err := unpackValues(method.Inputs, input[4:])
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot decode input of method %q: %v", method.Name, err)
+	}
+	return method, values, nil
+}
+
+// LogsError is returned by ParseLogs when some logs of events declared in the ABI could not
+// be decoded.
+type LogsError struct {
+	// Errors holds an entry for each log, which is nil for logs that were decoded or skipped.
+	Errors []error
+}
+
+func (e *LogsError) Error() string {
+	failed, first := countErrors(e.Errors)
+	return fmt.Sprintf("%d of %d logs could not be decoded, first error: %v", failed, len(e.Errors), first)
+}
+
+// ParseLogs decodes the logs of events declared in myabi. Indexed inputs are decoded from
+// their topics, except for dynamic types, which are returned as an IndexedHash. Logs of
+// events which are not in myabi, such as those emitted by other contracts, are skipped.
+// So are logs with a matching event id but another number of topics, e.g. an ERC-721
+// Transfer parsed with the ERC-20 ABI. If some logs cannot be decoded, the others are
+// returned with a *LogsError.
 func ParseLogs(myabi abi.ABI, logs []*types.Log) ([]Event, error) {
 	var output []Event
-	// output := make(map[string]map[string]interface{})
-	for _, log := range logs {
-		var out []interface{}
-		//event id is always in the first topic
-		event := FindEventById(myabi, log.Topics[0])
-		fields := make(map[string]interface{})
-		nonIndexed := getInputs(event.Inputs, false)
-		for _, t := range nonIndexed {
-			out = append(out, convertOutputParameter(t))
-		}
-		if len(nonIndexed) > 1 {
-			err := myabi.Unpack(&out, event.Name, log.Data)
-			if err != nil {
-				return nil, err
-			}
-			for i, o := range out {
-				fields[nonIndexed[i].Name] = reflect.ValueOf(o).Elem().Interface()
-			}
-		} else {
-			err := myabi.Unpack(&out[0], event.Name, log.Data)
-			if err != nil {
-				return nil, err
+	var errs []error
+	for i, log := range logs {
+		event, err := parseLog(myabi, log)
+		if err != nil {
+			if errs == nil {
+				errs = make([]error, len(logs))
 			}
-			fields[nonIndexed[0].Name] = out[0]
+			errs[i] = err
+			continue
 		}
-		for i, input := range getInputs(event.Inputs, true) {
-			fields[input.Name] = log.Topics[i+1].String()
+		if event != nil {
+			output = append(output, *event)
 		}
-		output = append(output, Event{Name: event.Name, Fields: fields})
+	}
+	if errs != nil {
+		return output, &LogsError{Errors: errs}
 	}
 	return output, nil
 }
 
+// parseLog decodes log, or returns nil if it is not a log of an event declared in myabi.
+func parseLog(myabi abi.ABI, log *types.Log) (*Event, error) {
+	//event id is always in the first topic
+	if len(log.Topics) == 0 {
+		return nil, nil
+	}
+	event := FindEventById(myabi, log.Topics[0])
+	if event == nil {
+		return nil, nil
+	}
+	indexed := getInputs(event.Inputs, true)
+	if len(log.Topics) != len(indexed)+1 {
+		// Another event with the same signature, but other indexed inputs.
+		return nil, nil
+	}
+	fields := make(map[string]interface{})
+	nonIndexed := getInputs(event.Inputs, false)
+	values, err := unpackValues(nonIndexed, log.Data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode data of event %q: %v", event.Name, err)
+	}
+	for i, v := range values {
+		fields[nonIndexed[i].Name] = v
+	}
+	for i, input := range indexed {
+		v, err := decodeTopic(input.Type, log.Topics[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode indexed input %q of event %q: %v", input.Name, event.Name, err)
+		}
+		fields[input.Name] = v
+	}
+	return &Event{Name: event.Name, Fields: fields}, nil
+}
+
 // ParseAmount parses a string (human readable amount with units ie 1go, 1nanogo...) and returns big.Int value of this string in wei/atto
 func ParseAmount(amount string) (*big.Int, error) {
 	var ret = new(big.Int)
@@ -0,0 +1,202 @@
+package web3
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gochain-io/gochain/v3/accounts/abi"
+	"github.com/gochain-io/gochain/v3/common"
+	"github.com/gochain-io/gochain/v3/core/types"
+	"github.com/gochain-io/gochain/v3/params"
+	"github.com/gochain-io/gochain/v3/rlp"
+	"github.com/gochain-io/web3/assets"
+)
+
+const testKey = "0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+
+// txMock is a MockClient for the transaction builders. It reports chainID, estimates
+// gas, and decodes the raw transactions sent.
+type txMock struct {
+	MockClient
+	sent []*types.Transaction
+}
+
+func newTxMock(chainID int64, gas uint64) *txMock {
+	m := &txMock{}
+	m.GetChainIDFunc = func(ctx context.Context) (*big.Int, error) {
+		return big.NewInt(chainID), nil
+	}
+	m.GetGasPriceFunc = func(ctx context.Context) (*big.Int, error) {
+		return big.NewInt(1e9), nil
+	}
+	m.GetPendingTransactionCountFunc = func(ctx context.Context, account common.Address) (uint64, error) {
+		return uint64(len(m.sent)), nil
+	}
+	m.EstimateGasFunc = func(ctx context.Context, msg CallMsg) (uint64, error) {
+		return gas, nil
+	}
+	m.SendRawTransactionFunc = func(ctx context.Context, raw []byte) error {
+		tx := new(types.Transaction)
+		if err := rlp.DecodeBytes(raw, tx); err != nil {
+			return err
+		}
+		m.sent = append(m.sent, tx)
+		return nil
+	}
+	return m
+}
+
+func TestCheckChainID(t *testing.T) {
+	ctx := context.Background()
+	m := newTxMock(60, 0)
+	if err := CheckChainID(ctx, m, big.NewInt(60)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	const exp = "chain id mismatch: expected 1 but the network reported 60"
+	if err := CheckChainID(ctx, m, big.NewInt(1)); err == nil || err.Error() != exp {
+		t.Errorf("expected error %q but got %v", exp, err)
+	}
+	m.GetChainIDFunc = func(ctx context.Context) (*big.Int, error) {
+		return nil, errors.New("method not found")
+	}
+	if err := CheckChainID(ctx, m, big.NewInt(60)); err == nil {
+		t.Error("expected error when the chain id is unavailable")
+	}
+}
+
+func TestSendEIP155(t *testing.T) {
+	ctx := context.Background()
+	acct, err := ParsePrivateKey(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := NewAccountSigner(acct)
+	to := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	for _, test := range []struct {
+		name string
+		opts *TxOpts
+		exp  int64
+	}{
+		{"network chain id", nil, 60},
+		{"override", &TxOpts{ChainID: big.NewInt(31337)}, 31337},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			m := newTxMock(60, 21000)
+			tx, err := Send(ctx, m, signer, test.opts, to, big.NewInt(1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(m.sent) != 1 {
+				t.Fatalf("expected 1 transaction sent but got %d", len(m.sent))
+			}
+			sent := m.sent[0]
+			if !sent.Protected() {
+				t.Fatal("expected a replay protected transaction")
+			}
+			if got := sent.ChainId(); got.Int64() != test.exp {
+				t.Errorf("expected chain id %d but got %s", test.exp, got)
+			}
+			from, err := types.Sender(types.NewEIP155Signer(big.NewInt(test.exp)), sent)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if from != signer.Address() {
+				t.Errorf("expected sender %s but got %s", signer.Address().Hex(), from.Hex())
+			}
+			if tx.Hash != sent.Hash() {
+				t.Errorf("expected hash %s but got %s", sent.Hash().Hex(), tx.Hash.Hex())
+			}
+		})
+	}
+}
+
+func TestSendGasLimit(t *testing.T) {
+	ctx := context.Background()
+	acct, err := ParsePrivateKey(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := NewAccountSigner(acct)
+	to := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	for _, test := range []struct {
+		name     string
+		estimate uint64
+		opts     *TxOpts
+		exp      uint64
+	}{
+		{"default multiplier", 50000, nil, 62500},
+		{"multiplier", 50000, &TxOpts{GasMultiplier: 2}, 100000},
+		{"plain transfer", params.TxGas, &TxOpts{GasMultiplier: 2}, params.TxGas},
+		{"override", 0, &TxOpts{GasLimit: 30000, GasMultiplier: 2}, 30000},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			m := newTxMock(60, test.estimate)
+			if test.estimate == 0 {
+				// The gas must not be estimated.
+				m.EstimateGasFunc = nil
+			}
+			if _, err := Send(ctx, m, signer, test.opts, to, big.NewInt(1)); err != nil {
+				t.Fatal(err)
+			}
+			if got := m.sent[0].Gas(); got != test.exp {
+				t.Errorf("expected gas limit %d but got %d", test.exp, got)
+			}
+		})
+	}
+}
+
+func TestParseLogs(t *testing.T) {
+	myabi, err := abi.JSON(strings.NewReader(assets.ERC20ABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	transfer := myabi.Events["Transfer"].Id()
+	from := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	to := common.HexToAddress("0x0000000000000000000000000000000000000002")
+	amount := common.BigToHash(big.NewInt(1000))
+	logs := []*types.Log{
+		// ERC-20 Transfer.
+		{Topics: []common.Hash{transfer, from.Hash(), to.Hash()}, Data: amount[:]},
+		// ERC-721 Transfer, with an indexed token id.
+		{Topics: []common.Hash{transfer, from.Hash(), to.Hash(), amount}, Data: []byte{}},
+		// Unknown event.
+		{Topics: []common.Hash{{1}}, Data: amount[:]},
+		// Truncated data.
+		{Topics: []common.Hash{transfer, from.Hash(), to.Hash()}, Data: amount[:16]},
+		// No topics.
+		{Data: amount[:]},
+	}
+	events, err := ParseLogs(myabi, logs)
+	exp := []Event{{Name: "Transfer", Fields: map[string]interface{}{
+		"from":  from,
+		"to":    to,
+		"value": big.NewInt(1000),
+	}}}
+	if !reflect.DeepEqual(events, exp) {
+		t.Errorf("expected events %v but got %v", exp, events)
+	}
+	lerr, ok := err.(*LogsError)
+	if !ok {
+		t.Fatalf("expected *LogsError but got %v", err)
+	}
+	if len(lerr.Errors) != len(logs) {
+		t.Fatalf("expected %d errors but got %d", len(logs), len(lerr.Errors))
+	}
+	for i, err := range lerr.Errors {
+		if (err != nil) != (i == 3) {
+			t.Errorf("log %d: unexpected error %v", i, err)
+		}
+	}
+
+	events, err = ParseLogs(myabi, logs[:3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(events, exp) {
+		t.Errorf("expected events %v but got %v", exp, events)
+	}
+}